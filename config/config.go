@@ -1,7 +1,11 @@
 package config
 
 import (
+	"errors"
+	"fmt"
+	"os"
 	"path"
+	"path/filepath"
 	"runtime/debug"
 	"strings"
 
@@ -11,37 +15,140 @@ import (
 	"github.com/prismedic/scalpel/logger"
 )
 
-func InitConfig(cfgFile string) {
+// EnvPrefix is prepended to the env variables read by viper, e.g. with "ARSENAL" the key logs.file.level
+// is read from ARSENAL_LOGS_FILE_LEVEL. It must be set before InitConfig, env variables are unprefixed when empty.
+var EnvPrefix = ""
+
+// OverridesEnv is the env variable listing override files separated like PATH, e.g. CONFIG_OVERRIDES=prod.yaml:local.yaml.
+// It is prefixed with EnvPrefix, the files are merged after the ones passed to InitConfig.
+const OverridesEnv = "CONFIG_OVERRIDES"
+
+// overrideFiles are merged on top of the config file, again after it is re-read on changes
+var overrideFiles []string
+
+// defaultConfigType is the format of the config files without a known extension
+const defaultConfigType = "yaml"
+
+// InitConfig loads the config file, merges the override files in order and binds the env variables.
+// The format of each file is picked by its extension among viper.SupportedExts, e.g. .toml or .json, YAML otherwise.
+// A key is resolved in the order of viper.Set, env variable, the last override file setting it, config file
+// and finally the default registered with viper.SetDefault. Only the config file is watched for changes.
+// Without a config file in the default paths, the defaults and the env variables are used with a warning.
+// A config file or an override file that cannot be read or parsed is an error, including a cfgFile or an override
+// file that does not exist.
+func InitConfig(cfgFile string, overrides ...string) error {
 	packageName := GetPackageName()
 	logger.Infof("Loading config for package %s", packageName)
 
-	viper.SetConfigType("yaml")
-
-	if cfgFile != "" {
-		viper.SetConfigFile(cfgFile)
-		logger.Infof("Loading config from %s", cfgFile)
-	} else {
+	if cfgFile == "" {
 		viper.SetConfigName("config")
 
-		viper.AddConfigPath(path.Join("/etc", packageName))
+		configPaths := []string{path.Join("/etc", packageName)}
 		for _, configDir := range xdg.ConfigDirs {
-			viper.AddConfigPath(path.Join(configDir, packageName))
+			configPaths = append(configPaths, path.Join(configDir, packageName))
+		}
+		configPaths = append(configPaths, path.Join(xdg.ConfigHome, packageName), "./config")
+		for _, configPath := range configPaths {
+			viper.AddConfigPath(configPath)
 		}
-		viper.AddConfigPath(path.Join(xdg.ConfigHome, packageName))
-		viper.AddConfigPath("./config")
 		logger.Infof("Searching config from default paths")
+		cfgFile = findConfigFile(configPaths, "config")
 	}
+	if cfgFile != "" {
+		viper.SetConfigFile(cfgFile)
+		logger.Infof("Loading config from %s", cfgFile)
+	}
+	// the type is always set, as viper keeps the type of a previous call and would parse the file with it
+	viper.SetConfigType(configType(cfgFile))
 
 	// support reading from environmental variables
 	// all env variables are capitalized, dot (levels) and dashes are replaced with underscores
+	// the prefix is separated by an underscore, which viper adds itself
+	viper.SetEnvPrefix(strings.TrimSuffix(EnvPrefix, "_"))
 	viper.AutomaticEnv()
-	viper.SetEnvKeyReplacer(strings.NewReplacer(".", "_", "-", "_"))
+	viper.SetEnvKeyReplacer(envKeyReplacer)
 
-	err := viper.ReadInConfig()
+	if err := viper.ReadInConfig(); err != nil {
+		var notFoundErr viper.ConfigFileNotFoundError
+		if !errors.As(err, &notFoundErr) {
+			return fmt.Errorf("error in reading config file: %w", err)
+		}
+		logger.Warnf("No config file found, using the defaults and the env variables. %v", err)
+	}
+
+	overrideFiles = append([]string{}, overrides...)
+	if envOverrides := os.Getenv(overridesEnvName()); envOverrides != "" {
+		overrideFiles = append(overrideFiles, filepath.SplitList(envOverrides)...)
+	}
+	return mergeOverrides(true)
+}
 
-	if err != nil {
-		logger.Warnf("Error in reading config. %v", err)
+var envKeyReplacer = strings.NewReplacer(".", "_", "-", "_")
+
+// EnvKey returns the name of the env variable of the key, e.g. LOGS_FILE_LEVEL for logs.file.level
+func EnvKey(key string) string {
+	name := strings.ToUpper(envKeyReplacer.Replace(key))
+	if prefix := strings.TrimSuffix(EnvPrefix, "_"); prefix != "" {
+		return strings.ToUpper(prefix) + "_" + name
+	}
+	return name
+}
+
+func overridesEnvName() string {
+	return EnvKey(strings.ToLower(OverridesEnv))
+}
+
+// mergeOverrides merges the override files into the config read by viper. When strict, a file that cannot be read
+// is an error, otherwise it is skipped with a warning, so a re-merge of the watcher keeps the other files.
+func mergeOverrides(strict bool) error {
+	for _, overrideFile := range overrideFiles {
+		if err := mergeConfigFile(overrideFile); err != nil {
+			if strict {
+				return fmt.Errorf("error in merging config override %s: %w", overrideFile, err)
+			}
+			logger.Warnf("Error in merging config override %s. %v", overrideFile, err)
+			continue
+		}
+		logger.Infof("Merged config override from %s", overrideFile)
+	}
+	return nil
+}
+
+// mergeConfigFile reads the file with its own viper rather than MergeInConfig, so that the config file used
+// and watched by viper stays the base config file, and an override can have another format than the config file
+func mergeConfigFile(filename string) error {
+	override := viper.New()
+	override.SetConfigFile(filename)
+	override.SetConfigType(configType(filename))
+	if err := override.ReadInConfig(); err != nil {
+		return err
+	}
+	return viper.MergeConfigMap(override.AllSettings())
+}
+
+// configType returns the format of the file from its extension, YAML for the other extensions
+func configType(filename string) string {
+	ext := strings.ToLower(strings.TrimPrefix(filepath.Ext(filename), "."))
+	for _, supported := range viper.SupportedExts {
+		if ext == supported {
+			return ext
+		}
+	}
+	return defaultConfigType
+}
+
+// findConfigFile returns the first file of the name with a supported extension, searching the paths in order like viper,
+// it is empty when no file is found
+func findConfigFile(configPaths []string, name string) string {
+	for _, configPath := range configPaths {
+		for _, ext := range viper.SupportedExts {
+			filename := path.Join(configPath, name+"."+ext)
+			if info, err := os.Stat(filename); err == nil && !info.IsDir() {
+				return filename
+			}
+		}
 	}
+	return ""
 }
 
 func GetPackageName() string {
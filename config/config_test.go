@@ -1,9 +1,14 @@
 package config_test
 
 import (
+	"fmt"
 	"os"
+	"path"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/go-playground/validator/v10"
 	"github.com/spf13/viper"
 
 	"github.com/prismedic/scalpel/config"
@@ -43,4 +48,156 @@ foo:
 			t.Errorf("unexpected config value, got %s, expected %s", gotVal, expectedVal)
 		}
 	})
+	t.Run("Test env variable prefix", func(t *testing.T) {
+		config.EnvPrefix = "ARSENAL_"
+		defer func() { config.EnvPrefix = "" }()
+		t.Setenv("ARSENAL_FOO_BAZ", "123")
+		t.Setenv("FOO_QUX", "456")
+		viper.SetDefault("foo.baz", "default_value")
+		viper.SetDefault("foo.qux", "default_value")
+		config.InitConfig("")
+		expectedVal := "123"
+		gotVal := viper.Get("foo.baz")
+		if gotVal != expectedVal {
+			t.Errorf("unexpected config value, got %s, expected %s", gotVal, expectedVal)
+		}
+		expectedVal = "default_value"
+		gotVal = viper.Get("foo.qux")
+		if gotVal != expectedVal {
+			t.Errorf("unprefixed env variable should be ignored, got %s, expected %s", gotVal, expectedVal)
+		}
+	})
+	t.Run("Test override files", func(t *testing.T) {
+		dir := t.TempDir()
+		files := map[string]string{
+			"base.yaml":     "layer:\n  a: base\n  b: base\n  c: base\n",
+			"override.yaml": "layer:\n  b: override\n  c: override\n",
+			"env.yaml":      "layer:\n  c: env\n",
+		}
+		for name, content := range files {
+			if err := os.WriteFile(path.Join(dir, name), []byte(content), 0600); err != nil {
+				t.Fatalf("failed to write config file %s: %v", name, err)
+			}
+		}
+		t.Setenv(config.OverridesEnv, path.Join(dir, "env.yaml"))
+		if err := config.InitConfig(path.Join(dir, "base.yaml"), path.Join(dir, "override.yaml")); err != nil {
+			t.Fatalf("unexpected error of the override files: %v", err)
+		}
+		for key, expectedVal := range map[string]string{"layer.a": "base", "layer.b": "override", "layer.c": "env"} {
+			if gotVal := viper.GetString(key); gotVal != expectedVal {
+				t.Errorf("unexpected config value of %s, got %s, expected %s", key, gotVal, expectedVal)
+			}
+		}
+		if gotPath := viper.ConfigFileUsed(); gotPath != path.Join(dir, "base.yaml") {
+			t.Errorf("config file used should stay the base file, got %s", gotPath)
+		}
+	})
+	t.Run("Test missing override file", func(t *testing.T) {
+		dir := t.TempDir()
+		filename := path.Join(dir, "base.yaml")
+		if err := os.WriteFile(filename, []byte("layer:\n  a: base\n"), 0600); err != nil {
+			t.Fatalf("failed to write config file: %v", err)
+		}
+		err := config.InitConfig(filename, path.Join(dir, "missing.yaml"))
+		if err == nil || !strings.Contains(err.Error(), "error in merging config override") {
+			t.Errorf("unexpected error of a missing override file, got %v", err)
+		}
+		t.Setenv(config.OverridesEnv, path.Join(dir, "missing.yaml"))
+		if err := config.InitConfig(filename); err == nil {
+			t.Errorf("expected error when an override file of the env variable does not exist")
+		}
+	})
+	t.Run("Test config file formats", func(t *testing.T) {
+		type serverConfig struct {
+			Name    string        `mapstructure:"name" validate:"required"`
+			Ports   []int         `mapstructure:"ports" validate:"required,dive,min=1"`
+			Timeout time.Duration `mapstructure:"timeout" validate:"required"`
+		}
+		// unmarshalled from the root, as viper.UnmarshalKey does not apply the defaults of the nested keys
+		var formats struct {
+			YAML serverConfig `mapstructure:"format_yaml"`
+			TOML serverConfig `mapstructure:"format_toml"`
+			JSON serverConfig `mapstructure:"format_json"`
+		}
+		dir := t.TempDir()
+		files := map[string]string{
+			"config.yaml": "format_yaml:\n  name: yaml\n  ports: [80, 443]\n",
+			"config.toml": "[format_toml]\nname = \"toml\"\nports = [80, 443]\n",
+			"config.json": `{"format_json": {"name": "json", "ports": [80, 443]}}`,
+		}
+		for name, content := range files {
+			filename := path.Join(dir, name)
+			if err := os.WriteFile(filename, []byte(content), 0600); err != nil {
+				t.Fatalf("failed to write config file %s: %v", name, err)
+			}
+			format := strings.TrimPrefix(path.Ext(name), ".")
+			key := "format_" + format
+			viper.SetDefault(key+".timeout", 5*time.Second)
+			config.InitConfig(filename)
+
+			if err := viper.Unmarshal(&formats); err != nil {
+				t.Fatalf("failed to unmarshal %s config: %v", format, err)
+			}
+			server := map[string]serverConfig{"yaml": formats.YAML, "toml": formats.TOML, "json": formats.JSON}[format]
+			if err := config.ValidateStruct(validator.New(), key, &server); err != nil {
+				t.Errorf("invalid %s config: %v", format, err)
+			}
+			expected := serverConfig{Name: format, Ports: []int{80, 443}, Timeout: 5 * time.Second}
+			if fmt.Sprint(server) != fmt.Sprint(expected) {
+				t.Errorf("unexpected %s config, got %+v, expected %+v", format, server, expected)
+			}
+		}
+	})
+	t.Run("Test override of another format", func(t *testing.T) {
+		dir := t.TempDir()
+		files := map[string]string{
+			"base.yaml":     "mixed:\n  a: base\n  b: base\n",
+			"override.toml": "[mixed]\nb = \"override\"\n",
+		}
+		for name, content := range files {
+			if err := os.WriteFile(path.Join(dir, name), []byte(content), 0600); err != nil {
+				t.Fatalf("failed to write config file %s: %v", name, err)
+			}
+		}
+		config.InitConfig(path.Join(dir, "base.yaml"), path.Join(dir, "override.toml"))
+		for key, expectedVal := range map[string]string{"mixed.a": "base", "mixed.b": "override"} {
+			if gotVal := viper.GetString(key); gotVal != expectedVal {
+				t.Errorf("unexpected config value of %s, got %s, expected %s", key, gotVal, expectedVal)
+			}
+		}
+	})
+	t.Run("Test missing config file", func(t *testing.T) {
+		viper.SetDefault("missing.value", "default_value")
+		if err := config.InitConfig(""); err != nil {
+			t.Fatalf("unexpected error without config file: %v", err)
+		}
+		if gotVal := viper.GetString("missing.value"); gotVal != "default_value" {
+			t.Errorf("unexpected config value, got %s, expected %s", gotVal, "default_value")
+		}
+	})
+	t.Run("Test malformed config file", func(t *testing.T) {
+		dir := t.TempDir()
+		filename := path.Join(dir, "config.yaml")
+		if err := os.WriteFile(filename, []byte("foo: [unclosed\n"), 0600); err != nil {
+			t.Fatalf("failed to write config file: %v", err)
+		}
+		if err := config.InitConfig(filename); err == nil || !strings.Contains(err.Error(), "error in reading config file") {
+			t.Errorf("unexpected error of a malformed config file, got %v", err)
+		}
+		if err := config.InitConfig(path.Join(dir, "missing.yaml")); err == nil {
+			t.Errorf("expected error when the config file passed does not exist")
+		}
+
+		base := path.Join(dir, "base.yaml")
+		override := path.Join(dir, "override.toml")
+		if err := os.WriteFile(base, []byte("layer:\n  a: base\n"), 0600); err != nil {
+			t.Fatalf("failed to write config file: %v", err)
+		}
+		if err := os.WriteFile(override, []byte("[layer\na = \"override\"\n"), 0600); err != nil {
+			t.Fatalf("failed to write override file: %v", err)
+		}
+		if err := config.InitConfig(base, override); err == nil || !strings.Contains(err.Error(), "error in merging config override") {
+			t.Errorf("unexpected error of a malformed override file, got %v", err)
+		}
+	})
 }
@@ -0,0 +1,34 @@
+package config
+
+import (
+	"strings"
+
+	"github.com/spf13/viper"
+)
+
+// environments returned by Environment
+const (
+	DevEnvironment     = "dev"
+	StagingEnvironment = "staging"
+	ProdEnvironment    = "prod"
+)
+
+func init() {
+	// config must have a default value for viper to load config from env variables
+	viper.SetDefault("environment", ProdEnvironment)
+}
+
+// Environment returns the environment the service runs in from the "environment" key, e.g. ENVIRONMENT=dev.
+// The value is lowercased, "development" and "production" are returned as dev and prod.
+func Environment() string {
+	environment := strings.ToLower(strings.TrimSpace(viper.GetString("environment")))
+	switch environment {
+	case "development":
+		return DevEnvironment
+	case "production":
+		return ProdEnvironment
+	case "":
+		return ProdEnvironment
+	}
+	return environment
+}
@@ -0,0 +1,52 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/spf13/viper"
+)
+
+// Unmarshal loads the config section of the key into a new T and validates it like ValidateStruct,
+// e.g. Unmarshal[loggerfx.LoggerConfig](validate, "logs"). The validate should be the validator of the app,
+// which has the validations of the modules registered by ValidationModule.
+// The defaults and the env variables of the nested keys apply, unlike with viper.UnmarshalKey.
+func Unmarshal[T any](validate *validator.Validate, key string) (*T, error) {
+	section := viper.New()
+	if settings, ok := sectionSettings(viper.AllSettings(), key); ok {
+		if err := section.MergeConfigMap(settings); err != nil {
+			return nil, fmt.Errorf("error in loading config %s: %w", key, err)
+		}
+	}
+	value := new(T)
+	if err := section.Unmarshal(value); err != nil {
+		return nil, fmt.Errorf("error in unmarshalling config %s: %w", key, err)
+	}
+	if err := ValidateStruct(validate, key, value); err != nil {
+		return nil, err
+	}
+	return value, nil
+}
+
+// Section returns a constructor of the config section of the key for fx.Provide, it is loaded with Unmarshal
+//
+//	fx.Provide(config.Section[loggerfx.LoggerConfig]("logs"))
+func Section[T any](key string) func(validate *validator.Validate) (*T, error) {
+	return func(validate *validator.Validate) (*T, error) {
+		return Unmarshal[T](validate, key)
+	}
+}
+
+// sectionSettings returns the nested settings of the key, the resolved settings of viper merge the defaults,
+// the env variables and the config files. It is false when the key is absent or is not a section.
+func sectionSettings(settings map[string]any, key string) (map[string]any, bool) {
+	for _, segment := range strings.Split(strings.ToLower(key), ".") {
+		nested, ok := settings[segment].(map[string]any)
+		if !ok {
+			return nil, false
+		}
+		settings = nested
+	}
+	return settings, true
+}
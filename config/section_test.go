@@ -0,0 +1,69 @@
+package config_test
+
+import (
+	"os"
+	"path"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/spf13/viper"
+	"go.uber.org/fx"
+	"go.uber.org/fx/fxtest"
+
+	"github.com/prismedic/scalpel/config"
+)
+
+type sectionConfig struct {
+	Name    string        `mapstructure:"name" validate:"required"`
+	Timeout time.Duration `mapstructure:"timeout" validate:"required"`
+	Retry   struct {
+		Count int `mapstructure:"count" validate:"min=1"`
+	} `mapstructure:"retry"`
+}
+
+func TestUnmarshal(t *testing.T) {
+	filename := path.Join(t.TempDir(), "config.yaml")
+	content := "sections:\n  billing:\n    name: billing\n  invalid:\n    retry:\n      count: 0\n"
+	if err := os.WriteFile(filename, []byte(content), 0600); err != nil {
+		t.Fatalf("failed to write config file: %v", err)
+	}
+	viper.SetDefault("sections.billing.timeout", 5*time.Second)
+	viper.SetDefault("sections.billing.retry.count", 1)
+	// viper keeps the prefix of a previous InitConfig when EnvPrefix is empty, so the test sets its own
+	config.EnvPrefix = "SECTION_"
+	defer func() { config.EnvPrefix = "" }()
+	t.Setenv(config.EnvKey("sections.billing.retry.count"), "3")
+	config.InitConfig(filename)
+
+	t.Run("Test defaults and env variables", func(t *testing.T) {
+		section, err := config.Unmarshal[sectionConfig](validator.New(), "sections.billing")
+		if err != nil {
+			t.Fatalf("failed to unmarshal section: %v", err)
+		}
+		if section.Name != "billing" || section.Timeout != 5*time.Second || section.Retry.Count != 3 {
+			t.Errorf("unexpected section %+v", section)
+		}
+	})
+	t.Run("Test invalid section", func(t *testing.T) {
+		_, err := config.Unmarshal[sectionConfig](validator.New(), "sections.invalid")
+		for _, expected := range []string{"sections.invalid.name: is required", "sections.invalid.retry.count: must be at least 1"} {
+			if err == nil || !strings.Contains(err.Error(), expected) {
+				t.Errorf("unexpected error, got %v, expected %q", err, expected)
+			}
+		}
+	})
+	t.Run("Test fx constructor", func(t *testing.T) {
+		var section *sectionConfig
+		app := fxtest.New(t,
+			fx.Provide(validator.New),
+			fx.Provide(config.Section[sectionConfig]("sections.billing")),
+			fx.Populate(&section),
+		)
+		defer app.RequireStart().RequireStop()
+		if section.Name != "billing" {
+			t.Errorf("unexpected section %+v", section)
+		}
+	})
+}
@@ -0,0 +1,115 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/go-playground/validator/v10"
+	"go.uber.org/multierr"
+)
+
+// ValidateStruct validates a config struct loaded from the key, e.g. "logs" for the LoggerConfig.
+// Validation failures are reported by ValidationError, other errors are returned as is.
+func ValidateStruct(validate *validator.Validate, key string, value any) error {
+	err := validate.Struct(value)
+	var validationErrors validator.ValidationErrors
+	if errors.As(err, &validationErrors) {
+		return ValidationError(key, value, validationErrors)
+	}
+	return err
+}
+
+// ValidationError combines the validation errors into one error listing every failing config key,
+// e.g. "logs.file.level: must be a valid loglevel". The keys are built from the mapstructure tags
+// of the validated value, prefixed by the key it is loaded from.
+func ValidationError(key string, value any, validationErrors validator.ValidationErrors) error {
+	var errs error
+	for _, fieldError := range validationErrors {
+		errs = multierr.Append(errs, fmt.Errorf("%s: %s", configKey(key, reflect.TypeOf(value), fieldError.StructNamespace()), validationMessage(fieldError)))
+	}
+	return errs
+}
+
+// configKey converts the struct namespace of a field error, e.g. "LoggerConfig.File.Level", to the config key
+func configKey(key string, valueType reflect.Type, namespace string) string {
+	segments := strings.Split(namespace, ".")
+	var keys []string
+	if key != "" {
+		keys = append(keys, key)
+	}
+	currentType := valueType
+	// the first segment is the name of the validated struct
+	for _, segment := range segments[1:] {
+		name, index, hasIndex := strings.Cut(segment, "[")
+		field, ok := structField(currentType, name)
+		if !ok {
+			// fall back to the struct namespace when the field cannot be resolved
+			keys = append(keys, strings.ToLower(segment))
+			currentType = nil
+			continue
+		}
+		tagName, tagOptions, _ := strings.Cut(field.Tag.Get("mapstructure"), ",")
+		if tagName == "" && !strings.Contains(tagOptions, "squash") {
+			tagName = strings.ToLower(field.Name)
+		}
+		if tagName != "" {
+			keys = append(keys, tagName)
+		}
+		currentType = field.Type
+		if hasIndex {
+			// map keys are config keys of their own, slice indexes are kept as they are
+			currentType = derefType(currentType)
+			if mapKey := strings.TrimSuffix(index, "]"); currentType.Kind() == reflect.Map && mapKey != "" {
+				keys = append(keys, mapKey)
+			} else if currentType.Kind() == reflect.Map {
+				// an empty map key cannot be a config key, e.g. logs.fields[""]
+				keys[len(keys)-1] += `[""]`
+			} else {
+				keys[len(keys)-1] += "[" + index
+			}
+			currentType = currentType.Elem()
+		}
+	}
+	return strings.Join(keys, ".")
+}
+
+func structField(structType reflect.Type, name string) (reflect.StructField, bool) {
+	if structType == nil {
+		return reflect.StructField{}, false
+	}
+	structType = derefType(structType)
+	if structType.Kind() != reflect.Struct {
+		return reflect.StructField{}, false
+	}
+	return structType.FieldByName(name)
+}
+
+func derefType(valueType reflect.Type) reflect.Type {
+	for valueType.Kind() == reflect.Pointer {
+		valueType = valueType.Elem()
+	}
+	return valueType
+}
+
+func validationMessage(fieldError validator.FieldError) string {
+	switch fieldError.Tag() {
+	case "required":
+		return "is required"
+	case "oneof":
+		return fmt.Sprintf("must be one of [%s]", fieldError.Param())
+	case "min":
+		return fmt.Sprintf("must be at least %s", fieldError.Param())
+	case "max":
+		return fmt.Sprintf("must be at most %s", fieldError.Param())
+	case "startswith":
+		return fmt.Sprintf("must start with %q", fieldError.Param())
+	case "excludesall":
+		return fmt.Sprintf("must not contain any of %q", fieldError.Param())
+	}
+	if fieldError.Param() != "" {
+		return fmt.Sprintf("must satisfy %s=%s", fieldError.Tag(), fieldError.Param())
+	}
+	return fmt.Sprintf("must be a valid %s", fieldError.Tag())
+}
@@ -0,0 +1,105 @@
+package config_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/go-playground/validator/v10"
+	"go.uber.org/fx"
+	"go.uber.org/fx/fxtest"
+	"go.uber.org/multierr"
+
+	"github.com/prismedic/scalpel/config"
+)
+
+type testConfig struct {
+	File struct {
+		Level string `mapstructure:"level" validate:"required"`
+	} `mapstructure:"file"`
+	Format    string            `mapstructure:"format" validate:"oneof=console json"`
+	MaxSizeMB int               `mapstructure:"max_size_mb" validate:"min=1"`
+	Labels    map[string]string `mapstructure:"labels" validate:"dive,required"`
+	Paths     []string          `mapstructure:"paths" validate:"dive,startswith=/"`
+	Fields    map[string]string `mapstructure:"fields" validate:"dive,keys,required,endkeys"`
+}
+
+func TestValidateStruct(t *testing.T) {
+	t.Run("Test all failing keys", func(t *testing.T) {
+		value := &testConfig{
+			Format: "xml",
+			Labels: map[string]string{"env": ""},
+			Paths:  []string{"/metrics", "healthz"},
+		}
+		err := config.ValidateStruct(validator.New(), "logs", value)
+		expectedErrors := []string{
+			"logs.file.level: is required",
+			"logs.format: must be one of [console json]",
+			"logs.max_size_mb: must be at least 1",
+			"logs.labels.env: is required",
+			`logs.paths[1]: must start with "/"`,
+		}
+		errs := multierr.Errors(err)
+		if len(errs) != len(expectedErrors) {
+			t.Fatalf("unexpected number of errors, got %d, expected %d: %v", len(errs), len(expectedErrors), err)
+		}
+		for _, expected := range expectedErrors {
+			if !strings.Contains(err.Error(), expected) {
+				t.Errorf("error %q not found in %v", expected, err)
+			}
+		}
+	})
+	t.Run("Test empty map key", func(t *testing.T) {
+		value := &testConfig{Format: "json", MaxSizeMB: 1, Fields: map[string]string{"region": "eu", "": "prod"}}
+		value.File.Level = "info"
+		err := config.ValidateStruct(validator.New(), "logs", value)
+		if errs := multierr.Errors(err); len(errs) != 1 {
+			t.Fatalf("unexpected number of errors, got %d, expected 1: %v", len(errs), err)
+		}
+		if expected := `logs.fields[""]: is required`; err.Error() != expected {
+			t.Errorf("unexpected error, got %v, expected %s", err, expected)
+		}
+	})
+	t.Run("Test valid config", func(t *testing.T) {
+		value := &testConfig{Format: "json", MaxSizeMB: 1}
+		value.File.Level = "info"
+		if err := config.ValidateStruct(validator.New(), "logs", value); err != nil {
+			t.Errorf("unexpected validation error: %v", err)
+		}
+	})
+}
+
+func TestRegisterValidations(t *testing.T) {
+	hostValidation := func() config.Validation {
+		return config.Validation{Tag: "testhost", Func: func(fieldLevel validator.FieldLevel) bool {
+			return !strings.Contains(fieldLevel.Field().String(), "/")
+		}}
+	}
+	type hostConfig struct {
+		Host string `mapstructure:"host" validate:"testhost"`
+	}
+
+	t.Run("Test validations of the group", func(t *testing.T) {
+		var validate *validator.Validate
+		app := fxtest.New(t,
+			config.ValidationModule,
+			fx.Provide(validator.New),
+			fx.Provide(config.AsValidation(hostValidation)),
+			fx.Populate(&validate),
+		)
+		defer app.RequireStart().RequireStop()
+		if err := config.ValidateStruct(validate, "router", &hostConfig{Host: "example.com"}); err != nil {
+			t.Errorf("unexpected validation error: %v", err)
+		}
+		err := config.ValidateStruct(validate, "router", &hostConfig{Host: "example.com/path"})
+		if err == nil || !strings.Contains(err.Error(), "router.host: must be a valid testhost") {
+			t.Errorf("unexpected validation error, got %v", err)
+		}
+	})
+	t.Run("Test duplicate tag", func(t *testing.T) {
+		_, err := config.RegisterValidations(validator.New(), []config.Validation{hostValidation(), hostValidation()})
+		if !errors.Is(err, config.ErrDuplicateValidation) {
+			t.Errorf("unexpected error, got %v, expected %v", err, config.ErrDuplicateValidation)
+		}
+	})
+}
@@ -0,0 +1,50 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/go-playground/validator/v10"
+	"go.uber.org/fx"
+)
+
+// ErrDuplicateValidation is returned by RegisterValidations when two validations have the same tag
+var ErrDuplicateValidation = errors.New("validation is registered more than once")
+
+// Validation is a custom validation tag of the configs, e.g. loglevel, provided by a module with AsValidation
+type Validation struct {
+	Tag  string
+	Func validator.Func
+}
+
+// AsValidation annotates a constructor of a Validation, or of a []Validation with the flatten option of fx groups
+func AsValidation(validation any) any {
+	return fx.Annotate(
+		validation,
+		fx.ResultTags(`group:"validations"`),
+	)
+}
+
+// ValidationModule registers the validations provided by the modules on the validator of the app.
+// The validator is decorated once for all the modules, as a second decoration of the same type fails in fx.
+var ValidationModule = fx.Decorate(
+	fx.Annotate(
+		RegisterValidations,
+		fx.ParamTags(``, `group:"validations"`),
+	),
+)
+
+// RegisterValidations registers the validations on the validator, a tag registered twice is an error
+func RegisterValidations(validate *validator.Validate, validations []Validation) (*validator.Validate, error) {
+	tags := make(map[string]bool, len(validations))
+	for _, validation := range validations {
+		if tags[validation.Tag] {
+			return nil, fmt.Errorf("%w: %q", ErrDuplicateValidation, validation.Tag)
+		}
+		tags[validation.Tag] = true
+		if err := validate.RegisterValidation(validation.Tag, validation.Func); err != nil {
+			return nil, fmt.Errorf("error in registering validation %q: %w", validation.Tag, err)
+		}
+	}
+	return validate, nil
+}
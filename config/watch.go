@@ -0,0 +1,40 @@
+package config
+
+import (
+	"sync"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/spf13/viper"
+
+	"github.com/prismedic/scalpel/logger"
+)
+
+var (
+	changeHandlersMutex sync.Mutex
+	changeHandlers      []func()
+	watchOnce           sync.Once
+)
+
+// OnConfigChange registers a handler called after the config file is changed and re-read by viper.
+// The config file is watched from the first registration, unlike viper.OnConfigChange multiple handlers can be registered.
+func OnConfigChange(handler func()) {
+	changeHandlersMutex.Lock()
+	changeHandlers = append(changeHandlers, handler)
+	changeHandlersMutex.Unlock()
+
+	watchOnce.Do(func() {
+		viper.OnConfigChange(func(event fsnotify.Event) {
+			logger.Infof("Config file %s changed", event.Name)
+			// viper re-reads only the config file, the overrides still take precedence over it
+			// a file that cannot be read is skipped, the service keeps running with the other files
+			mergeOverrides(false)
+			changeHandlersMutex.Lock()
+			handlers := append([]func(){}, changeHandlers...)
+			changeHandlersMutex.Unlock()
+			for _, handler := range handlers {
+				handler()
+			}
+		})
+		viper.WatchConfig()
+	})
+}
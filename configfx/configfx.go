@@ -0,0 +1,174 @@
+package configfx
+
+import (
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/spf13/viper"
+	"go.uber.org/fx"
+	"go.uber.org/zap"
+
+	"github.com/prismedic/scalpel/config"
+	"github.com/prismedic/scalpel/loggerfx"
+	"github.com/prismedic/scalpel/metricsfx"
+	"github.com/prismedic/scalpel/routerfx"
+)
+
+// Module serves the effective config at /v1/config, with the values of secret keys masked.
+// The route requires the bearer token of the metrics and is not registered without one.
+// It is not included in the scalpel module, add it to the app to opt in.
+var Module = fx.Module("config",
+	fx.Provide(
+		fx.Annotate(
+			newControllerRoutes,
+			fx.ParamTags(``, `optional:"true"`, `optional:"true"`),
+			fx.ResultTags(`group:"controllerRoutes,flatten"`),
+		),
+	),
+)
+
+const maskedValue = "***"
+
+// secretPatterns are the parts of the keys whose values are masked, matched case-insensitively
+var secretPatterns = []string{"password", "passwd", "secret", "token", "key", "dsn", "credential"}
+
+// sources of the values in the config response
+const (
+	EnvSource     = "env"
+	FileSource    = "file"
+	DefaultSource = "default"
+)
+
+type ConfigResponse struct {
+	// Settings are the nested settings resolved by viper
+	Settings map[string]any `json:"settings"`
+	// Sources map the keys of the settings to where their values come from, e.g. "logs.file.level": "env"
+	Sources map[string]string `json:"sources"`
+}
+
+// newControllerRoutes registers the config controller if the metrics have a token,
+// the keys redacted in the logs are also masked when the log config is provided
+func newControllerRoutes(metricsConfig *metricsfx.MetricsConfig, loggerConfig *loggerfx.LoggerConfig, logger *zap.SugaredLogger) []routerfx.ControllerRoute {
+	if metricsConfig.Auth.Token == "" {
+		if logger != nil {
+			logger.Warn("Config route is not registered, metrics.auth.token is required to protect it")
+		}
+		return nil
+	}
+	var redact []string
+	if loggerConfig != nil {
+		redact = loggerConfig.Redact
+	}
+	return []routerfx.ControllerRoute{NewConfigController(metricsConfig.Auth.Token, redact)}
+}
+
+type ConfigController struct {
+	token  string
+	redact map[string]bool
+}
+
+// NewConfigController returns the controller of the config route, the values of the redacted keys are masked like secrets
+func NewConfigController(token string, redact []string) *ConfigController {
+	redactSet := make(map[string]bool, len(redact))
+	for _, key := range redact {
+		redactSet[strings.ToLower(key)] = true
+	}
+	return &ConfigController{token: token, redact: redactSet}
+}
+
+// getConfig godoc
+//
+//	@Summary		Get effective config
+//	@Description	Get the settings resolved by viper and their sources, the values of secrets are masked
+//	@Produce		json
+//	@Success		200	{object}	ConfigResponse
+//	@Failure		401
+//	@Router			/config [get]
+func (cc *ConfigController) getConfig(c *gin.Context) {
+	response := ConfigResponse{
+		Settings: cc.mask(viper.AllSettings()).(map[string]any),
+		Sources:  make(map[string]string),
+	}
+	keys := viper.AllKeys()
+	sort.Strings(keys)
+	for _, key := range keys {
+		response.Sources[key] = source(key)
+	}
+	c.JSON(http.StatusOK, &response)
+}
+
+// mask replaces the values of the secret keys in the nested settings, and the passwords of URLs
+func (cc *ConfigController) mask(value any) any {
+	switch value := value.(type) {
+	case map[string]any:
+		masked := make(map[string]any, len(value))
+		for key, nested := range value {
+			if cc.isSecret(key) {
+				masked[key] = maskedValue
+				continue
+			}
+			masked[key] = cc.mask(nested)
+		}
+		return masked
+	case []any:
+		masked := make([]any, len(value))
+		for i, nested := range value {
+			masked[i] = cc.mask(nested)
+		}
+		return masked
+	case string:
+		return maskURL(value)
+	}
+	return value
+}
+
+func (cc *ConfigController) isSecret(key string) bool {
+	key = strings.ToLower(key)
+	if cc.redact[key] {
+		return true
+	}
+	for _, pattern := range secretPatterns {
+		if strings.Contains(key, pattern) {
+			return true
+		}
+	}
+	return false
+}
+
+// maskURL masks the password of a URL, e.g. a database connection string, with the "xxxxx" of url.URL.Redacted
+func maskURL(value string) string {
+	parsed, err := url.Parse(value)
+	if err != nil || parsed.User == nil {
+		return value
+	}
+	return parsed.Redacted()
+}
+
+// source tells where viper resolves the value of the key from, values set in code with viper.Set are not told apart
+func source(key string) string {
+	if _, ok := os.LookupEnv(config.EnvKey(key)); ok {
+		return EnvSource
+	}
+	if viper.InConfig(key) {
+		return FileSource
+	}
+	return DefaultSource
+}
+
+func (cc *ConfigController) RegisterControllerRoutes(rg *gin.RouterGroup) {
+	rg.GET("/", metricsfx.WithBearerToken(cc.token, cc.getConfig))
+}
+
+func (cc *ConfigController) RoutePattern() string {
+	return "/config"
+}
+
+func (cc *ConfigController) RouteDocs() []routerfx.RouteDoc {
+	return []routerfx.RouteDoc{
+		{Method: http.MethodGet, Path: "/", Summary: "Get the effective config with masked secrets", Response: ConfigResponse{}},
+	}
+}
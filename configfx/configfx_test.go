@@ -0,0 +1,72 @@
+package configfx_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/spf13/viper"
+
+	"github.com/prismedic/scalpel/configfx"
+)
+
+func TestConfigController(t *testing.T) {
+	viper.Set("testdb.password", "hunter2")
+	viper.Set("testdb.url", "postgres://app:hunter2@db:5432/app")
+	viper.Set("testdb.pool", map[string]any{"size": 10, "api_key": "abc"})
+	viper.Set("testdb.owner", "alice")
+	viper.Set("testdb.internal_field", "visible")
+	t.Setenv("TESTDB_OWNER", "bob")
+	viper.BindEnv("testdb.owner")
+
+	controller := configfx.NewConfigController("secret", []string{"internal_field"})
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	controller.RegisterControllerRoutes(router.Group(controller.RoutePattern()))
+
+	t.Run("Test missing token", func(t *testing.T) {
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/config/", nil))
+		if recorder.Code != http.StatusUnauthorized {
+			t.Errorf("unexpected status code, got %d, expected %d", recorder.Code, http.StatusUnauthorized)
+		}
+	})
+	t.Run("Test masked settings", func(t *testing.T) {
+		recorder := httptest.NewRecorder()
+		request := httptest.NewRequest(http.MethodGet, "/config/", nil)
+		request.Header.Set("Authorization", "Bearer secret")
+		router.ServeHTTP(recorder, request)
+		if recorder.Code != http.StatusOK {
+			t.Fatalf("unexpected status code, got %d, expected %d", recorder.Code, http.StatusOK)
+		}
+		var response struct {
+			Settings struct {
+				Testdb struct {
+					Password      string         `json:"password"`
+					Url           string         `json:"url"`
+					Pool          map[string]any `json:"pool"`
+					InternalField string         `json:"internal_field"`
+				} `json:"testdb"`
+			} `json:"settings"`
+			Sources map[string]string `json:"sources"`
+		}
+		if err := json.Unmarshal(recorder.Body.Bytes(), &response); err != nil {
+			t.Fatalf("failed to decode response: %v", err)
+		}
+		testdb := response.Settings.Testdb
+		if testdb.Password != "***" || testdb.InternalField != "***" || testdb.Pool["api_key"] != "***" {
+			t.Errorf("secrets are not masked in %s", recorder.Body.String())
+		}
+		if testdb.Url != "postgres://app:xxxxx@db:5432/app" {
+			t.Errorf("password of url is not masked, got %s", testdb.Url)
+		}
+		if testdb.Pool["size"] != float64(10) {
+			t.Errorf("unexpected pool size, got %v", testdb.Pool["size"])
+		}
+		if source := response.Sources["testdb.owner"]; source != configfx.EnvSource {
+			t.Errorf("unexpected source of testdb.owner, got %s, expected %s", source, configfx.EnvSource)
+		}
+	})
+}
@@ -1,12 +1,30 @@
 package dbfx
 
 import (
+	prometheusclient "github.com/prometheus/client_golang/prometheus"
+	"go.uber.org/fx"
 	"gorm.io/gorm"
 	"gorm.io/plugin/prometheus"
+
+	"github.com/prismedic/scalpel/metricsfx"
 )
 
-func SetupGormPrometheus(db *gorm.DB) error {
-	return db.Use(prometheus.New(prometheus.Config{
+type PrometheusParams struct {
+	fx.In
+	DB       *gorm.DB
+	Registry *prometheusclient.Registry `optional:"true"`
+}
+
+func SetupGormPrometheus(p PrometheusParams) error {
+	plugin := prometheus.New(prometheus.Config{
 		StartServer: false,
-	}))
+	})
+	if err := p.DB.Use(plugin); err != nil {
+		return err
+	}
+	// the plugin registers on the default registry, also register on the registry served by metricsfx
+	if p.Registry == nil {
+		return nil
+	}
+	return metricsfx.Register(p.Registry, append(plugin.DBStats.Collectors(), plugin.Collectors...)...)
 }
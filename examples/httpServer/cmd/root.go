@@ -6,7 +6,10 @@ import (
 	"github.com/spf13/cobra"
 )
 
-var cfgFile string
+var (
+	cfgFile      string
+	cfgOverrides []string
+)
 
 var rootCmd = &cobra.Command{
 	Use:   "httpServer",
@@ -28,4 +31,5 @@ func Execute() {
 
 func init() {
 	rootCmd.PersistentFlags().StringVar(&cfgFile, "config", "", "config file (default is $XDG_CONFIG_DIR/config.yaml)")
+	rootCmd.PersistentFlags().StringSliceVar(&cfgOverrides, "config-override", nil, "config files merged over the config file in order, also read from $CONFIG_OVERRIDES")
 }
@@ -1,23 +1,42 @@
 package cmd
 
 import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/prismedic/scalpel"
 	"github.com/prismedic/scalpel/config"
 	"github.com/spf13/cobra"
 
 	"examples/httpServer/internal/app"
+	appconfig "examples/httpServer/internal/pkg/config"
 )
 
+var validateConfig bool
+
 // serverCmd represents the server command
 var serverCmd = &cobra.Command{
 	Use:   "server",
 	Short: "Run the API server",
 	Long:  `Run the API server.`,
 	Run: func(cmd *cobra.Command, args []string) {
-		config.InitConfig(cfgFile)
+		if err := config.InitConfig(cfgFile, cfgOverrides...); err != nil {
+			fmt.Fprintf(os.Stderr, "%s\t[FATAL]\t%v\n", time.Now().Format(time.RFC3339), err)
+			os.Exit(1)
+		}
+		if validateConfig || scalpel.ValidateConfigRequested() {
+			if err := scalpel.ValidateConfig(os.Stdout, appconfig.Module); err != nil {
+				fmt.Fprintf(os.Stderr, "%s\t[FATAL]\t%v\n", time.Now().Format(time.RFC3339), err)
+				os.Exit(1)
+			}
+			return
+		}
 		app.New().Run()
 	},
 }
 
 func init() {
+	serverCmd.Flags().BoolVar(&validateConfig, "validate-config", false, "validate the config and print it without running the server, also enabled with $VALIDATE_CONFIG")
 	rootCmd.AddCommand(serverCmd)
 }
@@ -5,6 +5,7 @@ import (
 	"os"
 
 	"github.com/go-playground/validator/v10"
+	scalpelconfig "github.com/prismedic/scalpel/config"
 	"github.com/prismedic/scalpel/httpfx"
 	"github.com/prismedic/scalpel/loggerfx"
 	"github.com/prismedic/scalpel/sentryfx"
@@ -27,7 +28,7 @@ func NewConfig(validate *validator.Validate) (Config, error) {
 		return Config{}, fmt.Errorf("fail to unmarshal config: %w", err)
 	}
 
-	if err := validate.Struct(&config); err != nil {
+	if err := scalpelconfig.ValidateStruct(validate, "", &config); err != nil {
 		return Config{}, fmt.Errorf("config is invalid: %w", err)
 	}
 
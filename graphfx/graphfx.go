@@ -0,0 +1,92 @@
+package graphfx
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/spf13/viper"
+	"go.uber.org/fx"
+	"go.uber.org/zap"
+
+	"github.com/prismedic/scalpel/config"
+	"github.com/prismedic/scalpel/metricsfx"
+	"github.com/prismedic/scalpel/routerfx"
+)
+
+// Module serves the fx dependency graph in the DOT format at /debug/fx when fx_graph.enabled is true.
+// The route is only registered in the dev environment and requires a bearer token.
+// It is not included in the scalpel module, add it to the app to opt in.
+var Module = fx.Module("graph",
+	fx.Provide(
+		fx.Annotate(
+			newHandlerRoutes,
+			fx.ParamTags(``, ``, `optional:"true"`, `optional:"true"`),
+			fx.ResultTags(`group:"handlerRoutes,flatten"`),
+		),
+	),
+)
+
+// GraphPath is the route of the dependency graph
+const GraphPath = "/debug/fx"
+
+type GraphConfig struct {
+	// Enabled registers the graph route in the dev environment, it is not served by default
+	Enabled bool `mapstructure:"enabled" yaml:"enabled"`
+	Auth    struct {
+		// Token is the bearer token required to access the graph, the token of the metrics is used when it is empty
+		Token string `mapstructure:"token" yaml:"token"`
+	} `mapstructure:"auth" yaml:"auth"`
+}
+
+func init() {
+	// config must have a default value for viper to load config from env variables
+	viper.SetDefault("fx_graph.enabled", false)
+	viper.SetDefault("fx_graph.auth.token", "")
+}
+
+// newHandlerRoutes registers the graph handler if it is enabled in the dev environment with a token,
+// the metrics config and the logger are optional
+func newHandlerRoutes(graphConfig *GraphConfig, graph fx.DotGraph, metricsConfig *metricsfx.MetricsConfig, logger *zap.SugaredLogger) []routerfx.HandlerRoute {
+	if !graphConfig.Enabled {
+		return nil
+	}
+	if environment := config.Environment(); environment != config.DevEnvironment {
+		if logger != nil {
+			logger.Warnw("Dependency graph route is not registered outside of the dev environment", "environment", environment)
+		}
+		return nil
+	}
+	token := graphConfig.Auth.Token
+	if token == "" && metricsConfig != nil {
+		token = metricsConfig.Auth.Token
+	}
+	if token == "" {
+		if logger != nil {
+			logger.Warn("Dependency graph route is not registered, fx_graph.auth.token or metrics.auth.token is required to protect it")
+		}
+		return nil
+	}
+	return []routerfx.HandlerRoute{NewGraphHandler(token, graph)}
+}
+
+type GraphHandler struct {
+	token string
+	graph fx.DotGraph
+}
+
+// NewGraphHandler returns the handler of the graph route, the graph is rendered with e.g. `dot -Tsvg`
+func NewGraphHandler(token string, graph fx.DotGraph) *GraphHandler {
+	return &GraphHandler{token: token, graph: graph}
+}
+
+func (gh *GraphHandler) Handler() gin.HandlerFunc {
+	return metricsfx.WithBearerToken(gh.token, func(c *gin.Context) {
+		c.Data(http.StatusOK, "text/vnd.graphviz; charset=utf-8", []byte(gh.graph))
+	})
+}
+
+func (gh *GraphHandler) RoutePattern() string {
+	return GraphPath
+}
+
+var _ routerfx.HandlerRoute = (*GraphHandler)(nil)
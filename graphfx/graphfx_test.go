@@ -0,0 +1,75 @@
+package graphfx_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/spf13/viper"
+	"go.uber.org/fx"
+	"go.uber.org/fx/fxtest"
+
+	"github.com/prismedic/scalpel/config"
+	"github.com/prismedic/scalpel/graphfx"
+	"github.com/prismedic/scalpel/routerfx"
+)
+
+func serveGraph(t *testing.T, environment string, graphConfig *graphfx.GraphConfig, token string) *httptest.ResponseRecorder {
+	viper.Set("environment", environment)
+	t.Cleanup(func() { viper.Set("environment", config.ProdEnvironment) })
+
+	var router http.Handler
+	app := fxtest.New(t,
+		graphfx.Module,
+		routerfx.Module,
+		fx.Supply(&routerfx.Config{}, graphConfig),
+		fx.Populate(&router),
+	)
+	app.RequireStart()
+	defer app.RequireStop()
+
+	request := httptest.NewRequest(http.MethodGet, graphfx.GraphPath, nil)
+	if token != "" {
+		request.Header.Set("Authorization", "Bearer "+token)
+	}
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, request)
+	return recorder
+}
+
+func TestGraph(t *testing.T) {
+	enabled := &graphfx.GraphConfig{Enabled: true}
+	enabled.Auth.Token = "secret"
+
+	t.Run("Test disabled by default", func(t *testing.T) {
+		if code := serveGraph(t, config.DevEnvironment, &graphfx.GraphConfig{}, "").Code; code != http.StatusNotFound {
+			t.Errorf("unexpected status code, got %d, expected %d", code, http.StatusNotFound)
+		}
+	})
+	t.Run("Test not registered in prod", func(t *testing.T) {
+		if code := serveGraph(t, config.ProdEnvironment, enabled, "secret").Code; code != http.StatusNotFound {
+			t.Errorf("unexpected status code, got %d, expected %d", code, http.StatusNotFound)
+		}
+	})
+	t.Run("Test not registered without token", func(t *testing.T) {
+		if code := serveGraph(t, config.DevEnvironment, &graphfx.GraphConfig{Enabled: true}, "").Code; code != http.StatusNotFound {
+			t.Errorf("unexpected status code, got %d, expected %d", code, http.StatusNotFound)
+		}
+	})
+	t.Run("Test missing token", func(t *testing.T) {
+		if code := serveGraph(t, config.DevEnvironment, enabled, "").Code; code != http.StatusUnauthorized {
+			t.Errorf("unexpected status code, got %d, expected %d", code, http.StatusUnauthorized)
+		}
+	})
+	t.Run("Test graph", func(t *testing.T) {
+		recorder := serveGraph(t, config.DevEnvironment, enabled, "secret")
+		if recorder.Code != http.StatusOK {
+			t.Fatalf("unexpected status code, got %d, expected %d", recorder.Code, http.StatusOK)
+		}
+		body := recorder.Body.String()
+		if !strings.HasPrefix(body, "digraph") || !strings.Contains(body, "routerfx") {
+			t.Errorf("unexpected graph: %s", body)
+		}
+	})
+}
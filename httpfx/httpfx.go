@@ -2,27 +2,149 @@ package httpfx
 
 import (
 	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
 	"net/http"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/spf13/viper"
 	"go.uber.org/fx"
+	"go.uber.org/multierr"
+	"go.uber.org/zap"
 )
 
 var Module = fx.Module("http",
+	Validations,
 	fx.Provide(NewHttp),
+	fx.Provide(NewHttpServers),
 	fx.Invoke(RunHttpServer),
 )
 
 type HttpConfig struct {
-	ListenAddr string `mapstructure:"listen_addr" yaml:"listen_addr" required:"required,hostname_port"`
+	// ListenAddr is the host:port to listen on, a random free port is picked with port 0, e.g. "127.0.0.1:0".
+	// It is the path of the socket file with the unix network.
+	ListenAddr string `mapstructure:"listen_addr" yaml:"listen_addr" validate:"required,listenaddr"`
+	// Network is tcp or unix, tcp when empty
+	Network string `mapstructure:"network" yaml:"network" validate:"omitempty,oneof=tcp unix"`
+	// SocketMode is the octal permissions of the socket file, e.g. "0660", the umask applies when empty
+	SocketMode string `mapstructure:"socket_mode" yaml:"socket_mode"`
+	// ShutdownTimeout is how long in-flight requests are drained before the remaining connections are closed
+	ShutdownTimeout time.Duration `mapstructure:"shutdown_timeout" yaml:"shutdown_timeout" validate:"min=0"`
+	TLS             TLSConfig     `mapstructure:"tls" yaml:"tls"`
+	// Timeouts limit the connections of slow clients, they apply to the named servers as well except the write timeout,
+	// see ServerConfig.Timeouts
+	Timeouts TimeoutsConfig `mapstructure:"timeouts" yaml:"timeouts"`
+	// Servers are the named servers listening next to the default server, e.g. "admin" for the metrics and health routes.
+	// They serve the routes of their name, see routerfx.ServerRoute, and share the shutdown timeout.
+	Servers map[string]ServerConfig `mapstructure:"servers" yaml:"servers" validate:"dive,keys,required,endkeys,required"`
+}
+
+// ServerConfig is the listener of a named server, its fields are the ones of the default server
+type ServerConfig struct {
+	ListenAddr string    `mapstructure:"listen_addr" yaml:"listen_addr" validate:"required,listenaddr"`
+	Network    string    `mapstructure:"network" yaml:"network" validate:"omitempty,oneof=tcp unix"`
+	SocketMode string    `mapstructure:"socket_mode" yaml:"socket_mode"`
+	TLS        TLSConfig `mapstructure:"tls" yaml:"tls"`
+	// Timeouts override http.timeouts for the server, a zero timeout is the one of http.timeouts.
+	// The write timeout is disabled by default instead, so the admin routes can stream for longer than 60s,
+	// e.g. the pprof profiles and traces of ?seconds=N.
+	Timeouts TimeoutsConfig `mapstructure:"timeouts" yaml:"timeouts"`
+}
+
+// TimeoutsConfig are the timeouts of the connections of http.Server, e.g. to close the connections of slow-loris clients.
+// They bound the reads and the writes of the connection, unlike router.request_timeout, which cancels the context of
+// the handlers and answers 503. WriteTimeout should be longer than the request timeout, so the 503 reaches the client.
+// A zero timeout uses the default, a negative timeout disables it.
+type TimeoutsConfig struct {
+	// ReadHeader is the time to read the headers of a request, 10s by default
+	ReadHeader time.Duration `mapstructure:"read_header" yaml:"read_header"`
+	// Read is the time to read a whole request including its body, 60s by default
+	Read time.Duration `mapstructure:"read" yaml:"read"`
+	// Write is the time from the end of the headers of the request to the end of the response, 60s by default.
+	// Long streaming responses need a longer timeout, e.g. net/http/pprof rejects the profiles of ?seconds=N
+	// when N is not below it. The named servers have no write timeout by default.
+	Write time.Duration `mapstructure:"write" yaml:"write"`
+	// Idle is the time a keep-alive connection waits for the next request, 120s by default
+	Idle time.Duration `mapstructure:"idle" yaml:"idle"`
+}
+
+// default timeouts of the connections, they are applied when the config is zero, e.g. without viper
+const (
+	defaultReadHeaderTimeout = 10 * time.Second
+	defaultReadTimeout       = 60 * time.Second
+	defaultWriteTimeout      = 60 * time.Second
+	defaultIdleTimeout       = 120 * time.Second
+)
+
+// timeout returns the default of a zero timeout, and zero, which disables it in http.Server, for a negative timeout
+func timeout(value time.Duration, defaultValue time.Duration) time.Duration {
+	if value == 0 {
+		return defaultValue
+	}
+	if value < 0 {
+		return 0
+	}
+	return value
+}
+
+// newServer returns a server with the timeouts
+func newServer(timeouts TimeoutsConfig, addr string, handler http.Handler) *http.Server {
+	return &http.Server{
+		Addr:              addr,
+		Handler:           handler,
+		ReadHeaderTimeout: timeout(timeouts.ReadHeader, defaultReadHeaderTimeout),
+		ReadTimeout:       timeout(timeouts.Read, defaultReadTimeout),
+		WriteTimeout:      timeout(timeouts.Write, defaultWriteTimeout),
+		IdleTimeout:       timeout(timeouts.Idle, defaultIdleTimeout),
+	}
+}
+
+// serverTimeouts returns the timeouts of a named server, the ones of http.timeouts without a write timeout by default
+func (c *HttpConfig) serverTimeouts(server ServerConfig) TimeoutsConfig {
+	timeouts := server.Timeouts
+	if timeouts.ReadHeader == 0 {
+		timeouts.ReadHeader = c.Timeouts.ReadHeader
+	}
+	if timeouts.Read == 0 {
+		timeouts.Read = c.Timeouts.Read
+	}
+	if timeouts.Write == 0 {
+		timeouts.Write = -1
+	}
+	if timeouts.Idle == 0 {
+		timeouts.Idle = c.Timeouts.Idle
+	}
+	return timeouts
+}
+
+// server returns the listener config of the default server
+func (c *HttpConfig) server() ServerConfig {
+	return ServerConfig{ListenAddr: c.ListenAddr, Network: c.Network, SocketMode: c.SocketMode, TLS: c.TLS}
 }
 
 func init() {
 	// config must have a default value for viper to load config from env variables
 	// default value of empty string (zero value) will not pass the "required" config validation
 	viper.SetDefault("http.listen_addr", ":8080")
+	viper.SetDefault("http.network", TCPNetwork)
+	viper.SetDefault("http.socket_mode", "")
+	viper.SetDefault("http.shutdown_timeout", 10*time.Second)
+	viper.SetDefault("http.tls.cert_file", "")
+	viper.SetDefault("http.tls.key_file", "")
+	viper.SetDefault("http.timeouts.read_header", defaultReadHeaderTimeout)
+	viper.SetDefault("http.timeouts.read", defaultReadTimeout)
+	viper.SetDefault("http.timeouts.write", defaultWriteTimeout)
+	viper.SetDefault("http.timeouts.idle", defaultIdleTimeout)
 }
 
+// ErrUnknownServer is returned when routes are served by a server missing from http.servers
+var ErrUnknownServer = errors.New("unknown http server")
+
 type HttpParams struct {
 	fx.In
 	Config  *HttpConfig
@@ -30,30 +152,205 @@ type HttpParams struct {
 }
 
 func NewHttp(p HttpParams) *http.Server {
-	return &http.Server{
-		Addr:    p.Config.ListenAddr,
-		Handler: p.Handler,
+	return newServer(p.Config.Timeouts, p.Config.ListenAddr, p.Handler)
+}
+
+// HttpServers are the named servers of http.servers, by name
+type HttpServers map[string]*http.Server
+
+type HttpServersParams struct {
+	fx.In
+	Config *HttpConfig
+	// Handlers are the handlers of the named servers, provided by routerfx
+	Handlers map[string]http.Handler `name:"serverHandlers" optional:"true"`
+	Logger   *zap.SugaredLogger      `optional:"true"`
+}
+
+// NewHttpServers returns the named servers of the config, a server without routes answers 404 to all the requests
+func NewHttpServers(p HttpServersParams) (HttpServers, error) {
+	for name := range p.Handlers {
+		if _, ok := p.Config.Servers[name]; !ok {
+			return nil, fmt.Errorf("error in creating http servers: %w: %q has routes but no http.servers config", ErrUnknownServer, name)
+		}
 	}
+	servers := make(HttpServers, len(p.Config.Servers))
+	for name, config := range p.Config.Servers {
+		handler, ok := p.Handlers[name]
+		if !ok {
+			if p.Logger != nil {
+				p.Logger.Warnw("http server has no routes", "server", name)
+			}
+			handler = http.NotFoundHandler()
+		}
+		servers[name] = newServer(p.Config.serverTimeouts(config), config.ListenAddr, handler)
+	}
+	return servers, nil
 }
 
 type RunHttpParams struct {
 	fx.In
 	Lifecycle  fx.Lifecycle
+	Config     *HttpConfig
 	HttpServer *http.Server
+	// Servers are the named servers, they are started and shut down with the default server
+	Servers HttpServers        `optional:"true"`
+	Logger  *zap.SugaredLogger `optional:"true"`
+}
+
+// trackConnections counts the open connections of the server, so the drained connections can be reported on shutdown
+func trackConnections(server *http.Server) *atomic.Int64 {
+	var openConnections atomic.Int64
+	connState := server.ConnState
+	server.ConnState = func(conn net.Conn, state http.ConnState) {
+		switch state {
+		case http.StateNew:
+			openConnections.Add(1)
+		case http.StateHijacked, http.StateClosed:
+			openConnections.Add(-1)
+		}
+		if connState != nil {
+			connState(conn, state)
+		}
+	}
+	return &openConnections
+}
+
+// runningServer is a server of the app with its listener config
+type runningServer struct {
+	name            string
+	config          ServerConfig
+	server          *http.Server
+	openConnections *atomic.Int64
+	reloader        *certReloader
+	logger          *zap.SugaredLogger
+}
+
+func newRunningServer(name string, config ServerConfig, server *http.Server, logger *zap.SugaredLogger) *runningServer {
+	if logger != nil && name != "" {
+		logger = logger.With("server", name)
+	}
+	return &runningServer{name: name, config: config, server: server, openConnections: trackConnections(server), logger: logger}
 }
 
+func (s *runningServer) start() error {
+	if s.config.TLS.Enabled() {
+		var err error
+		s.reloader, err = newCertReloader(&s.config.TLS, s.logger)
+		if err != nil {
+			return err
+		}
+		s.server.TLSConfig = &tls.Config{
+			MinVersion:     tls.VersionTLS12,
+			GetCertificate: s.reloader.GetCertificate,
+		}
+	}
+
+	// listen before starting, so that a port in use fails the start of the app
+	listener, err := listen(&s.config, s.server.Addr)
+	if err != nil {
+		if s.reloader != nil {
+			s.reloader.Close()
+		}
+		return fmt.Errorf("error in listening on %s: %w", s.server.Addr, err)
+	}
+	// the address of the server is updated with the picked port when port 0 is configured
+	s.server.Addr = listener.Addr().String()
+	if s.logger != nil {
+		s.logger.Infow("http server listening", "addr", s.server.Addr, "tls", s.reloader != nil)
+	}
+
+	go func() {
+		var err error
+		if s.reloader != nil {
+			// the certificate is served by GetCertificate of the TLS config
+			err = s.server.ServeTLS(listener, "", "")
+		} else {
+			err = s.server.Serve(listener)
+		}
+		if err != nil && err != http.ErrServerClosed {
+			panic(err)
+		}
+	}()
+	return nil
+}
+
+func (s *runningServer) stop(ctx context.Context) error {
+	if s.reloader != nil {
+		defer s.reloader.Close()
+	}
+	defer func() {
+		if err := removeSocket(&s.config, s.server.Addr); err != nil && s.logger != nil {
+			s.logger.Warnw("failed to remove socket", "err", err)
+		}
+	}()
+	draining := s.openConnections.Load()
+	err := s.server.Shutdown(ctx)
+	if errors.Is(err, context.DeadlineExceeded) {
+		remaining := s.openConnections.Load()
+		if s.logger != nil {
+			s.logger.Warnw("timeout in draining http connections, closing remaining connections",
+				"drained", draining-remaining, "remaining", remaining)
+		}
+		return s.server.Close()
+	}
+	if err != nil {
+		return err
+	}
+	if s.logger != nil {
+		s.logger.Infow("http server shut down", "drained", draining)
+	}
+	return nil
+}
+
+// RunHttpServer runs the default server and the named servers. The start fails when any server cannot listen,
+// the servers already started are closed. They are shut down concurrently, so the shutdown timeout applies to all of them.
 func RunHttpServer(p RunHttpParams) {
+	servers := []*runningServer{newRunningServer("", p.Config.server(), p.HttpServer, p.Logger)}
+	names := make([]string, 0, len(p.Servers))
+	for name := range p.Servers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		servers = append(servers, newRunningServer(name, p.Config.Servers[name], p.Servers[name], p.Logger))
+	}
+
 	p.Lifecycle.Append(fx.Hook{
 		OnStart: func(context.Context) error {
-			go func() {
-				if err := p.HttpServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-					panic(err)
+			for i, server := range servers {
+				if err := server.start(); err != nil {
+					for _, started := range servers[:i] {
+						started.server.Close()
+						if started.reloader != nil {
+							started.reloader.Close()
+						}
+					}
+					return err
 				}
-			}()
+			}
 			return nil
 		},
 		OnStop: func(ctx context.Context) error {
-			return p.HttpServer.Shutdown(ctx)
+			if p.Config.ShutdownTimeout > 0 {
+				var cancel context.CancelFunc
+				ctx, cancel = context.WithTimeout(ctx, p.Config.ShutdownTimeout)
+				defer cancel()
+			}
+			errs := make([]error, len(servers))
+			var wg sync.WaitGroup
+			for i, server := range servers {
+				wg.Add(1)
+				go func(i int, server *runningServer) {
+					defer wg.Done()
+					if err := server.stop(ctx); err != nil && server.name != "" {
+						errs[i] = fmt.Errorf("error in shutting down http server %s: %w", server.name, err)
+					} else {
+						errs[i] = err
+					}
+				}(i, server)
+			}
+			wg.Wait()
+			return multierr.Combine(errs...)
 		},
 	})
 }
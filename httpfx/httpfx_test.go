@@ -0,0 +1,364 @@
+package httpfx_test
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"path"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"go.uber.org/fx"
+	"go.uber.org/fx/fxtest"
+
+	scalpelconfig "github.com/prismedic/scalpel/config"
+	"github.com/prismedic/scalpel/httpfx"
+)
+
+func newTestApp(t *testing.T, config *httpfx.HttpConfig, server **http.Server) *fxtest.App {
+	return fxtest.New(t,
+		httpfx.Module,
+		fx.Supply(config),
+		fx.Provide(func() http.Handler { return http.NotFoundHandler() }),
+		fx.Populate(server),
+	)
+}
+
+func TestRunHttpServer(t *testing.T) {
+	t.Run("Test random port", func(t *testing.T) {
+		var server *http.Server
+		app := newTestApp(t, &httpfx.HttpConfig{ListenAddr: "127.0.0.1:0"}, &server)
+		app.RequireStart()
+		defer app.RequireStop()
+		if strings.HasSuffix(server.Addr, ":0") {
+			t.Fatalf("server address is not updated with the picked port, got %s", server.Addr)
+		}
+		response, err := http.Get("http://" + server.Addr)
+		if err != nil {
+			t.Fatalf("failed to request server: %v", err)
+		}
+		response.Body.Close()
+		if response.StatusCode != http.StatusNotFound {
+			t.Errorf("unexpected status code, got %d, expected %d", response.StatusCode, http.StatusNotFound)
+		}
+	})
+	t.Run("Test port in use", func(t *testing.T) {
+		listener, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			t.Fatalf("failed to listen: %v", err)
+		}
+		defer listener.Close()
+		var server *http.Server
+		app := newTestApp(t, &httpfx.HttpConfig{ListenAddr: listener.Addr().String()}, &server)
+		err = app.Start(context.Background())
+		if err == nil {
+			app.RequireStop()
+			t.Fatalf("expected start to fail when the port is in use")
+		}
+		if !strings.Contains(err.Error(), "error in listening on "+listener.Addr().String()) {
+			t.Errorf("unexpected error %v", err)
+		}
+	})
+	t.Run("Test unix socket", func(t *testing.T) {
+		socketPath := path.Join(t.TempDir(), "app.sock")
+		// a socket file left by a process that was killed
+		stale, err := net.Listen("unix", socketPath)
+		if err != nil {
+			t.Fatalf("failed to listen: %v", err)
+		}
+		stale.(*net.UnixListener).SetUnlinkOnClose(false)
+		stale.Close()
+
+		var server *http.Server
+		config := &httpfx.HttpConfig{ListenAddr: socketPath, Network: httpfx.UnixNetwork, SocketMode: "0600"}
+		app := newTestApp(t, config, &server)
+		app.RequireStart()
+		info, err := os.Stat(socketPath)
+		if err != nil {
+			t.Fatalf("failed to stat socket: %v", err)
+		}
+		if mode := info.Mode().Perm(); mode != 0o600 {
+			t.Errorf("unexpected socket mode, got %o, expected 600", mode)
+		}
+		client := &http.Client{Transport: &http.Transport{
+			DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+				return (&net.Dialer{}).DialContext(ctx, "unix", socketPath)
+			},
+		}}
+		response, err := client.Get("http://unix/")
+		if err != nil {
+			t.Fatalf("failed to request server: %v", err)
+		}
+		response.Body.Close()
+		if response.StatusCode != http.StatusNotFound {
+			t.Errorf("unexpected status code, got %d, expected %d", response.StatusCode, http.StatusNotFound)
+		}
+		app.RequireStop()
+		if _, err := os.Stat(socketPath); !os.IsNotExist(err) {
+			t.Errorf("socket is not removed on stop: %v", err)
+		}
+	})
+	t.Run("Test unix socket path of a regular file", func(t *testing.T) {
+		filePath := path.Join(t.TempDir(), "app.sock")
+		if err := os.WriteFile(filePath, []byte("data"), 0o600); err != nil {
+			t.Fatalf("failed to write file: %v", err)
+		}
+		var server *http.Server
+		app := newTestApp(t, &httpfx.HttpConfig{ListenAddr: filePath, Network: httpfx.UnixNetwork}, &server)
+		if err := app.Start(context.Background()); err == nil {
+			app.RequireStop()
+			t.Fatalf("expected start to fail when the path is a regular file")
+		}
+		if content, err := os.ReadFile(filePath); err != nil || string(content) != "data" {
+			t.Errorf("regular file is modified, got %q: %v", content, err)
+		}
+	})
+	t.Run("Test named servers", func(t *testing.T) {
+		var server *http.Server
+		var servers httpfx.HttpServers
+		config := &httpfx.HttpConfig{
+			ListenAddr: "127.0.0.1:0",
+			Servers: map[string]httpfx.ServerConfig{
+				"admin": {ListenAddr: "127.0.0.1:0"},
+				"idle":  {ListenAddr: "127.0.0.1:0"},
+			},
+		}
+		app := fxtest.New(t,
+			httpfx.Module,
+			fx.Supply(config),
+			fx.Provide(func() http.Handler { return http.NotFoundHandler() }),
+			fx.Provide(fx.Annotate(
+				func() map[string]http.Handler {
+					return map[string]http.Handler{"admin": http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+						w.WriteHeader(http.StatusNoContent)
+					})}
+				},
+				fx.ResultTags(`name:"serverHandlers"`),
+			)),
+			fx.Populate(&server, &servers),
+		)
+		app.RequireStart()
+		for _, test := range []struct {
+			server       *http.Server
+			expectedCode int
+		}{
+			{server: server, expectedCode: http.StatusNotFound},
+			{server: servers["admin"], expectedCode: http.StatusNoContent},
+			{server: servers["idle"], expectedCode: http.StatusNotFound},
+		} {
+			response, err := http.Get("http://" + test.server.Addr)
+			if err != nil {
+				t.Fatalf("failed to request server %s: %v", test.server.Addr, err)
+			}
+			response.Body.Close()
+			if response.StatusCode != test.expectedCode {
+				t.Errorf("unexpected status code of %s, got %d, expected %d", test.server.Addr, response.StatusCode, test.expectedCode)
+			}
+		}
+		app.RequireStop()
+		for name, named := range servers {
+			if _, err := http.Get("http://" + named.Addr); err == nil {
+				t.Errorf("server %s is not shut down", name)
+			}
+		}
+	})
+	t.Run("Test routes of an unknown server", func(t *testing.T) {
+		app := fx.New(
+			httpfx.Module,
+			fx.Supply(&httpfx.HttpConfig{ListenAddr: "127.0.0.1:0"}),
+			fx.Provide(func() http.Handler { return http.NotFoundHandler() }),
+			fx.Provide(fx.Annotate(
+				func() map[string]http.Handler { return map[string]http.Handler{"admin": http.NotFoundHandler()} },
+				fx.ResultTags(`name:"serverHandlers"`),
+			)),
+			fx.NopLogger,
+		)
+		// the errors of the constructors are not wrapped by fx
+		if err := app.Err(); err == nil || !strings.Contains(err.Error(), httpfx.ErrUnknownServer.Error()) {
+			t.Errorf("unexpected error, got %v, expected %v", err, httpfx.ErrUnknownServer)
+		}
+	})
+}
+
+func TestServerTimeouts(t *testing.T) {
+	t.Run("Test default timeouts", func(t *testing.T) {
+		server := httpfx.NewHttp(httpfx.HttpParams{Config: &httpfx.HttpConfig{}, Handler: http.NotFoundHandler()})
+		if server.ReadHeaderTimeout != 10*time.Second || server.ReadTimeout != time.Minute ||
+			server.WriteTimeout != time.Minute || server.IdleTimeout != 2*time.Minute {
+			t.Errorf("unexpected default timeouts, got %v %v %v %v", server.ReadHeaderTimeout, server.ReadTimeout, server.WriteTimeout, server.IdleTimeout)
+		}
+	})
+	t.Run("Test configured timeouts", func(t *testing.T) {
+		config := &httpfx.HttpConfig{
+			Timeouts: httpfx.TimeoutsConfig{ReadHeader: time.Second, Write: -1},
+			Servers:  map[string]httpfx.ServerConfig{"admin": {ListenAddr: "127.0.0.1:0"}},
+		}
+		server := httpfx.NewHttp(httpfx.HttpParams{Config: config, Handler: http.NotFoundHandler()})
+		if server.ReadHeaderTimeout != time.Second || server.WriteTimeout != 0 {
+			t.Errorf("unexpected timeouts, got %v %v", server.ReadHeaderTimeout, server.WriteTimeout)
+		}
+		servers, err := httpfx.NewHttpServers(httpfx.HttpServersParams{Config: config})
+		if err != nil {
+			t.Fatalf("failed to create http servers: %v", err)
+		}
+		if admin := servers["admin"]; admin.ReadHeaderTimeout != time.Second || admin.WriteTimeout != 0 {
+			t.Errorf("unexpected timeouts of the named server, got %v %v", admin.ReadHeaderTimeout, admin.WriteTimeout)
+		}
+	})
+	t.Run("Test named server timeouts", func(t *testing.T) {
+		config := &httpfx.HttpConfig{
+			Timeouts: httpfx.TimeoutsConfig{ReadHeader: time.Second, Write: time.Minute},
+			Servers: map[string]httpfx.ServerConfig{
+				"admin":    {ListenAddr: "127.0.0.1:0"},
+				"internal": {ListenAddr: "127.0.0.1:0", Timeouts: httpfx.TimeoutsConfig{Read: time.Second, Write: 5 * time.Minute}},
+			},
+		}
+		servers, err := httpfx.NewHttpServers(httpfx.HttpServersParams{Config: config})
+		if err != nil {
+			t.Fatalf("failed to create http servers: %v", err)
+		}
+		// the write timeout of http.timeouts would cut off the long profiles of the admin routes
+		if admin := servers["admin"]; admin.ReadHeaderTimeout != time.Second || admin.WriteTimeout != 0 {
+			t.Errorf("unexpected timeouts of the admin server, got %v %v", admin.ReadHeaderTimeout, admin.WriteTimeout)
+		}
+		if internal := servers["internal"]; internal.ReadHeaderTimeout != time.Second || internal.ReadTimeout != time.Second ||
+			internal.WriteTimeout != 5*time.Minute || internal.IdleTimeout != 2*time.Minute {
+			t.Errorf("unexpected timeouts of the internal server, got %v %v %v %v",
+				internal.ReadHeaderTimeout, internal.ReadTimeout, internal.WriteTimeout, internal.IdleTimeout)
+		}
+	})
+	t.Run("Test slow headers", func(t *testing.T) {
+		var server *http.Server
+		config := &httpfx.HttpConfig{ListenAddr: "127.0.0.1:0", Timeouts: httpfx.TimeoutsConfig{ReadHeader: 50 * time.Millisecond}}
+		app := newTestApp(t, config, &server)
+		app.RequireStart()
+		defer app.RequireStop()
+		conn, err := net.Dial("tcp", server.Addr)
+		if err != nil {
+			t.Fatalf("failed to connect to server: %v", err)
+		}
+		defer conn.Close()
+		if _, err := conn.Write([]byte("GET / HTTP/1.1\r\n")); err != nil {
+			t.Fatalf("failed to write request line: %v", err)
+		}
+		// the server closes the connection without waiting for the rest of the headers
+		_ = conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+		if _, err := io.ReadAll(conn); err != nil {
+			t.Errorf("connection is not closed by the server: %v", err)
+		}
+	})
+}
+
+func TestShutdown(t *testing.T) {
+	newSlowApp := func(t *testing.T, config *httpfx.HttpConfig, handler http.HandlerFunc) (*fxtest.App, *http.Server) {
+		var server *http.Server
+		app := fxtest.New(t,
+			httpfx.Module,
+			fx.Supply(config),
+			fx.Provide(func() http.Handler { return handler }),
+			fx.Populate(&server),
+		)
+		app.RequireStart()
+		return app, server
+	}
+	type result struct {
+		body string
+		err  error
+	}
+	request := func(server *http.Server) <-chan result {
+		results := make(chan result, 1)
+		go func() {
+			response, err := http.Get("http://" + server.Addr)
+			if err != nil {
+				results <- result{err: err}
+				return
+			}
+			defer response.Body.Close()
+			body, err := io.ReadAll(response.Body)
+			results <- result{body: string(body), err: err}
+		}()
+		return results
+	}
+
+	t.Run("Test in-flight requests drained", func(t *testing.T) {
+		started := make(chan struct{})
+		app, server := newSlowApp(t, &httpfx.HttpConfig{ListenAddr: "127.0.0.1:0", ShutdownTimeout: 5 * time.Second},
+			func(w http.ResponseWriter, r *http.Request) {
+				close(started)
+				time.Sleep(200 * time.Millisecond)
+				_, _ = w.Write([]byte("done"))
+			})
+		results := request(server)
+		<-started
+		start := time.Now()
+		app.RequireStop()
+		if elapsed := time.Since(start); elapsed < 100*time.Millisecond {
+			t.Errorf("stop does not wait for the in-flight request, took %s", elapsed)
+		}
+		if result := <-results; result.err != nil || result.body != "done" {
+			t.Errorf("in-flight request is not drained, got %q: %v", result.body, result.err)
+		}
+	})
+	t.Run("Test shutdown timeout", func(t *testing.T) {
+		started := make(chan struct{})
+		release := make(chan struct{})
+		defer close(release)
+		app, server := newSlowApp(t, &httpfx.HttpConfig{ListenAddr: "127.0.0.1:0", ShutdownTimeout: 50 * time.Millisecond},
+			func(w http.ResponseWriter, r *http.Request) {
+				close(started)
+				<-release
+			})
+		results := request(server)
+		<-started
+		start := time.Now()
+		app.RequireStop()
+		if elapsed := time.Since(start); elapsed > 2*time.Second {
+			t.Errorf("stop is not bounded by the shutdown timeout, took %s", elapsed)
+		}
+		if result := <-results; result.err == nil {
+			t.Errorf("expected the remaining connection to be closed, got %q", result.body)
+		}
+		if _, err := net.Dial("tcp", server.Addr); err == nil {
+			t.Errorf("listener is still open after the shutdown timeout")
+		}
+	})
+}
+
+func TestListenAddrValidation(t *testing.T) {
+	validate, err := httpfx.RegisterHttpValidation(validator.New())
+	if err != nil {
+		t.Fatalf("failed to register validation: %v", err)
+	}
+	for _, test := range []struct {
+		listenAddr string
+		network    string
+		valid      bool
+	}{
+		{":8080", "", true},
+		{"0.0.0.0:8080", httpfx.TCPNetwork, true},
+		{"127.0.0.1:0", "", true},
+		{"[::]:8080", "", true},
+		{"localhost:8080", "", true},
+		{"8080", "", false},
+		{"localhost:http", "", false},
+		{":70000", "", false},
+		{"local host:8080", "", false},
+		{"/run/app.sock", "", false},
+		{"/run/app.sock", httpfx.UnixNetwork, true},
+	} {
+		config := &httpfx.HttpConfig{ListenAddr: test.listenAddr, Network: test.network}
+		if err := validate.Struct(config); (err == nil) != test.valid {
+			t.Errorf("unexpected validation of %s on network %q, got %v, expected valid %t", test.listenAddr, test.network, err, test.valid)
+		}
+	}
+
+	config := &httpfx.HttpConfig{ListenAddr: ":8080", Servers: map[string]httpfx.ServerConfig{"admin": {ListenAddr: "9090"}}}
+	err = scalpelconfig.ValidateStruct(validate, "http", config)
+	if expected := "http.servers.admin.listen_addr: must be a valid listenaddr"; err == nil || err.Error() != expected {
+		t.Errorf("unexpected validation error, got %v, expected %s", err, expected)
+	}
+}
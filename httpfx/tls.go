@@ -0,0 +1,111 @@
+package httpfx
+
+import (
+	"crypto/tls"
+	"fmt"
+	"path/filepath"
+	"sync"
+
+	"github.com/fsnotify/fsnotify"
+	"go.uber.org/zap"
+)
+
+// TLSConfig enables TLS when both files are set, the server serves plain HTTP when both are empty
+type TLSConfig struct {
+	CertFile string `mapstructure:"cert_file" yaml:"cert_file" validate:"required_with=KeyFile"`
+	KeyFile  string `mapstructure:"key_file" yaml:"key_file" validate:"required_with=CertFile"`
+}
+
+func (c *TLSConfig) Enabled() bool {
+	return c.CertFile != "" && c.KeyFile != ""
+}
+
+// certReloader serves the certificate of the files and reloads it when they change,
+// so a rotated certificate is used without a restart
+type certReloader struct {
+	certFile string
+	keyFile  string
+	logger   *zap.SugaredLogger
+	watcher  *fsnotify.Watcher
+
+	mutex sync.RWMutex
+	cert  *tls.Certificate
+}
+
+func newCertReloader(config *TLSConfig, logger *zap.SugaredLogger) (*certReloader, error) {
+	reloader := &certReloader{
+		certFile: config.CertFile,
+		keyFile:  config.KeyFile,
+		logger:   logger,
+	}
+	if err := reloader.reload(); err != nil {
+		return nil, err
+	}
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, fmt.Errorf("error in watching certificate files: %w", err)
+	}
+	// the folders are watched rather than the files, as mounted secrets are replaced by renaming a symlink
+	for _, dir := range []string{filepath.Dir(config.CertFile), filepath.Dir(config.KeyFile)} {
+		if err := watcher.Add(dir); err != nil {
+			watcher.Close()
+			return nil, fmt.Errorf("error in watching certificate folder %s: %w", dir, err)
+		}
+	}
+	reloader.watcher = watcher
+	go reloader.watch()
+	return reloader, nil
+}
+
+func (r *certReloader) reload() error {
+	cert, err := tls.LoadX509KeyPair(r.certFile, r.keyFile)
+	if err != nil {
+		return fmt.Errorf("error in loading certificate: %w", err)
+	}
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.cert = &cert
+	return nil
+}
+
+func (r *certReloader) watch() {
+	for {
+		select {
+		case event, ok := <-r.watcher.Events:
+			if !ok {
+				return
+			}
+			if event.Op == fsnotify.Chmod {
+				continue
+			}
+			// keep serving the current certificate when the new files are incomplete or invalid
+			if err := r.reload(); err != nil {
+				if r.logger != nil {
+					r.logger.Warnw("Fail to reload certificate, keeping the current one", "err", err)
+				}
+				continue
+			}
+			if r.logger != nil {
+				r.logger.Infow("Reloaded certificate", "cert_file", r.certFile)
+			}
+		case err, ok := <-r.watcher.Errors:
+			if !ok {
+				return
+			}
+			if r.logger != nil {
+				r.logger.Warnw("Error in watching certificate files", "err", err)
+			}
+		}
+	}
+}
+
+func (r *certReloader) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+	return r.cert, nil
+}
+
+func (r *certReloader) Close() error {
+	return r.watcher.Close()
+}
@@ -0,0 +1,86 @@
+package httpfx_test
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net/http"
+	"os"
+	"path"
+	"testing"
+	"time"
+
+	"github.com/prismedic/scalpel/httpfx"
+)
+
+// writeCertificate writes a self-signed certificate with the serial number
+func writeCertificate(t *testing.T, certFile string, keyFile string, serial int64) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(serial),
+		Subject:      pkix.Name{CommonName: "localhost"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("failed to create certificate: %v", err)
+	}
+	keyDer, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatalf("failed to marshal key: %v", err)
+	}
+	// the key is written first, so the pair is complete when the certificate changes
+	if err := os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer}), 0600); err != nil {
+		t.Fatalf("failed to write key: %v", err)
+	}
+	if err := os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0600); err != nil {
+		t.Fatalf("failed to write certificate: %v", err)
+	}
+}
+
+func servedSerial(t *testing.T, addr string) int64 {
+	conn, err := tls.Dial("tcp", addr, &tls.Config{InsecureSkipVerify: true})
+	if err != nil {
+		t.Fatalf("failed to connect: %v", err)
+	}
+	defer conn.Close()
+	return conn.ConnectionState().PeerCertificates[0].SerialNumber.Int64()
+}
+
+func TestTLS(t *testing.T) {
+	dir := t.TempDir()
+	config := &httpfx.HttpConfig{ListenAddr: "127.0.0.1:0"}
+	config.TLS.CertFile = path.Join(dir, "tls.crt")
+	config.TLS.KeyFile = path.Join(dir, "tls.key")
+	writeCertificate(t, config.TLS.CertFile, config.TLS.KeyFile, 1)
+
+	var server *http.Server
+	app := newTestApp(t, config, &server)
+	app.RequireStart()
+	defer app.RequireStop()
+
+	t.Run("Test serving certificate", func(t *testing.T) {
+		if serial := servedSerial(t, server.Addr); serial != 1 {
+			t.Errorf("unexpected certificate serial, got %d, expected 1", serial)
+		}
+	})
+	t.Run("Test reloading certificate", func(t *testing.T) {
+		writeCertificate(t, config.TLS.CertFile, config.TLS.KeyFile, 2)
+		deadline := time.Now().Add(5 * time.Second)
+		for servedSerial(t, server.Addr) != 2 {
+			if time.Now().After(deadline) {
+				t.Fatalf("certificate is not reloaded")
+			}
+			time.Sleep(50 * time.Millisecond)
+		}
+	})
+}
@@ -0,0 +1,77 @@
+package httpfx
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"net"
+	"os"
+	"strconv"
+)
+
+// networks of the listener
+const (
+	TCPNetwork  = "tcp"
+	UnixNetwork = "unix"
+)
+
+// listen listens on the address of the config, a host:port for tcp or the path of the socket file for unix
+func listen(config *ServerConfig, addr string) (net.Listener, error) {
+	if config.Network != UnixNetwork {
+		return net.Listen(TCPNetwork, addr)
+	}
+	var mode fs.FileMode
+	if config.SocketMode != "" {
+		parsed, err := strconv.ParseUint(config.SocketMode, 8, 32)
+		if err != nil {
+			return nil, fmt.Errorf("error in parsing socket mode %q: %w", config.SocketMode, err)
+		}
+		mode = fs.FileMode(parsed)
+	}
+	if err := removeStaleSocket(addr); err != nil {
+		return nil, err
+	}
+	listener, err := net.Listen(UnixNetwork, addr)
+	if err != nil {
+		return nil, err
+	}
+	if mode != 0 {
+		if err := os.Chmod(addr, mode); err != nil {
+			listener.Close()
+			return nil, fmt.Errorf("error in changing mode of socket %s: %w", addr, err)
+		}
+	}
+	return listener, nil
+}
+
+// removeStaleSocket removes the socket file left by a process that was not shut down,
+// other files at the path are kept so that a misconfigured path does not delete them
+func removeStaleSocket(path string) error {
+	info, err := os.Lstat(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("error in checking socket %s: %w", path, err)
+	}
+	if info.Mode()&fs.ModeSocket == 0 {
+		return fmt.Errorf("error in removing stale socket %s: not a socket", path)
+	}
+	// the socket of a running server accepts the dial, it is only stale when the dial fails
+	if conn, err := net.Dial(UnixNetwork, path); err == nil {
+		conn.Close()
+		return fmt.Errorf("error in removing stale socket %s: socket is in use", path)
+	}
+	return os.Remove(path)
+}
+
+// removeSocket removes the socket file on shutdown, net.UnixListener already unlinks it when it is closed
+func removeSocket(config *ServerConfig, addr string) error {
+	if config.Network != UnixNetwork {
+		return nil
+	}
+	if err := os.Remove(addr); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("error in removing socket %s: %w", addr, err)
+	}
+	return nil
+}
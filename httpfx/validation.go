@@ -0,0 +1,49 @@
+package httpfx
+
+import (
+	"net"
+	"regexp"
+	"strconv"
+
+	"github.com/go-playground/validator/v10"
+	"go.uber.org/fx"
+
+	"github.com/prismedic/scalpel/config"
+)
+
+// Validations provides the listenaddr validation of the HttpConfig to config.ValidationModule
+var Validations = fx.Provide(
+	fx.Annotate(
+		httpValidations,
+		fx.ResultTags(`group:"validations,flatten"`),
+	),
+)
+
+func httpValidations() []config.Validation {
+	return []config.Validation{
+		{Tag: "listenaddr", Func: validateListenAddr},
+	}
+}
+
+// RegisterHttpValidation registers the validations of the HttpConfig on a validator outside of an fx app
+func RegisterHttpValidation(validate *validator.Validate) (*validator.Validate, error) {
+	return config.RegisterValidations(validate, httpValidations())
+}
+
+var hostnameRegexp = regexp.MustCompile(`^[a-zA-Z0-9]([a-zA-Z0-9.-]*[a-zA-Z0-9])?$`)
+
+// validateListenAddr checks the host:port of a tcp listener, port 0 picks a random port.
+// The address of the unix network is the path of the socket file, it is not checked.
+func validateListenAddr(fieldLevel validator.FieldLevel) bool {
+	if network := fieldLevel.Parent().FieldByName("Network"); network.IsValid() && network.String() == UnixNetwork {
+		return true
+	}
+	host, port, err := net.SplitHostPort(fieldLevel.Field().String())
+	if err != nil {
+		return false
+	}
+	if _, err := strconv.ParseUint(port, 10, 16); err != nil {
+		return false
+	}
+	return host == "" || net.ParseIP(host) != nil || hostnameRegexp.MatchString(host)
+}
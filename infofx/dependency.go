@@ -0,0 +1,57 @@
+package infofx
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Dependencies runs the health checks gating the controllers declaring a dependency, see routerfx.DependentRoute.
+// A result is kept for the cache TTL of the config, so the checks do not run on each request,
+// and the thresholds of the checks apply like in the health response.
+// The readiness route runs the same checks with their own results, the gates turn away the requests of the routes
+// of a failing dependency before the readiness probe takes the service out of the traffic.
+type Dependencies struct {
+	checks   map[string]*dependency
+	states   *checkStates
+	timeouts HealthConfig
+}
+
+// dependency is a health check with its last result, the mutex lets a single request run an expired check
+type dependency struct {
+	check     HealthCheck
+	mutex     sync.Mutex
+	err       error
+	checkedAt time.Time
+}
+
+// NewDependencies returns the dependencies of the health checks, the config is optional
+func NewDependencies(checks []HealthCheck, config *HealthConfig) *Dependencies {
+	dependencies := make(map[string]*dependency, len(checks))
+	for _, check := range checks {
+		dependencies[check.Name()] = &dependency{check: check}
+	}
+	return &Dependencies{checks: dependencies, states: newCheckStates(), timeouts: checkTimeouts(config)}
+}
+
+func (d *Dependencies) HasDependency(name string) bool {
+	_, ok := d.checks[name]
+	return ok
+}
+
+// CheckDependency returns the cached result of the health check, or runs it when the result is older than the cache TTL.
+// The check does not use the context of the request, so a canceled request is not cached as a failure.
+func (d *Dependencies) CheckDependency(_ context.Context, name string) error {
+	dependency, ok := d.checks[name]
+	if !ok {
+		return ErrUnknownDependency
+	}
+	dependency.mutex.Lock()
+	defer dependency.mutex.Unlock()
+	if !dependency.checkedAt.IsZero() && time.Since(dependency.checkedAt) < d.timeouts.DependencyCacheTTL {
+		return dependency.err
+	}
+	dependency.err = d.states.report(dependency.check, runCheck(context.Background(), dependency.check, d.timeouts.CheckTimeout))
+	dependency.checkedAt = time.Now()
+	return dependency.err
+}
@@ -1,29 +1,196 @@
 package infofx
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"net/http"
+	"sync"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/spf13/viper"
+	"go.uber.org/fx"
 )
 
-type HealthController struct{}
+// HealthCheckTimeout is the time limit of each health or readiness check when no HealthConfig is provided
+var HealthCheckTimeout = 5 * time.Second
 
-type HealthResponse struct {
+// ErrCheckTimeout is the result of a check that did not return before its timeout or the overall deadline
+var ErrCheckTimeout = errors.New("timeout")
+
+// ErrUnknownDependency is the result of a dependency without a health check of its name
+var ErrUnknownDependency = errors.New("unknown dependency")
+
+type HealthConfig struct {
+	// CheckTimeout is the time limit of each check
+	CheckTimeout time.Duration `mapstructure:"check_timeout" yaml:"check_timeout" validate:"min=0"`
+	// TotalTimeout is the time limit of all the checks of a request, the checks still running are failed with a timeout.
+	// As the checks run concurrently, it only matters when it is lower than the check timeout, 0 disables it.
+	TotalTimeout time.Duration `mapstructure:"total_timeout" yaml:"total_timeout" validate:"min=0"`
+	// DependencyCacheTTL is how long the result of the check of a route dependency is kept, 0 runs it on each request
+	DependencyCacheTTL time.Duration `mapstructure:"dependency_cache_ttl" yaml:"dependency_cache_ttl" validate:"min=0"`
+	// Server is the name of the server of http.servers serving the health and readiness routes, the default server when empty
+	Server string `mapstructure:"server" yaml:"server"`
+}
+
+func init() {
+	// config must have a default value for viper to load config from env variables
+	viper.SetDefault("health.check_timeout", 5*time.Second)
+	viper.SetDefault("health.total_timeout", 0)
+	viper.SetDefault("health.dependency_cache_ttl", time.Second)
+	viper.SetDefault("health.server", "")
+}
+
+// checkTimeouts returns the timeouts of the config, HealthCheckTimeout without a total timeout when it is not provided
+func checkTimeouts(config *HealthConfig) HealthConfig {
+	if config == nil {
+		return HealthConfig{CheckTimeout: HealthCheckTimeout}
+	}
+	timeouts := *config
+	if timeouts.CheckTimeout <= 0 {
+		timeouts.CheckTimeout = HealthCheckTimeout
+	}
+	return timeouts
+}
+
+const (
+	StatusOK       = "OK"
+	StatusNotReady = "NOT_READY"
+)
+
+// HealthCheck is a named check of a dependency such as a database or a downstream service.
+// The checks must pass before the service is ready to receive traffic, and they gate the routes depending on them,
+// see Dependencies. They are not run by the liveness route, so a failing dependency does not restart the service.
+type HealthCheck interface {
+	Name() string
+	Check(ctx context.Context) error
+}
+
+func AsHealthCheck(check any) any {
+	return fx.Annotate(
+		check,
+		fx.As(new(HealthCheck)),
+		fx.ResultTags(`group:"healthChecks"`),
+	)
+}
+
+// checkFunc is the HealthCheck of NewHealthCheck
+type checkFunc struct {
+	name  string
+	check func(ctx context.Context) error
+}
+
+// NewHealthCheck returns the health check running the function, e.g. the ping of a client
+func NewHealthCheck(name string, check func(ctx context.Context) error) HealthCheck {
+	return &checkFunc{name: name, check: check}
+}
+
+func (c *checkFunc) Name() string {
+	return c.name
+}
+
+func (c *checkFunc) Check(ctx context.Context) error {
+	return c.check(ctx)
+}
+
+type CheckResponse struct {
 	Status string `json:"status"`
+	// Checks maps the name of each check to "OK" or the error of the check, they are only set in the readiness response
+	Checks map[string]string `json:"checks,omitempty"`
+	// StartedAt and UptimeSeconds are only set in the health response
+	StartedAt     *time.Time `json:"started_at,omitempty"`
+	UptimeSeconds float64    `json:"uptime_seconds,omitempty"`
+}
+
+// runChecks runs all checks concurrently, each with its own timeout and all within the total timeout.
+// The results are reported after the thresholds of the checks, which are kept in the states.
+func runChecks(ctx context.Context, checks []HealthCheck, states *checkStates, timeouts HealthConfig) (*CheckResponse, bool) {
+	response := &CheckResponse{Status: StatusOK}
+	if len(checks) == 0 {
+		return response, true
+	}
+	response.Checks = make(map[string]string, len(checks))
+	if timeouts.TotalTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeouts.TotalTimeout)
+		defer cancel()
+	}
+
+	var mutex sync.Mutex
+	var wg sync.WaitGroup
+	for _, check := range checks {
+		wg.Add(1)
+		go func(check HealthCheck) {
+			defer wg.Done()
+			err := states.report(check, runCheck(ctx, check, timeouts.CheckTimeout))
+			mutex.Lock()
+			defer mutex.Unlock()
+			if err != nil {
+				response.Status = StatusNotReady
+				response.Checks[check.Name()] = fmt.Sprintf("error: %v", err)
+			} else {
+				response.Checks[check.Name()] = StatusOK
+			}
+		}(check)
+	}
+	wg.Wait()
+	return response, response.Status == StatusOK
+}
+
+// runCheck returns as soon as the check or its context is done, a missed deadline is reported as ErrCheckTimeout
+func runCheck(ctx context.Context, check HealthCheck, timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	// buffered so that the goroutine of a check ignoring its context can still exit
+	result := make(chan error, 1)
+	go func() {
+		result <- check.Check(ctx)
+	}()
+	var err error
+	select {
+	case err = <-result:
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+	if errors.Is(err, context.DeadlineExceeded) && errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return ErrCheckTimeout
+	}
+	return err
+}
+
+func writeCheckResponse(c *gin.Context, response *CheckResponse, ok bool) {
+	if !ok {
+		c.JSON(http.StatusServiceUnavailable, response)
+		return
+	}
+	c.JSON(http.StatusOK, response)
 }
 
-func NewHealthController() *HealthController {
-	return &HealthController{}
+type HealthController struct {
+	uptime *Uptime
+	server string
+}
+
+// NewHealthController returns the controller of the liveness route, the config is optional
+func NewHealthController(uptime *Uptime, config *HealthConfig) *HealthController {
+	return &HealthController{uptime: uptime, server: checkTimeouts(config).Server}
 }
 
 // getHealth godoc
+//
 //	@Summary		Get health status
-//	@Description	Get health status of the service
+//	@Description	Get liveness and uptime of the service, always OK once the process is up. The health checks are run by the readiness route.
 //	@Produce		json
-//	@Success		200	{object}	HealthResponse
+//	@Success		200	{object}	CheckResponse
 //	@Router			/healthz [get]
 func (hc *HealthController) getHealth(c *gin.Context) {
-	c.JSON(http.StatusOK, &HealthResponse{Status: "OK"})
+	startedAt := hc.uptime.StartedAt()
+	c.JSON(http.StatusOK, &CheckResponse{
+		Status:        StatusOK,
+		StartedAt:     &startedAt,
+		UptimeSeconds: time.Since(startedAt).Seconds(),
+	})
 }
 
 func (hc *HealthController) RegisterControllerRoutes(rg *gin.RouterGroup) {
@@ -33,3 +200,44 @@ func (hc *HealthController) RegisterControllerRoutes(rg *gin.RouterGroup) {
 func (hc *HealthController) RoutePattern() string {
 	return "/healthz"
 }
+
+func (hc *HealthController) RouteServer() string {
+	return hc.server
+}
+
+type ReadinessController struct {
+	checks   []HealthCheck
+	states   *checkStates
+	timeouts HealthConfig
+}
+
+// NewReadinessController returns the controller of the health checks, the config is optional
+func NewReadinessController(checks []HealthCheck, config *HealthConfig) *ReadinessController {
+	return &ReadinessController{checks: checks, states: newCheckStates(), timeouts: checkTimeouts(config)}
+}
+
+// getReadiness godoc
+//
+//	@Summary		Get readiness status
+//	@Description	Get readiness of the service and the results of its health checks, OK only when all the checks pass
+//	@Produce		json
+//	@Success		200	{object}	CheckResponse
+//	@Failure		503	{object}	CheckResponse
+//	@Router			/readyz [get]
+func (rc *ReadinessController) getReadiness(c *gin.Context) {
+	response, ok := runChecks(c.Request.Context(), rc.checks, rc.states, rc.timeouts)
+	writeCheckResponse(c, response, ok)
+}
+
+func (rc *ReadinessController) RegisterControllerRoutes(rg *gin.RouterGroup) {
+	rg.GET("/", rc.getReadiness)
+}
+
+func (rc *ReadinessController) RoutePattern() string {
+	return "/readyz"
+}
+
+// RouteServer is the server of the config, the timeouts keep the other fields of the config
+func (rc *ReadinessController) RouteServer() string {
+	return rc.timeouts.Server
+}
@@ -0,0 +1,249 @@
+package infofx_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/fx"
+	"go.uber.org/fx/fxtest"
+	"go.uber.org/zap"
+
+	"github.com/prismedic/scalpel/infofx"
+	"github.com/prismedic/scalpel/routerfx"
+)
+
+type testHealthCheck struct {
+	name string
+	err  error
+	wait time.Duration
+}
+
+func (hc *testHealthCheck) Name() string {
+	return hc.name
+}
+
+func (hc *testHealthCheck) Check(ctx context.Context) error {
+	select {
+	case <-time.After(hc.wait):
+		return hc.err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func serveCheck(t *testing.T, controller routerfx.ControllerRoute, path string) (int, *infofx.CheckResponse) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	controller.RegisterControllerRoutes(router.Group(controller.RoutePattern()))
+
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, path, nil))
+	var response infofx.CheckResponse
+	if err := json.Unmarshal(recorder.Body.Bytes(), &response); err != nil {
+		t.Fatalf("failed to parse response %s: %v", recorder.Body.String(), err)
+	}
+	return recorder.Code, &response
+}
+
+func getReadiness(t *testing.T, config *infofx.HealthConfig, checks ...infofx.HealthCheck) (int, *infofx.CheckResponse) {
+	return serveCheck(t, infofx.NewReadinessController(checks, config), "/readyz/")
+}
+
+func TestHealthController(t *testing.T) {
+	t.Run("Test uptime", func(t *testing.T) {
+		lifecycle := fxtest.NewLifecycle(t)
+		controller := infofx.NewHealthController(infofx.NewUptime(lifecycle), nil)
+		lifecycle.RequireStart()
+		defer lifecycle.RequireStop()
+		code, response := serveCheck(t, controller, "/healthz/")
+		if code != http.StatusOK || response.Status != infofx.StatusOK {
+			t.Errorf("unexpected response, got %d %+v", code, response)
+		}
+		if response.StartedAt == nil || time.Since(*response.StartedAt) > time.Minute {
+			t.Errorf("unexpected start time, got %v", response.StartedAt)
+		}
+		if response.UptimeSeconds <= 0 {
+			t.Errorf("unexpected uptime, got %f", response.UptimeSeconds)
+		}
+	})
+	t.Run("Test failing check", func(t *testing.T) {
+		var routes []routerfx.ControllerRoute
+		app := fxtest.New(t,
+			infofx.Module,
+			fx.Supply(zap.NewNop().Sugar()),
+			fx.Provide(infofx.AsHealthCheck(func() *testHealthCheck {
+				return &testHealthCheck{name: "db", err: errors.New("connection refused")}
+			})),
+			fx.Invoke(fx.Annotate(func(r []routerfx.ControllerRoute) { routes = r }, fx.ParamTags(`group:"controllerRoutes"`))),
+		)
+		app.RequireStart()
+		defer app.RequireStop()
+		for _, route := range routes {
+			switch route.(type) {
+			case *infofx.HealthController:
+				if code, response := serveCheck(t, route, "/healthz/"); code != http.StatusOK || response.Status != infofx.StatusOK || response.Checks != nil {
+					t.Errorf("unexpected liveness response, got %d %+v", code, response)
+				}
+			case *infofx.ReadinessController:
+				if code, response := serveCheck(t, route, "/readyz/"); code != http.StatusServiceUnavailable || response.Status != infofx.StatusNotReady {
+					t.Errorf("unexpected readiness response, got %d %+v", code, response)
+				}
+			}
+		}
+	})
+}
+
+func TestReadinessController(t *testing.T) {
+	t.Run("Test no checks", func(t *testing.T) {
+		code, response := getReadiness(t, nil)
+		if code != http.StatusOK || response.Status != infofx.StatusOK {
+			t.Errorf("unexpected response, got %d %+v", code, response)
+		}
+	})
+	t.Run("Test failing check", func(t *testing.T) {
+		code, response := getReadiness(t, nil,
+			&testHealthCheck{name: "db"},
+			infofx.NewHealthCheck("redis", func(context.Context) error { return errors.New("connection refused") }),
+		)
+		if code != http.StatusServiceUnavailable || response.Status != infofx.StatusNotReady {
+			t.Errorf("unexpected response, got %d %+v", code, response)
+		}
+		if response.Checks["db"] != infofx.StatusOK {
+			t.Errorf("unexpected db check result, got %s", response.Checks["db"])
+		}
+		if response.Checks["redis"] != "error: connection refused" {
+			t.Errorf("unexpected redis check result, got %s", response.Checks["redis"])
+		}
+	})
+	t.Run("Test check timeout", func(t *testing.T) {
+		config := &infofx.HealthConfig{CheckTimeout: 10 * time.Millisecond}
+		code, response := getReadiness(t, config, &testHealthCheck{name: "slow", wait: time.Second})
+		if code != http.StatusServiceUnavailable {
+			t.Errorf("unexpected status code, got %d, expected %d", code, http.StatusServiceUnavailable)
+		}
+		if response.Checks["slow"] != "error: timeout" {
+			t.Errorf("unexpected slow check result, got %s", response.Checks["slow"])
+		}
+	})
+	t.Run("Test total timeout", func(t *testing.T) {
+		config := &infofx.HealthConfig{CheckTimeout: time.Second, TotalTimeout: 20 * time.Millisecond}
+		start := time.Now()
+		code, response := getReadiness(t, config,
+			&testHealthCheck{name: "db"},
+			&testHealthCheck{name: "slow", wait: 500 * time.Millisecond},
+		)
+		if elapsed := time.Since(start); elapsed > 200*time.Millisecond {
+			t.Errorf("response not sent at the total timeout, took %s", elapsed)
+		}
+		if code != http.StatusServiceUnavailable {
+			t.Errorf("unexpected status code, got %d, expected %d", code, http.StatusServiceUnavailable)
+		}
+		if response.Checks["db"] != infofx.StatusOK || response.Checks["slow"] != "error: timeout" {
+			t.Errorf("unexpected check results, got %v", response.Checks)
+		}
+	})
+}
+
+func TestCheckThresholds(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	check := &testHealthCheck{name: "db"}
+	controller := infofx.NewReadinessController([]infofx.HealthCheck{infofx.WithThresholds(check, 3, 2)}, nil)
+	controller.RegisterControllerRoutes(router.Group(controller.RoutePattern()))
+
+	failure := errors.New("connection refused")
+	for i, test := range []struct {
+		err          error
+		expectedCode int
+	}{
+		{nil, http.StatusOK},
+		{failure, http.StatusOK},
+		{failure, http.StatusOK},
+		{failure, http.StatusServiceUnavailable},
+		{nil, http.StatusServiceUnavailable},
+		{failure, http.StatusServiceUnavailable},
+		{nil, http.StatusServiceUnavailable},
+		{nil, http.StatusOK},
+	} {
+		check.err = test.err
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/readyz/", nil))
+		if recorder.Code != test.expectedCode {
+			t.Errorf("unexpected status code of check %d, got %d, expected %d", i, recorder.Code, test.expectedCode)
+		}
+	}
+}
+
+type testDependentController struct {
+	dependency string
+}
+
+func (tc *testDependentController) RegisterControllerRoutes(rg *gin.RouterGroup) {
+	rg.GET("/", func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+}
+
+func (tc *testDependentController) RoutePattern() string {
+	return "/orders"
+}
+
+func (tc *testDependentController) RouteDependency() string {
+	return tc.dependency
+}
+
+func TestDependencies(t *testing.T) {
+	newRouter := func(t *testing.T, config *infofx.HealthConfig, check infofx.HealthCheck) http.Handler {
+		result, err := routerfx.New(routerfx.Params{
+			Config:           &routerfx.Config{},
+			ControllerRoutes: []routerfx.ControllerRoute{&testDependentController{dependency: "database"}},
+			Dependencies:     infofx.NewDependencies([]infofx.HealthCheck{check}, config),
+		})
+		if err != nil {
+			t.Fatalf("failed to create router: %v", err)
+		}
+		return result.Router
+	}
+	getOrders := func(router http.Handler) int {
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/v1/orders/", nil))
+		return recorder.Code
+	}
+
+	t.Run("Test gated until the check passes", func(t *testing.T) {
+		check := &testHealthCheck{name: "database", err: errors.New("connection refused")}
+		router := newRouter(t, nil, check)
+		if code := getOrders(router); code != http.StatusServiceUnavailable {
+			t.Errorf("unexpected status code with a failing dependency, got %d, expected %d", code, http.StatusServiceUnavailable)
+		}
+		check.err = nil
+		if code := getOrders(router); code != http.StatusOK {
+			t.Errorf("unexpected status code with a ready dependency, got %d, expected %d", code, http.StatusOK)
+		}
+	})
+	t.Run("Test cached result", func(t *testing.T) {
+		check := &testHealthCheck{name: "database", err: errors.New("connection refused")}
+		router := newRouter(t, &infofx.HealthConfig{DependencyCacheTTL: time.Hour}, check)
+		getOrders(router)
+		check.err = nil
+		if code := getOrders(router); code != http.StatusServiceUnavailable {
+			t.Errorf("unexpected status code within the cache TTL, got %d, expected %d", code, http.StatusServiceUnavailable)
+		}
+	})
+	t.Run("Test unknown dependency", func(t *testing.T) {
+		_, err := routerfx.New(routerfx.Params{
+			Config:           &routerfx.Config{},
+			ControllerRoutes: []routerfx.ControllerRoute{&testDependentController{dependency: "cache"}},
+			Dependencies:     infofx.NewDependencies(nil, nil),
+		})
+		if !errors.Is(err, routerfx.ErrUnknownDependency) {
+			t.Errorf("unexpected error, got %v, expected %v", err, routerfx.ErrUnknownDependency)
+		}
+	})
+}
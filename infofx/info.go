@@ -3,24 +3,62 @@ package infofx
 import (
 	"errors"
 	"fmt"
+	"net/http"
 	"os"
 	"runtime"
 	"runtime/debug"
+	"time"
+
+	"github.com/gin-gonic/gin"
 
 	"github.com/prismedic/scalpel/config"
 )
 
+// build info set with ldflags, e.g. -ldflags "-X github.com/prismedic/scalpel/infofx.BuildDate=..."
+// the vcs info embedded by the go toolchain is used when they are empty
 var (
-	BuildDate string
+	BuildVersion string
+	BuildCommit  string
+	BuildDate    string
 )
 
+// startTime is an approximation of the process start time, used for the uptime
+var startTime = time.Now()
+
+type RuntimeStats struct {
+	Goroutines    int           `json:"goroutines"`
+	HeapAlloc     uint64        `json:"heap_alloc"`
+	NumGC         uint32        `json:"num_gc"`
+	Uptime        time.Duration `json:"-"`
+	UptimeSeconds float64       `json:"uptime_seconds"`
+}
+
 type InfoDisplay struct {
-	Name        string
-	Platform    string
-	Runtime     string
-	HostName    string
-	BuildCommit string
-	BuildDate   string
+	Name     string `json:"name"`
+	Platform string `json:"platform"`
+	Runtime  string `json:"runtime"`
+	HostName string `json:"host_name"`
+	// Version is the module version of the binary, "(devel)" when it is not built from a tagged module
+	Version     string `json:"version"`
+	BuildCommit string `json:"build_commit"`
+	BuildDate   string `json:"build_date"`
+	// Dirty is true when the binary is built from a modified working tree
+	Dirty     bool         `json:"dirty"`
+	StartedAt time.Time    `json:"started_at"`
+	Stats     RuntimeStats `json:"stats"`
+}
+
+func GetRuntimeStats() RuntimeStats {
+	var memStats runtime.MemStats
+	runtime.ReadMemStats(&memStats)
+	uptime := time.Since(startTime)
+	return RuntimeStats{
+		Goroutines:    runtime.NumGoroutine(),
+		HeapAlloc:     memStats.HeapAlloc,
+		NumGC:         memStats.NumGC,
+		Uptime:        uptime,
+		UptimeSeconds: uptime.Seconds(),
+	}
 }
 
 func GetInfo() (*InfoDisplay, error) {
@@ -38,13 +76,74 @@ func GetInfo() (*InfoDisplay, error) {
 	if !ok {
 		return nil, errors.New("failed to read build info")
 	}
-	buildCommit := os.Getenv("BUILD_COMMIT")
+	display.SetBuildInfo(buildInfo)
+	display.Stats = GetRuntimeStats()
+	return display, nil
+}
+
+// SetBuildInfo sets the version, commit, date and dirty flag of the build info embedded by the go toolchain.
+// The ldflags variables override the vcs info, BUILD_COMMIT is the commit of a build without vcs info.
+func (d *InfoDisplay) SetBuildInfo(buildInfo *debug.BuildInfo) {
+	d.Version = buildInfo.Main.Version
+	if BuildVersion != "" {
+		d.Version = BuildVersion
+	}
+	d.BuildCommit = os.Getenv("BUILD_COMMIT")
+	d.BuildDate = ""
+	d.Dirty = false
 	for _, buildSetting := range buildInfo.Settings {
-		if buildSetting.Key == "vcs.revision" {
-			buildCommit = buildSetting.Value
+		switch buildSetting.Key {
+		case "vcs.revision":
+			d.BuildCommit = buildSetting.Value
+		case "vcs.time":
+			d.BuildDate = buildSetting.Value
+		case "vcs.modified":
+			d.Dirty = buildSetting.Value == "true"
 		}
 	}
-	display.BuildCommit = buildCommit
-	display.BuildDate = BuildDate
-	return display, nil
+	if BuildCommit != "" {
+		d.BuildCommit = BuildCommit
+	}
+	if BuildDate != "" {
+		d.BuildDate = BuildDate
+	}
+}
+
+type InfoController struct {
+	// info holds the static fields, which are computed once at startup
+	info   InfoDisplay
+	uptime *Uptime
+}
+
+func NewInfoController(uptime *Uptime) (*InfoController, error) {
+	info, err := GetInfo()
+	if err != nil {
+		return nil, err
+	}
+	return &InfoController{info: *info, uptime: uptime}, nil
+}
+
+// getInfo godoc
+//
+//	@Summary		Get application info
+//	@Description	Get build info, start time and runtime stats of the application
+//	@Produce		json
+//	@Success		200	{object}	InfoDisplay
+//	@Router			/info [get]
+func (ic *InfoController) getInfo(c *gin.Context) {
+	info := ic.info
+	info.StartedAt = ic.uptime.StartedAt()
+	info.Stats = GetRuntimeStats()
+	// the uptime of the app rather than the process
+	info.Stats.Uptime = time.Since(info.StartedAt)
+	info.Stats.UptimeSeconds = info.Stats.Uptime.Seconds()
+	c.JSON(http.StatusOK, &info)
+}
+
+func (ic *InfoController) RegisterControllerRoutes(rg *gin.RouterGroup) {
+	rg.GET("/", ic.getInfo)
+}
+
+func (ic *InfoController) RoutePattern() string {
+	return "/info"
 }
@@ -14,20 +14,33 @@ type InfoParams struct {
 	Logger    *zap.SugaredLogger
 }
 
+// DisplayInfo logs the info of the application as one structured entry when it starts, and its runtime stats
 func DisplayInfo(p InfoParams) {
 	p.Lifecycle.Append(fx.Hook{
 		OnStart: func(context.Context) error {
-			p.Logger.Info("Starting application:")
 			info, err := GetInfo()
 			if err != nil {
 				return err
 			}
-			p.Logger.Info(info.Name)
-			p.Logger.Info(info.Platform)
-			p.Logger.Info(info.Runtime)
-			p.Logger.Info(info.HostName)
-			p.Logger.Info(info.BuildCommit)
-			p.Logger.Info(info.BuildDate)
+			// a single entry so that the log pipelines get the build of each start as fields
+			p.Logger.Infow("Starting application",
+				"name", info.Name,
+				"version", info.Version,
+				"commit", info.BuildCommit,
+				"build_date", info.BuildDate,
+				"platform", info.Platform,
+				"runtime", info.Runtime,
+				"host_name", info.HostName,
+			)
+			if info.Dirty {
+				p.Logger.Warn("Built from a modified working tree")
+			}
+			p.Logger.Infow("Runtime stats",
+				"goroutines", info.Stats.Goroutines,
+				"heap_alloc", info.Stats.HeapAlloc,
+				"num_gc", info.Stats.NumGC,
+				"uptime", info.Stats.Uptime,
+			)
 			return nil
 		},
 	})
@@ -44,7 +57,31 @@ func cleanup(p InfoParams) {
 }
 
 var Module = fx.Module("info",
-	fx.Provide(routerfx.AsControllerRoute(NewHealthController)),
+	fx.Provide(NewUptime),
+	fx.Provide(
+		fx.Annotate(
+			NewHealthController,
+			fx.ParamTags(``, `optional:"true"`),
+			fx.As(new(routerfx.ControllerRoute)),
+			fx.ResultTags(`group:"controllerRoutes"`),
+		),
+	),
+	fx.Provide(
+		fx.Annotate(
+			NewReadinessController,
+			fx.ParamTags(`group:"healthChecks"`, `optional:"true"`),
+			fx.As(new(routerfx.ControllerRoute)),
+			fx.ResultTags(`group:"controllerRoutes"`),
+		),
+	),
+	fx.Provide(
+		fx.Annotate(
+			NewDependencies,
+			fx.ParamTags(`group:"healthChecks"`, `optional:"true"`),
+			fx.As(new(routerfx.DependencyChecker)),
+		),
+	),
+	fx.Provide(routerfx.AsControllerRoute(NewInfoController)),
 	fx.Invoke(DisplayInfo),
 	fx.Invoke(cleanup),
 )
@@ -0,0 +1,112 @@
+package infofx_test
+
+import (
+	"runtime/debug"
+	"testing"
+
+	"go.uber.org/fx/fxtest"
+	"go.uber.org/zap/zapcore"
+
+	"github.com/prismedic/scalpel/infofx"
+	"github.com/prismedic/scalpel/loggerfx"
+)
+
+func TestDisplayInfo(t *testing.T) {
+	defer func(version, commit, date string) {
+		infofx.BuildVersion, infofx.BuildCommit, infofx.BuildDate = version, commit, date
+	}(infofx.BuildVersion, infofx.BuildCommit, infofx.BuildDate)
+	infofx.BuildVersion, infofx.BuildCommit, infofx.BuildDate = "v2.0.0", "def456", "2024-06-01"
+	logger, logs := loggerfx.NewObserved(zapcore.InfoLevel)
+	lifecycle := fxtest.NewLifecycle(t)
+	infofx.DisplayInfo(infofx.InfoParams{Lifecycle: lifecycle, Logger: logger})
+	lifecycle.RequireStart()
+	defer lifecycle.RequireStop()
+
+	entries := logs.FilterMessage("Starting application").All()
+	if len(entries) != 1 {
+		t.Fatalf("unexpected number of startup entries, got %d, expected 1", len(entries))
+	}
+	fields := entries[0].ContextMap()
+	for _, key := range []string{"name", "version", "commit", "build_date", "platform", "runtime", "host_name"} {
+		if _, ok := fields[key]; !ok {
+			t.Errorf("field %s not found in startup entry %v", key, fields)
+		}
+	}
+	if fields["name"] == "" || fields["platform"] == "" {
+		t.Errorf("unexpected empty fields in startup entry %v", fields)
+	}
+	if fields["version"] != "v2.0.0" || fields["commit"] != "def456" || fields["build_date"] != "2024-06-01" {
+		t.Errorf("build fields of the ldflags not found in startup entry %v", fields)
+	}
+}
+
+func TestSetBuildInfo(t *testing.T) {
+	vcsInfo := &debug.BuildInfo{
+		Main: debug.Module{Version: "v1.2.0"},
+		Settings: []debug.BuildSetting{
+			{Key: "vcs.revision", Value: "abc123"},
+			{Key: "vcs.time", Value: "2024-05-01T10:00:00Z"},
+			{Key: "vcs.modified", Value: "true"},
+		},
+	}
+	develInfo := &debug.BuildInfo{Main: debug.Module{Version: "(devel)"}}
+	for _, test := range []struct {
+		name      string
+		buildInfo *debug.BuildInfo
+		ldflags   [3]string
+		env       string
+		expected  infofx.InfoDisplay
+	}{
+		{
+			name:      "Test vcs info",
+			buildInfo: vcsInfo,
+			expected:  infofx.InfoDisplay{Version: "v1.2.0", BuildCommit: "abc123", BuildDate: "2024-05-01T10:00:00Z", Dirty: true},
+		},
+		{
+			name:      "Test ldflags override vcs info",
+			buildInfo: vcsInfo,
+			ldflags:   [3]string{"v2.0.0", "def456", "2024-06-01"},
+			env:       "env789",
+			expected:  infofx.InfoDisplay{Version: "v2.0.0", BuildCommit: "def456", BuildDate: "2024-06-01", Dirty: true},
+		},
+		{
+			name:      "Test partial ldflags",
+			buildInfo: vcsInfo,
+			ldflags:   [3]string{"", "def456", ""},
+			expected:  infofx.InfoDisplay{Version: "v1.2.0", BuildCommit: "def456", BuildDate: "2024-05-01T10:00:00Z", Dirty: true},
+		},
+		{
+			name:      "Test env commit without vcs info",
+			buildInfo: develInfo,
+			env:       "env789",
+			expected:  infofx.InfoDisplay{Version: "(devel)", BuildCommit: "env789"},
+		},
+		{
+			name:      "Test vcs info over env commit",
+			buildInfo: vcsInfo,
+			env:       "env789",
+			expected:  infofx.InfoDisplay{Version: "v1.2.0", BuildCommit: "abc123", BuildDate: "2024-05-01T10:00:00Z", Dirty: true},
+		},
+		{
+			name:      "Test ldflags without vcs info",
+			buildInfo: develInfo,
+			ldflags:   [3]string{"v2.0.0", "def456", "2024-06-01"},
+			expected:  infofx.InfoDisplay{Version: "v2.0.0", BuildCommit: "def456", BuildDate: "2024-06-01"},
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			defer func(version, commit, date string) {
+				infofx.BuildVersion, infofx.BuildCommit, infofx.BuildDate = version, commit, date
+			}(infofx.BuildVersion, infofx.BuildCommit, infofx.BuildDate)
+			infofx.BuildVersion, infofx.BuildCommit, infofx.BuildDate = test.ldflags[0], test.ldflags[1], test.ldflags[2]
+			t.Setenv("BUILD_COMMIT", test.env)
+
+			var display infofx.InfoDisplay
+			display.SetBuildInfo(test.buildInfo)
+			if display.Version != test.expected.Version || display.BuildCommit != test.expected.BuildCommit ||
+				display.BuildDate != test.expected.BuildDate || display.Dirty != test.expected.Dirty {
+				t.Errorf("unexpected build info, got %+v, expected %+v", display, test.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,93 @@
+package infofx
+
+import (
+	"fmt"
+	"sync"
+)
+
+// ThresholdCheck is implemented by checks tolerating transient failures, see WithThresholds
+type ThresholdCheck interface {
+	HealthCheck
+	// Thresholds returns the number of consecutive failures before the check is reported as failing
+	// and the number of consecutive successes before a failing check is reported as ok again
+	Thresholds() (failureThreshold int, recoveryThreshold int)
+}
+
+type thresholdCheck struct {
+	HealthCheck
+	failureThreshold  int
+	recoveryThreshold int
+}
+
+// WithThresholds wraps the check to be reported as failing only after failureThreshold consecutive failures,
+// and as ok again after recoveryThreshold consecutive successes. Thresholds below 1 are treated as 1,
+// which is the behavior of checks without thresholds.
+func WithThresholds(check HealthCheck, failureThreshold int, recoveryThreshold int) HealthCheck {
+	return &thresholdCheck{
+		HealthCheck:       check,
+		failureThreshold:  failureThreshold,
+		recoveryThreshold: recoveryThreshold,
+	}
+}
+
+func (c *thresholdCheck) Thresholds() (int, int) {
+	return c.failureThreshold, c.recoveryThreshold
+}
+
+// checkState holds the consecutive results of a check, the first result is reported as it is
+type checkState struct {
+	checked   bool
+	failing   bool
+	failures  int
+	successes int
+}
+
+// checkStates are the states of the checks of a controller, kept between the requests
+type checkStates struct {
+	mutex  sync.Mutex
+	states map[string]*checkState
+}
+
+func newCheckStates() *checkStates {
+	return &checkStates{states: make(map[string]*checkState)}
+}
+
+// report records the result of the check and returns the result to report, nil while a failure is tolerated
+func (s *checkStates) report(check HealthCheck, err error) error {
+	failureThreshold, recoveryThreshold := 1, 1
+	if thresholds, ok := check.(ThresholdCheck); ok {
+		failureThreshold, recoveryThreshold = thresholds.Thresholds()
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	state, ok := s.states[check.Name()]
+	if !ok {
+		state = &checkState{}
+		s.states[check.Name()] = state
+	}
+
+	if err != nil {
+		state.failures++
+		state.successes = 0
+		if !state.checked || state.failures >= failureThreshold {
+			state.failing = true
+		}
+	} else {
+		state.successes++
+		state.failures = 0
+		if !state.checked || state.successes >= recoveryThreshold {
+			state.failing = false
+		}
+	}
+	state.checked = true
+
+	switch {
+	case !state.failing:
+		return nil
+	case err != nil:
+		return err
+	default:
+		return fmt.Errorf("recovering, %d of %d consecutive checks passed", state.successes, recoveryThreshold)
+	}
+}
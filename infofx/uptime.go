@@ -0,0 +1,39 @@
+package infofx
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"go.uber.org/fx"
+)
+
+// Uptime is the start time of the application shared by the info and health controllers.
+// It is the process start time until the app is started.
+type Uptime struct {
+	mutex     sync.RWMutex
+	startedAt time.Time
+}
+
+func NewUptime(lifecycle fx.Lifecycle) *Uptime {
+	uptime := &Uptime{startedAt: startTime}
+	lifecycle.Append(fx.Hook{
+		OnStart: func(context.Context) error {
+			uptime.mutex.Lock()
+			defer uptime.mutex.Unlock()
+			uptime.startedAt = time.Now()
+			return nil
+		},
+	})
+	return uptime
+}
+
+func (u *Uptime) StartedAt() time.Time {
+	u.mutex.RLock()
+	defer u.mutex.RUnlock()
+	return u.startedAt
+}
+
+func (u *Uptime) Duration() time.Duration {
+	return time.Since(u.StartedAt())
+}
@@ -0,0 +1,25 @@
+package logger
+
+import (
+	"fmt"
+	"os"
+	"path"
+)
+
+// PrepareFile creates the folder of the log file and returns the path of the file.
+// The file is opened once, so a read-only folder fails at startup instead of dropping the first writes
+// of the rotating writer opening it lazily.
+func PrepareFile(dir string, name string) (string, error) {
+	if err := os.MkdirAll(dir, os.ModePerm); err != nil {
+		return "", fmt.Errorf("error in creating log file folder for writing: %w", err)
+	}
+	filename := path.Join(dir, name)
+	file, err := os.OpenFile(filename, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0600)
+	if err != nil {
+		return "", fmt.Errorf("log file is not writable: %w", err)
+	}
+	if err := file.Close(); err != nil {
+		return "", fmt.Errorf("error in closing log file: %w", err)
+	}
+	return filename, nil
+}
@@ -0,0 +1,94 @@
+package loggerfx
+
+import (
+	"io"
+	"os"
+	"strings"
+
+	"github.com/fatih/color"
+	"github.com/go-playground/validator/v10"
+	"github.com/mattn/go-isatty"
+	"go.uber.org/zap/zapcore"
+
+	"github.com/prismedic/scalpel/logger"
+)
+
+var colorAttributeMap = map[string]color.Attribute{
+	"black":     color.FgBlack,
+	"red":       color.FgRed,
+	"green":     color.FgGreen,
+	"yellow":    color.FgYellow,
+	"blue":      color.FgBlue,
+	"magenta":   color.FgMagenta,
+	"cyan":      color.FgCyan,
+	"white":     color.FgWhite,
+	"hiblack":   color.FgHiBlack,
+	"hired":     color.FgHiRed,
+	"higreen":   color.FgHiGreen,
+	"hiyellow":  color.FgHiYellow,
+	"hiblue":    color.FgHiBlue,
+	"himagenta": color.FgHiMagenta,
+	"hicyan":    color.FgHiCyan,
+	"hiwhite":   color.FgHiWhite,
+	"bold":      color.Bold,
+	"underline": color.Underline,
+}
+
+// parseColor parses a space separated list of color names such as "red bold"
+func parseColor(name string) (*color.Color, bool) {
+	fields := strings.Fields(strings.ToLower(name))
+	if len(fields) == 0 {
+		return nil, false
+	}
+	attributes := make([]color.Attribute, 0, len(fields))
+	for _, field := range fields {
+		attribute, ok := colorAttributeMap[field]
+		if !ok {
+			return nil, false
+		}
+		attributes = append(attributes, attribute)
+	}
+	return color.New(attributes...), true
+}
+
+func validateLogColor(fieldLevel validator.FieldLevel) bool {
+	_, ok := parseColor(fieldLevel.Field().String())
+	return ok
+}
+
+// newColorMap builds the level colors from config, levels not in config keep the default colors
+func newColorMap(colors map[LogLevel]string) map[zapcore.Level]*color.Color {
+	colorMap := map[zapcore.Level]*color.Color{
+		zapcore.DebugLevel:  logger.DebugColor,
+		zapcore.InfoLevel:   logger.InfoColor,
+		zapcore.WarnLevel:   logger.WarnColor,
+		zapcore.ErrorLevel:  logger.ErrorColor,
+		zapcore.DPanicLevel: logger.FatalColor,
+		zapcore.FatalLevel:  logger.FatalColor,
+		zapcore.PanicLevel:  logger.FatalColor,
+	}
+	for level, name := range colors {
+		zapLevel, ok := logLevelMap[level]
+		if !ok {
+			continue
+		}
+		if levelColor, ok := parseColor(name); ok {
+			colorMap[zapLevel] = levelColor
+		}
+	}
+	return colorMap
+}
+
+// colorSupported reports whether ANSI colors should be written to the writer.
+// Colors are disabled when the NO_COLOR env variable is set (https://no-color.org)
+// or when the writer is not a terminal, e.g. redirected to a file in CI.
+func colorSupported(writer io.Writer) bool {
+	if _, ok := os.LookupEnv("NO_COLOR"); ok {
+		return false
+	}
+	file, ok := writer.(*os.File)
+	if !ok {
+		return false
+	}
+	return isatty.IsTerminal(file.Fd()) || isatty.IsCygwinTerminal(file.Fd())
+}
@@ -0,0 +1,53 @@
+package loggerfx
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+func TestConsoleColor(t *testing.T) {
+	t.Run("Test non-TTY writer", func(t *testing.T) {
+		config := &LoggerConfig{}
+		config.Console.Format = ConsoleFormat
+		var buffer bytes.Buffer
+		core := zapcore.NewCore(newConsoleEncoder(config, &buffer), zapcore.AddSync(&buffer), zapcore.DebugLevel)
+		zap.New(core).Info("hello")
+		if strings.Contains(buffer.String(), "\x1b[") {
+			t.Errorf("unexpected escape sequence in output: %q", buffer.String())
+		}
+		if !strings.Contains(buffer.String(), "[INFO]") {
+			t.Errorf("level not found in output: %q", buffer.String())
+		}
+	})
+	t.Run("Test NO_COLOR env variable", func(t *testing.T) {
+		t.Setenv("NO_COLOR", "1")
+		if colorSupported(&bytes.Buffer{}) {
+			t.Errorf("color should be disabled when NO_COLOR is set")
+		}
+	})
+	t.Run("Test level style and separator", func(t *testing.T) {
+		for _, test := range []struct {
+			levelStyle string
+			expected   string
+		}{
+			{levelStyle: "", expected: " | [INFO] | hello"},
+			{levelStyle: PlainLevelStyle, expected: " | INFO | hello"},
+			{levelStyle: LowerLevelStyle, expected: " | info | hello"},
+		} {
+			config := &LoggerConfig{}
+			config.Console.Format = ConsoleFormat
+			config.Console.LevelStyle = test.levelStyle
+			config.Console.Separator = " | "
+			var buffer bytes.Buffer
+			core := zapcore.NewCore(newConsoleEncoder(config, &buffer), zapcore.AddSync(&buffer), zapcore.DebugLevel)
+			zap.New(core).Info("hello")
+			if !strings.Contains(buffer.String(), test.expected) {
+				t.Errorf("unexpected output with level style %q, got %q, expected %q", test.levelStyle, buffer.String(), test.expected)
+			}
+		}
+	})
+}
@@ -0,0 +1,52 @@
+package loggerfx
+
+import (
+	"context"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+
+	"github.com/prismedic/scalpel/routerfx"
+)
+
+type loggerKey struct{}
+
+// NewContext returns a copy of ctx carrying the logger
+func NewContext(ctx context.Context, logger *zap.SugaredLogger) context.Context {
+	return context.WithValue(ctx, loggerKey{}, logger)
+}
+
+// WithContext returns the logger stored in ctx with the request ID of ctx attached as the request_id field,
+// and the trace_id and span_id fields when ctx carries an active span.
+// The global zap logger is used when ctx carries no logger, which is a no-op unless replaced with zap.ReplaceGlobals.
+func WithContext(ctx context.Context) *zap.SugaredLogger {
+	logger, ok := ctx.Value(loggerKey{}).(*zap.SugaredLogger)
+	if !ok || logger == nil {
+		logger = zap.S()
+	}
+	if requestID, ok := routerfx.RequestIDFromContext(ctx); ok {
+		logger = logger.With("request_id", requestID)
+	}
+	if spanContext := trace.SpanContextFromContext(ctx); spanContext.IsValid() {
+		logger = logger.With("trace_id", spanContext.TraceID().String(), "span_id", spanContext.SpanID().String())
+	}
+	return logger
+}
+
+// ContextMiddleware is the name of the middleware storing the logger in the request context
+const ContextMiddleware = "logger_context"
+
+// NewContextMiddleware returns a middleware storing the logger in the request context,
+// so WithContext(c.Request.Context()) returns it in the handlers
+func NewContextMiddleware(logger *zap.SugaredLogger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Request = c.Request.WithContext(NewContext(c.Request.Context(), logger))
+		c.Next()
+	}
+}
+
+// newContextMiddleware runs after the request ID middleware, so the loggers of the middlewares after it have the request ID
+func newContextMiddleware(logger *zap.SugaredLogger) routerfx.Middleware {
+	return routerfx.Middleware{Name: ContextMiddleware, Handler: NewContextMiddleware(logger), After: routerfx.RequestIDMiddleware}
+}
@@ -0,0 +1,71 @@
+package loggerfx_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+
+	"github.com/prismedic/scalpel/loggerfx"
+	"github.com/prismedic/scalpel/routerfx"
+)
+
+func TestContext(t *testing.T) {
+	core, logs := observer.New(zapcore.InfoLevel)
+	logger := zap.New(core).Sugar()
+
+	t.Run("Test logger of the context", func(t *testing.T) {
+		ctx := routerfx.ContextWithRequestID(loggerfx.NewContext(context.Background(), logger), "request-1")
+		loggerfx.WithContext(ctx).Info("from context")
+		entries := logs.TakeAll()
+		if len(entries) != 1 || entries[0].ContextMap()["request_id"] != "request-1" {
+			t.Errorf("unexpected entries %+v", entries)
+		}
+	})
+	t.Run("Test trace fields", func(t *testing.T) {
+		spanContext := trace.NewSpanContext(trace.SpanContextConfig{
+			TraceID:    trace.TraceID{0x01, 0x02},
+			SpanID:     trace.SpanID{0x03},
+			TraceFlags: trace.FlagsSampled,
+		})
+		ctx := trace.ContextWithSpanContext(loggerfx.NewContext(context.Background(), logger), spanContext)
+		loggerfx.WithContext(ctx).Info("traced")
+		entries := logs.TakeAll()
+		if len(entries) != 1 {
+			t.Fatalf("unexpected number of entries, got %d, expected 1", len(entries))
+		}
+		fields := entries[0].ContextMap()
+		if fields["trace_id"] != spanContext.TraceID().String() || fields["span_id"] != spanContext.SpanID().String() {
+			t.Errorf("unexpected trace fields %v", fields)
+		}
+		if _, ok := fields["request_id"]; ok {
+			t.Errorf("unexpected request_id without a request ID in the context")
+		}
+	})
+	t.Run("Test global logger without a logger in the context", func(t *testing.T) {
+		if loggerfx.WithContext(context.Background()) != zap.S() {
+			t.Errorf("expected the global logger")
+		}
+	})
+	t.Run("Test middleware", func(t *testing.T) {
+		gin.SetMode(gin.TestMode)
+		router := gin.New()
+		router.Use(routerfx.NewRequestID(""), loggerfx.NewContextMiddleware(logger))
+		router.GET("/", func(c *gin.Context) {
+			loggerfx.WithContext(c.Request.Context()).Info("from handler")
+		})
+		request := httptest.NewRequest(http.MethodGet, "/", nil)
+		request.Header.Set(routerfx.DefaultRequestIDHeader, "request-2")
+		router.ServeHTTP(httptest.NewRecorder(), request)
+		entries := logs.TakeAll()
+		if len(entries) != 1 || entries[0].ContextMap()["request_id"] != "request-2" {
+			t.Errorf("unexpected entries %+v", entries)
+		}
+	})
+}
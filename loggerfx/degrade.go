@@ -0,0 +1,104 @@
+package loggerfx
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+const (
+	// maxFileWriteErrors is the number of consecutive failed writes after which a log file is dropped
+	maxFileWriteErrors = 3
+	// fileRetryInterval is how often a dropped log file is written again, so logging resumes once the disk is freed
+	fileRetryInterval = time.Minute
+)
+
+// fileFailureReporter logs the state changes of the log files with the logger using them.
+// The logger is only set once it is built, the files cannot fail before it is used.
+type fileFailureReporter struct {
+	logger atomic.Pointer[zap.Logger]
+}
+
+func (r *fileFailureReporter) report(filename string, dropped bool, err error) {
+	logger := r.logger.Load()
+	if logger == nil {
+		return
+	}
+	if dropped {
+		// written to the other outputs, the dropped file discards it
+		logger.Error("log file writes failed, the file is dropped until it is writable again",
+			zap.String("file", filename), zap.Int("failed_writes", maxFileWriteErrors), zap.Duration("retry_interval", fileRetryInterval), zap.Error(err))
+		return
+	}
+	logger.Warn("log file is writable again, the file is resumed", zap.String("file", filename))
+}
+
+// degradingWriter drops the writes to a log file after repeated errors, e.g. when its disk is full,
+// instead of failing every log line. A write is tried again every retry interval to resume the file.
+type degradingWriter struct {
+	zapcore.WriteSyncer
+	filename string
+	reporter *fileFailureReporter
+
+	mutex    sync.Mutex
+	failures int
+	dropped  bool
+	retryAt  time.Time
+}
+
+func newDegradingWriter(writer zapcore.WriteSyncer, filename string, reporter *fileFailureReporter) *degradingWriter {
+	return &degradingWriter{
+		WriteSyncer: writer,
+		filename:    filename,
+		reporter:    reporter,
+	}
+}
+
+func (w *degradingWriter) Write(data []byte) (int, error) {
+	w.mutex.Lock()
+	if w.dropped && time.Now().Before(w.retryAt) {
+		w.mutex.Unlock()
+		return len(data), nil
+	}
+	n, err := w.WriteSyncer.Write(data)
+	if err == nil {
+		resumed := w.dropped
+		w.failures = 0
+		w.dropped = false
+		w.mutex.Unlock()
+		// reported without the lock, the entry is also written to this file
+		if resumed {
+			w.reporter.report(w.filename, false, nil)
+		}
+		return n, nil
+	}
+
+	w.failures++
+	if w.dropped {
+		w.retryAt = time.Now().Add(fileRetryInterval)
+		w.mutex.Unlock()
+		return len(data), nil
+	}
+	if w.failures < maxFileWriteErrors {
+		w.mutex.Unlock()
+		return n, err
+	}
+	w.dropped = true
+	w.retryAt = time.Now().Add(fileRetryInterval)
+	w.mutex.Unlock()
+	w.reporter.report(w.filename, true, err)
+	return len(data), nil
+}
+
+func (w *degradingWriter) Sync() error {
+	w.mutex.Lock()
+	dropped := w.dropped
+	w.mutex.Unlock()
+	if dropped {
+		return nil
+	}
+	return w.WriteSyncer.Sync()
+}
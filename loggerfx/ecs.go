@@ -0,0 +1,59 @@
+package loggerfx
+
+import (
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/buffer"
+	"go.uber.org/zap/zapcore"
+)
+
+// ECSPreset formats the file entries with the field names of the Elastic Common Schema
+const ECSPreset = "ecs"
+
+// ecsVersion is the version of the schema written in the ecs.version field
+const ecsVersion = "8.11.0"
+
+// newECSEncoderConfig returns the JSON encoder config of the ECS fields, the levels are kept lowercase as in zap.
+// The caller is split into the log.origin.file.name and log.origin.file.line fields by the encoder.
+func newECSEncoderConfig() zapcore.EncoderConfig {
+	encoderConfig := zap.NewProductionEncoderConfig()
+	encoderConfig.TimeKey = "@timestamp"
+	encoderConfig.LevelKey = "log.level"
+	encoderConfig.MessageKey = "message"
+	encoderConfig.NameKey = "log.logger"
+	encoderConfig.CallerKey = "log.origin.file.name"
+	encoderConfig.FunctionKey = "log.origin.function"
+	encoderConfig.StacktraceKey = "error.stack_trace"
+	encoderConfig.EncodeLevel = zapcore.LowercaseLevelEncoder
+	// ISO8601 in UTC with milliseconds, the date format of Elasticsearch
+	encoderConfig.EncodeTime = func(t time.Time, encoder zapcore.PrimitiveArrayEncoder) {
+		encoder.AppendString(t.UTC().Format("2006-01-02T15:04:05.000Z07:00"))
+	}
+	encoderConfig.EncodeCaller = func(caller zapcore.EntryCaller, encoder zapcore.PrimitiveArrayEncoder) {
+		encoder.AppendString(caller.File)
+	}
+	return encoderConfig
+}
+
+// ecsEncoder adds the ecs.version and log.origin.file.line fields to the entries of the JSON encoder
+type ecsEncoder struct {
+	zapcore.Encoder
+}
+
+func newECSEncoder(encoderConfig zapcore.EncoderConfig) zapcore.Encoder {
+	encoder := zapcore.NewJSONEncoder(encoderConfig)
+	encoder.AddString("ecs.version", ecsVersion)
+	return &ecsEncoder{Encoder: encoder}
+}
+
+func (e *ecsEncoder) Clone() zapcore.Encoder {
+	return &ecsEncoder{Encoder: e.Encoder.Clone()}
+}
+
+func (e *ecsEncoder) EncodeEntry(entry zapcore.Entry, fields []zapcore.Field) (*buffer.Buffer, error) {
+	if entry.Caller.Defined {
+		fields = append(fields, zap.Int("log.origin.file.line", entry.Caller.Line))
+	}
+	return e.Encoder.EncodeEntry(entry, fields)
+}
@@ -0,0 +1,205 @@
+package loggerfx
+
+import (
+	"errors"
+	"fmt"
+	"path"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"gopkg.in/natefinch/lumberjack.v2"
+
+	"github.com/prismedic/scalpel/logger"
+)
+
+// RotationConfig controls how lumberjack rotates the log file.
+// Zero values fall back to the lumberjack defaults (100MB, keep all backups forever).
+type RotationConfig struct {
+	MaxSizeMB  int  `mapstructure:"max_size_mb" yaml:"max_size_mb" validate:"min=0"`
+	MaxBackups int  `mapstructure:"max_backups" yaml:"max_backups" validate:"min=0"`
+	MaxAgeDays int  `mapstructure:"max_age_days" yaml:"max_age_days" validate:"min=0"`
+	Compress   bool `mapstructure:"compress" yaml:"compress"`
+	// CompressFormat of the backups when Compress is set, gzip by lumberjack when empty or zstd, see zstdRotationWriter
+	CompressFormat string `mapstructure:"compress_format" yaml:"compress_format" validate:"omitempty,oneof=gzip zstd"`
+}
+
+// ErrorFileConfig configures the file that only receives warnings and above.
+// Path defaults to the path of the main log file.
+type ErrorFileConfig struct {
+	Path     string         `mapstructure:"path" yaml:"path"`
+	Name     string         `mapstructure:"name" yaml:"name" validate:"omitempty,excludesall=/\\"`
+	Rotation RotationConfig `mapstructure:"rotation" yaml:"rotation"`
+}
+
+// FileConfig configures an additional log file with its own level, see LoggerConfig.Files
+type FileConfig struct {
+	Level LogLevel `mapstructure:"level" yaml:"level" validate:"required,loglevel"`
+	Path  string   `mapstructure:"path" yaml:"path" validate:"required"`
+	Name  string   `mapstructure:"name" yaml:"name" validate:"required,excludesall=/\\"`
+	// MaxLevel is the highest level written to the file, all the levels above Level are written when it is empty
+	MaxLevel LogLevel `mapstructure:"max_level" yaml:"max_level" validate:"omitempty,loglevel"`
+	// Format defaults to json
+	Format string `mapstructure:"format" yaml:"format" validate:"omitempty,oneof=json logfmt"`
+	// TimeFormat is a named time format such as rfc3339 or a Go time layout, it defaults to the one of the format
+	TimeFormat string         `mapstructure:"time_format" yaml:"time_format"`
+	Rotation   RotationConfig `mapstructure:"rotation" yaml:"rotation"`
+}
+
+var ErrDuplicateLogFile = errors.New("log file is configured more than once")
+
+const defaultErrorFileName = "errors.log"
+
+// newFileWriter returns the rotating writer of the file, which is added to the files when they are not nil
+func newFileWriter(dir string, name string, rotation RotationConfig, reporter *fileFailureReporter, files *LogFiles) (zapcore.WriteSyncer, error) {
+	filename, err := logger.PrepareFile(dir, name)
+	if err != nil {
+		return nil, err
+	}
+
+	// create a new writer for log rotation
+	if rotation.Compress && rotation.CompressFormat == ZstdCompression {
+		writer := newZstdRotationWriter(filename, rotation)
+		files.add(filename, writer)
+		return newDegradingWriter(zapcore.AddSync(writer), filename, reporter), nil
+	}
+	writer := &lumberjack.Logger{
+		Filename:   filename,
+		MaxSize:    rotation.MaxSizeMB,
+		MaxBackups: rotation.MaxBackups,
+		MaxAge:     rotation.MaxAgeDays,
+		Compress:   rotation.Compress,
+	}
+	files.add(filename, writer)
+	return newDegradingWriter(zapcore.AddSync(writer), filename, reporter), nil
+}
+
+// FileKeysConfig renames the keys of the file entries, e.g. @timestamp for a central log store
+type FileKeysConfig struct {
+	Time    string `mapstructure:"time" yaml:"time" validate:"required"`
+	Message string `mapstructure:"message" yaml:"message" validate:"required"`
+	Level   string `mapstructure:"level" yaml:"level" validate:"required"`
+	Caller  string `mapstructure:"caller" yaml:"caller" validate:"required"`
+	Name    string `mapstructure:"name" yaml:"name" validate:"required"`
+}
+
+// newFileEncoder returns the encoder of the format, JSON has epoch timestamps and logfmt ISO8601 ones unless timeFormat is set.
+// The keys of zap.NewProductionEncoderConfig are used when keys is nil, a preset replaces the keys and the format.
+// showFunction adds the function of the caller, the ecs preset always has it.
+func newFileEncoder(format string, timeFormat string, keys *FileKeysConfig, preset string, showFunction bool) zapcore.Encoder {
+	if preset == ECSPreset {
+		encoderConfig := newECSEncoderConfig()
+		if timeEncoder := newTimeEncoder(timeFormat); timeEncoder != nil {
+			encoderConfig.EncodeTime = timeEncoder
+		}
+		return newECSEncoder(encoderConfig)
+	}
+	fileEncoderConfig := zap.NewProductionEncoderConfig()
+	if keys != nil {
+		fileEncoderConfig.TimeKey = keys.Time
+		fileEncoderConfig.MessageKey = keys.Message
+		fileEncoderConfig.LevelKey = keys.Level
+		fileEncoderConfig.CallerKey = keys.Caller
+		fileEncoderConfig.NameKey = keys.Name
+	}
+	if showFunction {
+		fileEncoderConfig.FunctionKey = functionKey
+	}
+	if format == LogfmtFormat {
+		// epoch timestamps are hard to read without a JSON parser
+		fileEncoderConfig.EncodeTime = zapcore.ISO8601TimeEncoder
+	}
+	if timeEncoder := newTimeEncoder(timeFormat); timeEncoder != nil {
+		fileEncoderConfig.EncodeTime = timeEncoder
+	}
+	if format == LogfmtFormat {
+		return newLogfmtEncoder(fileEncoderConfig)
+	}
+	return zapcore.NewJSONEncoder(fileEncoderConfig)
+}
+
+// fileSink is a log file with the level enabling its entries, and the keys, the preset and the caller function of the main file
+type fileSink struct {
+	FileConfig
+	enabler      zapcore.LevelEnabler
+	keys         *FileKeysConfig
+	preset       string
+	showFunction bool
+}
+
+// fileSinks returns the main log file, when enabled, followed by the additional files.
+// The main file keeps the level that can be changed at runtime, the additional files have a fixed level.
+func fileSinks(config *LoggerConfig, levels *LogLevels) ([]fileSink, error) {
+	var sinks []fileSink
+	if config.File.Enabled {
+		sinks = append(sinks, fileSink{
+			FileConfig: FileConfig{
+				Level:      config.File.Level,
+				MaxLevel:   config.File.MaxLevel,
+				Path:       config.File.Path,
+				Name:       config.File.Name,
+				Format:     config.File.Format,
+				TimeFormat: config.File.TimeFormat,
+				Rotation:   config.File.Rotation,
+			},
+			enabler:      levels.File,
+			keys:         config.File.Keys,
+			preset:       config.File.Preset,
+			showFunction: config.File.ShowFunction,
+		})
+	}
+	for _, file := range config.Files {
+		sinks = append(sinks, fileSink{FileConfig: file, enabler: logLevelMap[file.Level], keys: config.File.Keys, preset: config.File.Preset, showFunction: config.File.ShowFunction})
+	}
+
+	// two lumberjack loggers rotating the same file would overwrite each other
+	filenames := make([]string, 0, len(sinks)+1)
+	for _, sink := range sinks {
+		filenames = append(filenames, path.Join(sink.Path, sink.Name))
+	}
+	if config.ErrorFile != nil {
+		filenames = append(filenames, path.Join(errorFilePath(config)))
+	}
+	seen := make(map[string]bool, len(filenames))
+	for _, filename := range filenames {
+		filename = path.Clean(filename)
+		if seen[filename] {
+			return nil, fmt.Errorf("%w: %s", ErrDuplicateLogFile, filename)
+		}
+		seen[filename] = true
+	}
+	return sinks, nil
+}
+
+// newFileCore returns the core of the file, the enabler is the one of the sink with the max level and the named levels
+func newFileCore(sink fileSink, enabler zapcore.LevelEnabler, reporter *fileFailureReporter, files *LogFiles) (zapcore.Core, error) {
+	fileWriter, err := newFileWriter(sink.Path, sink.Name, sink.Rotation, reporter, files)
+	if err != nil {
+		return nil, err
+	}
+	return zapcore.NewCore(newFileEncoder(sink.Format, sink.TimeFormat, sink.keys, sink.preset, sink.showFunction), fileWriter, enabler), nil
+}
+
+// errorFilePath returns the directory and the name of the error file, the directory of the main file by default
+func errorFilePath(config *LoggerConfig) (dir string, name string) {
+	dir = config.ErrorFile.Path
+	if dir == "" {
+		dir = config.File.Path
+	}
+	name = config.ErrorFile.Name
+	if name == "" {
+		name = defaultErrorFileName
+	}
+	return dir, name
+}
+
+func newErrorFileCore(config *LoggerConfig, reporter *fileFailureReporter, files *LogFiles) (zapcore.Core, error) {
+	dir, name := errorFilePath(config)
+	fileWriter, err := newFileWriter(dir, name, config.ErrorFile.Rotation, reporter, files)
+	if err != nil {
+		return nil, err
+	}
+	level := zap.LevelEnablerFunc(func(l zapcore.Level) bool {
+		return l >= zapcore.WarnLevel
+	})
+	return zapcore.NewCore(newFileEncoder(config.File.Format, config.File.TimeFormat, config.File.Keys, config.File.Preset, config.File.ShowFunction), fileWriter, level), nil
+}
@@ -0,0 +1,147 @@
+package loggerfx
+
+import (
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"go.uber.org/fx"
+	"go.uber.org/fx/fxevent"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+
+	"github.com/prismedic/scalpel/metricsfx"
+)
+
+// FxEventsConfig configures the logging of the events of fx, e.g. the provided constructors and the executed hooks
+type FxEventsConfig struct {
+	// Disabled drops the events, errors of the app are still returned by fx
+	Disabled bool `mapstructure:"disabled" yaml:"disabled"`
+	// Level is the level of the routine events, which fx logs at info, errors are kept at the error level
+	Level LogLevel `mapstructure:"level" yaml:"level" validate:"omitempty,loglevel"`
+	// Timings logs the duration of each invoke and lifecycle hook at the debug level, even when the events are disabled,
+	// and records them in the fx_duration_seconds histogram when the metrics are part of the app.
+	// Invokes include the constructors of their parameters, as fx does not time the constructors.
+	Timings bool `mapstructure:"timings" yaml:"timings"`
+}
+
+// NewFxEventLogger returns the logger of the fx events, it is set with fx.WithLogger in Module.
+// The registry is optional, it is only used by the timings.
+func NewFxEventLogger(logger *zap.SugaredLogger, config *LoggerConfig, registry *prometheus.Registry) fxevent.Logger {
+	eventLogger := newEventLogger(logger, config)
+	if !config.FxEvents.Timings {
+		return eventLogger
+	}
+	return newTimingLogger(eventLogger, logger, registry)
+}
+
+// fxEventLoggerParams are the parameters of the logger of fx.WithLogger, which does not accept annotated constructors
+type fxEventLoggerParams struct {
+	fx.In
+	Logger   *zap.SugaredLogger
+	Config   *LoggerConfig
+	Registry *prometheus.Registry `optional:"true"`
+}
+
+func newFxEventLogger(p fxEventLoggerParams) fxevent.Logger {
+	return NewFxEventLogger(p.Logger, p.Config, p.Registry)
+}
+
+func newEventLogger(logger *zap.SugaredLogger, config *LoggerConfig) fxevent.Logger {
+	if config.FxEvents.Disabled {
+		return fxevent.NopLogger
+	}
+	eventLogger := logger.Desugar()
+	if level, ok := logLevelMap[config.FxEvents.Level]; ok && level != zapcore.InfoLevel {
+		eventLogger = eventLogger.WithOptions(zap.WrapCore(func(core zapcore.Core) zapcore.Core {
+			return &relevelCore{Core: core, level: level}
+		}))
+	}
+	return &fxevent.ZapLogger{Logger: eventLogger}
+}
+
+// timingLogger logs the durations of the invokes and the lifecycle hooks after passing the events to the event logger
+type timingLogger struct {
+	fxevent.Logger
+	logger    *zap.SugaredLogger
+	durations *prometheus.HistogramVec
+	mutex     sync.Mutex
+	invoking  map[string]time.Time
+}
+
+func newTimingLogger(eventLogger fxevent.Logger, logger *zap.SugaredLogger, registry *prometheus.Registry) *timingLogger {
+	timings := &timingLogger{Logger: eventLogger, logger: logger, invoking: make(map[string]time.Time)}
+	if registry == nil {
+		return timings
+	}
+	durations := prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "fx_duration_seconds",
+		Help:    "Duration of the fx invokes and lifecycle hooks in seconds.",
+		Buckets: prometheus.ExponentialBuckets(0.001, 4, 8),
+	}, []string{"event", "function"})
+	if err := metricsfx.Register(registry, durations); err != nil {
+		logger.Warnw("Fail to register fx durations, they are only logged", "err", err)
+		return timings
+	}
+	timings.durations = durations
+	return timings
+}
+
+func (l *timingLogger) LogEvent(event fxevent.Event) {
+	l.Logger.LogEvent(event)
+	switch e := event.(type) {
+	case *fxevent.Invoking:
+		l.mutex.Lock()
+		l.invoking[e.FunctionName] = time.Now()
+		l.mutex.Unlock()
+	case *fxevent.Invoked:
+		l.mutex.Lock()
+		start, ok := l.invoking[e.FunctionName]
+		delete(l.invoking, e.FunctionName)
+		l.mutex.Unlock()
+		if ok {
+			l.record("invoke", e.FunctionName, "", time.Since(start))
+		}
+	case *fxevent.OnStartExecuted:
+		l.record("on_start", e.FunctionName, e.CallerName, e.Runtime)
+	case *fxevent.OnStopExecuted:
+		l.record("on_stop", e.FunctionName, e.CallerName, e.Runtime)
+	}
+}
+
+func (l *timingLogger) record(event string, function string, caller string, duration time.Duration) {
+	fields := []interface{}{"event", event, "function", function, "duration", duration}
+	if caller != "" {
+		fields = append(fields, "caller", caller)
+	}
+	l.logger.Debugw("fx timing", fields...)
+	if l.durations != nil {
+		l.durations.WithLabelValues(event, function).Observe(duration.Seconds())
+	}
+}
+
+// relevelCore writes the info entries at another level
+type relevelCore struct {
+	zapcore.Core
+	level zapcore.Level
+}
+
+func (c *relevelCore) relevel(level zapcore.Level) zapcore.Level {
+	if level == zapcore.InfoLevel {
+		return c.level
+	}
+	return level
+}
+
+func (c *relevelCore) Enabled(level zapcore.Level) bool {
+	return c.Core.Enabled(c.relevel(level))
+}
+
+func (c *relevelCore) With(fields []zapcore.Field) zapcore.Core {
+	return &relevelCore{Core: c.Core.With(fields), level: c.level}
+}
+
+func (c *relevelCore) Check(entry zapcore.Entry, checked *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	entry.Level = c.relevel(entry.Level)
+	return c.Core.Check(entry, checked)
+}
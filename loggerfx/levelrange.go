@@ -0,0 +1,22 @@
+package loggerfx
+
+import "go.uber.org/zap/zapcore"
+
+// levelRange enables the levels of the minimum enabler up to the maximum level, e.g. debug to the file
+// without the errors that are only written to the console
+type levelRange struct {
+	min zapcore.LevelEnabler
+	max zapcore.Level
+}
+
+// withMaxLevel bounds the enabler by the max level, an empty max level keeps every level above the enabler
+func withMaxLevel(enabler zapcore.LevelEnabler, maxLevel LogLevel) zapcore.LevelEnabler {
+	if maxLevel == "" {
+		return enabler
+	}
+	return &levelRange{min: enabler, max: logLevelMap[maxLevel]}
+}
+
+func (r *levelRange) Enabled(level zapcore.Level) bool {
+	return level <= r.max && r.min.Enabled(level)
+}
@@ -0,0 +1,259 @@
+package loggerfx
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"go.uber.org/zap/buffer"
+	"go.uber.org/zap/zapcore"
+)
+
+var logfmtBufferPool = buffer.NewPool()
+
+// logfmtEncoder encodes log entries as space separated key=value pairs.
+// Keys and values are quoted when they are empty or contain spaces, quotes, "=" or non-printable characters,
+// arrays, objects and reflected values are written as quoted JSON.
+type logfmtEncoder struct {
+	config     *zapcore.EncoderConfig
+	buf        *buffer.Buffer
+	namespaces []string
+}
+
+func newLogfmtEncoder(config zapcore.EncoderConfig) zapcore.Encoder {
+	return &logfmtEncoder{
+		config: &config,
+		buf:    logfmtBufferPool.Get(),
+	}
+}
+
+func (e *logfmtEncoder) addKey(key string) {
+	if e.buf.Len() > 0 {
+		e.buf.AppendByte(' ')
+	}
+	if len(e.namespaces) > 0 {
+		key = strings.Join(e.namespaces, ".") + "." + key
+	}
+	e.appendValue(key)
+	e.buf.AppendByte('=')
+}
+
+func (e *logfmtEncoder) appendValue(value string) {
+	if needsQuote(value) {
+		e.buf.AppendString(strconv.Quote(value))
+		return
+	}
+	e.buf.AppendString(value)
+}
+
+func needsQuote(value string) bool {
+	if value == "" {
+		return true
+	}
+	for _, r := range value {
+		if r <= ' ' || r == '=' || r == '"' || r == unicode.ReplacementChar || !unicode.IsPrint(r) {
+			return true
+		}
+	}
+	return false
+}
+
+// appendJSON writes the value as quoted JSON, marshal errors are written in place of the value
+func (e *logfmtEncoder) appendJSON(value interface{}) {
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		e.appendValue(fmt.Sprintf("!ERROR: %v", err))
+		return
+	}
+	e.appendValue(string(encoded))
+}
+
+// encodePrimitive formats a value written by one of the zapcore encoder functions, e.g. EncodeTime
+func encodePrimitive(encode func(zapcore.PrimitiveArrayEncoder)) string {
+	objectEncoder := zapcore.NewMapObjectEncoder()
+	_ = objectEncoder.AddArray("value", zapcore.ArrayMarshalerFunc(func(arrayEncoder zapcore.ArrayEncoder) error {
+		encode(arrayEncoder)
+		return nil
+	}))
+	values, _ := objectEncoder.Fields["value"].([]interface{})
+	if len(values) == 0 {
+		return ""
+	}
+	switch value := values[0].(type) {
+	case string:
+		return value
+	case float64:
+		return strconv.FormatFloat(value, 'f', -1, 64)
+	default:
+		return fmt.Sprint(value)
+	}
+}
+
+func (e *logfmtEncoder) AddArray(key string, marshaler zapcore.ArrayMarshaler) error {
+	objectEncoder := zapcore.NewMapObjectEncoder()
+	err := objectEncoder.AddArray(key, marshaler)
+	e.addKey(key)
+	e.appendJSON(objectEncoder.Fields[key])
+	return err
+}
+
+func (e *logfmtEncoder) AddObject(key string, marshaler zapcore.ObjectMarshaler) error {
+	objectEncoder := zapcore.NewMapObjectEncoder()
+	err := objectEncoder.AddObject(key, marshaler)
+	e.addKey(key)
+	e.appendJSON(objectEncoder.Fields[key])
+	return err
+}
+
+func (e *logfmtEncoder) AddBinary(key string, value []byte) {
+	e.AddString(key, base64.StdEncoding.EncodeToString(value))
+}
+
+func (e *logfmtEncoder) AddByteString(key string, value []byte) {
+	e.AddString(key, string(value))
+}
+
+func (e *logfmtEncoder) AddBool(key string, value bool) {
+	e.AddString(key, strconv.FormatBool(value))
+}
+
+func (e *logfmtEncoder) AddComplex128(key string, value complex128) {
+	e.AddString(key, strconv.FormatComplex(value, 'f', -1, 128))
+}
+
+func (e *logfmtEncoder) AddComplex64(key string, value complex64) {
+	e.AddString(key, strconv.FormatComplex(complex128(value), 'f', -1, 64))
+}
+
+func (e *logfmtEncoder) AddDuration(key string, value time.Duration) {
+	if e.config.EncodeDuration == nil {
+		e.AddString(key, value.String())
+		return
+	}
+	e.AddString(key, encodePrimitive(func(encoder zapcore.PrimitiveArrayEncoder) {
+		e.config.EncodeDuration(value, encoder)
+	}))
+}
+
+func (e *logfmtEncoder) AddFloat64(key string, value float64) {
+	e.AddString(key, strconv.FormatFloat(value, 'f', -1, 64))
+}
+
+func (e *logfmtEncoder) AddFloat32(key string, value float32) {
+	e.AddString(key, strconv.FormatFloat(float64(value), 'f', -1, 32))
+}
+
+func (e *logfmtEncoder) AddInt(key string, value int)     { e.AddInt64(key, int64(value)) }
+func (e *logfmtEncoder) AddInt32(key string, value int32) { e.AddInt64(key, int64(value)) }
+func (e *logfmtEncoder) AddInt16(key string, value int16) { e.AddInt64(key, int64(value)) }
+func (e *logfmtEncoder) AddInt8(key string, value int8)   { e.AddInt64(key, int64(value)) }
+
+func (e *logfmtEncoder) AddInt64(key string, value int64) {
+	e.AddString(key, strconv.FormatInt(value, 10))
+}
+
+func (e *logfmtEncoder) AddString(key, value string) {
+	e.addKey(key)
+	e.appendValue(value)
+}
+
+func (e *logfmtEncoder) AddTime(key string, value time.Time) {
+	if e.config.EncodeTime == nil {
+		e.AddString(key, value.Format(time.RFC3339Nano))
+		return
+	}
+	e.AddString(key, encodePrimitive(func(encoder zapcore.PrimitiveArrayEncoder) {
+		e.config.EncodeTime(value, encoder)
+	}))
+}
+
+func (e *logfmtEncoder) AddUint(key string, value uint)       { e.AddUint64(key, uint64(value)) }
+func (e *logfmtEncoder) AddUint32(key string, value uint32)   { e.AddUint64(key, uint64(value)) }
+func (e *logfmtEncoder) AddUint16(key string, value uint16)   { e.AddUint64(key, uint64(value)) }
+func (e *logfmtEncoder) AddUint8(key string, value uint8)     { e.AddUint64(key, uint64(value)) }
+func (e *logfmtEncoder) AddUintptr(key string, value uintptr) { e.AddUint64(key, uint64(value)) }
+
+func (e *logfmtEncoder) AddUint64(key string, value uint64) {
+	e.AddString(key, strconv.FormatUint(value, 10))
+}
+
+func (e *logfmtEncoder) AddReflected(key string, value interface{}) error {
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	e.addKey(key)
+	e.appendValue(string(encoded))
+	return nil
+}
+
+// OpenNamespace prefixes the keys of the following fields with the namespace, e.g. "namespace.key"
+func (e *logfmtEncoder) OpenNamespace(key string) {
+	e.namespaces = append(e.namespaces, key)
+}
+
+func (e *logfmtEncoder) Clone() zapcore.Encoder {
+	clone := &logfmtEncoder{
+		config:     e.config,
+		buf:        logfmtBufferPool.Get(),
+		namespaces: append([]string{}, e.namespaces...),
+	}
+	clone.buf.Write(e.buf.Bytes())
+	return clone
+}
+
+func (e *logfmtEncoder) EncodeEntry(entry zapcore.Entry, fields []zapcore.Field) (*buffer.Buffer, error) {
+	final := &logfmtEncoder{
+		config: e.config,
+		buf:    logfmtBufferPool.Get(),
+	}
+
+	if e.config.TimeKey != "" {
+		final.AddTime(e.config.TimeKey, entry.Time)
+	}
+	if e.config.LevelKey != "" && e.config.EncodeLevel != nil {
+		final.AddString(e.config.LevelKey, encodePrimitive(func(encoder zapcore.PrimitiveArrayEncoder) {
+			e.config.EncodeLevel(entry.Level, encoder)
+		}))
+	}
+	if e.config.NameKey != "" && entry.LoggerName != "" {
+		final.AddString(e.config.NameKey, entry.LoggerName)
+	}
+	if e.config.CallerKey != "" && entry.Caller.Defined && e.config.EncodeCaller != nil {
+		final.AddString(e.config.CallerKey, encodePrimitive(func(encoder zapcore.PrimitiveArrayEncoder) {
+			e.config.EncodeCaller(entry.Caller, encoder)
+		}))
+	}
+	if e.config.FunctionKey != "" && entry.Caller.Defined {
+		final.AddString(e.config.FunctionKey, entry.Caller.Function)
+	}
+	if e.config.MessageKey != "" {
+		final.AddString(e.config.MessageKey, entry.Message)
+	}
+
+	// fields added with With are already encoded in the buffer of this encoder
+	if e.buf.Len() > 0 {
+		final.buf.AppendByte(' ')
+		final.buf.Write(e.buf.Bytes())
+	}
+	final.namespaces = append([]string{}, e.namespaces...)
+	for _, field := range fields {
+		field.AddTo(final)
+	}
+	final.namespaces = nil
+
+	if e.config.StacktraceKey != "" && entry.Stack != "" {
+		final.AddString(e.config.StacktraceKey, entry.Stack)
+	}
+
+	lineEnding := e.config.LineEnding
+	if lineEnding == "" {
+		lineEnding = zapcore.DefaultLineEnding
+	}
+	final.buf.AppendString(lineEnding)
+	return final.buf, nil
+}
@@ -1,36 +1,87 @@
 package loggerfx
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"io"
 	"os"
 	"path"
+	"sort"
+	"syscall"
 
-	"github.com/fatih/color"
 	"github.com/go-playground/validator/v10"
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/spf13/viper"
 	"go.uber.org/fx"
-	"go.uber.org/fx/fxevent"
+	"go.uber.org/multierr"
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
-	"gopkg.in/natefinch/lumberjack.v2"
 
 	"github.com/prismedic/scalpel/config"
-	"github.com/prismedic/scalpel/logger"
+	"github.com/prismedic/scalpel/metricsfx"
+	"github.com/prismedic/scalpel/routerfx"
 )
 
 var Module = fx.Options(
-	fx.Provide(New),
-	fx.WithLogger(func(logger *zap.SugaredLogger) fxevent.Logger {
-		return &fxevent.ZapLogger{Logger: logger.Desugar()}
-	}),
-	fx.Decorate(RegisterLogLevelValidation),
+	fx.Provide(NewLogLevels),
+	fx.Provide(NewRecentLogs),
+	fx.Provide(NewLogFiles),
+	fx.Provide(newOTLPLogs),
+	fx.Provide(fx.Annotate(newLoggerWithSync, fx.ParamTags(``, ``, ``, ``, `group:"logCores"`, ``, ``, ``, `optional:"true"`, `optional:"true"`))),
+	fx.Provide(
+		fx.Annotate(
+			newLogLevelController,
+			fx.ParamTags(``, ``, `optional:"true"`),
+			fx.As(new(routerfx.ControllerRoute)),
+			fx.ResultTags(`group:"controllerRoutes"`),
+		),
+	),
+	fx.Provide(routerfx.AsOrderedMiddleware(newContextMiddleware)),
+	fx.Provide(
+		fx.Annotate(
+			newRecentLogsRoutes,
+			fx.ParamTags(``, `optional:"true"`),
+			fx.ResultTags(`group:"controllerRoutes,flatten"`),
+		),
+	),
+	fx.Provide(
+		fx.Annotate(
+			newLogRotationRoutes,
+			fx.ParamTags(``, `optional:"true"`),
+			fx.ResultTags(`group:"controllerRoutes,flatten"`),
+		),
+	),
+	fx.Provide(
+		fx.Annotate(
+			newWritableChecks,
+			fx.ResultTags(`group:"healthChecks,flatten"`),
+		),
+	),
+	fx.WithLogger(newFxEventLogger),
+	Validations,
+	fx.Invoke(WatchLogLevels),
 )
 
-func RegisterLogLevelValidation(validate *validator.Validate) (*validator.Validate, error) {
-	if err := validate.RegisterValidation("loglevel", validateLogLevel); err != nil {
-		return nil, err
+// Validations provides the loglevel and logcolor validations of the LoggerConfig to config.ValidationModule,
+// e.g. for the other configs using them. The logger registers them on the validator too, so Module works without it.
+var Validations = fx.Provide(
+	fx.Annotate(
+		logValidations,
+		fx.ResultTags(`group:"validations,flatten"`),
+	),
+)
+
+func logValidations() []config.Validation {
+	return []config.Validation{
+		{Tag: "loglevel", Func: validateLogLevel},
+		{Tag: "logcolor", Func: validateLogColor},
 	}
-	return validate, nil
+}
+
+// RegisterLogLevelValidation registers the validations of the LoggerConfig on a validator outside of an fx app
+func RegisterLogLevelValidation(validate *validator.Validate) (*validator.Validate, error) {
+	return config.RegisterValidations(validate, logValidations())
 }
 
 type LogLevel string
@@ -61,70 +112,446 @@ func validateLogLevel(fieldLevel validator.FieldLevel) bool {
 	return ok
 }
 
+// output formats of the log encoders
+const (
+	ConsoleFormat = "console"
+	JSONFormat    = "json"
+	LogfmtFormat  = "logfmt"
+)
+
+// functionKey is the key of the caller function when ShowFunction is set
+const functionKey = "func"
+
+// outputs of the console log
+const (
+	StderrOutput = "stderr"
+	StdoutOutput = "stdout"
+)
+
+// styles of the level in the console format, e.g. [INFO], INFO or info
+const (
+	BracketLevelStyle = "bracket"
+	PlainLevelStyle   = "plain"
+	LowerLevelStyle   = "lower"
+)
+
 type LoggerConfig struct {
 	File struct {
-		Level LogLevel `mapstructure:"level" yaml:"level" validate:"required,loglevel"`
+		Enabled bool `mapstructure:"enabled" yaml:"enabled"`
+		// Level defaults to the level of the environment, see DefaultLogLevel
+		Level LogLevel `mapstructure:"level" yaml:"level" validate:"omitempty,loglevel"`
 		Path  string   `mapstructure:"path" yaml:"path" validate:"required"`
+		Name  string   `mapstructure:"name" yaml:"name" validate:"required,excludesall=/\\"`
+		// MaxLevel is the highest level written to the file, all the levels above Level are written when it is empty
+		MaxLevel LogLevel `mapstructure:"max_level" yaml:"max_level" validate:"omitempty,loglevel"`
+		// Format of the log file and the error file, the console format is set separately
+		Format string `mapstructure:"format" yaml:"format" validate:"required,oneof=json logfmt"`
+		// TimeFormat of the log file and the error file, a named time format such as rfc3339 or a Go time layout.
+		// It defaults to epoch for json and iso8601 for logfmt.
+		TimeFormat string         `mapstructure:"time_format" yaml:"time_format"`
+		Rotation   RotationConfig `mapstructure:"rotation" yaml:"rotation"`
+		// Keys of the entries of all the log files, the keys of zap.NewProductionEncoderConfig are kept when the block is absent
+		Keys *FileKeysConfig `mapstructure:"keys" yaml:"keys,omitempty"`
+		// Preset formats the entries of all the log files for a log store, e.g. ecs, the keys are ignored with a preset.
+		// The ecs preset is a JSON format, so the format must be json.
+		Preset string `mapstructure:"preset" yaml:"preset" validate:"omitempty,oneof=ecs,excluded_unless=Format json"`
+		// ShowFunction adds the function of the caller to the entries of all the log files under the "func" key
+		ShowFunction bool `mapstructure:"show_function" yaml:"show_function"`
 	} `mapstructure:"file" yaml:"file" validate:"required"`
 	Console struct {
-		Level LogLevel `mapstructure:"level" yaml:"level" validate:"required,loglevel"`
+		Enabled bool `mapstructure:"enabled" yaml:"enabled"`
+		// Level defaults to the level of the environment, see DefaultLogLevel
+		Level  LogLevel `mapstructure:"level" yaml:"level" validate:"omitempty,loglevel"`
+		Format string   `mapstructure:"format" yaml:"format" validate:"required,oneof=console json"`
+		// MaxLevel is the highest level written to the console, e.g. info to keep the errors in the files only
+		MaxLevel LogLevel `mapstructure:"max_level" yaml:"max_level" validate:"omitempty,loglevel"`
+		// TimeFormat is a named time format such as rfc3339, rfc3339nano, iso8601 or epoch, or a Go time layout, rfc3339 when empty
+		TimeFormat string `mapstructure:"time_format" yaml:"time_format"`
+		// ShowFunction writes the function of the caller after the file and the line, e.g. github.com/org/app/server.(*Server).Start
+		ShowFunction bool `mapstructure:"show_function" yaml:"show_function"`
+		// LevelStyle writes the level as [INFO] with bracket, INFO with plain or info with lower, bracket when empty.
+		// The colors apply to all the styles.
+		LevelStyle string `mapstructure:"level_style" yaml:"level_style" validate:"omitempty,oneof=bracket plain lower"`
+		// Separator is written between the time, the level, the caller and the message of the entries, a tab when empty
+		Separator string `mapstructure:"separator" yaml:"separator"`
+		// Colors maps a log level to a space separated list of color names, e.g. "red bold"
+		Colors  map[LogLevel]string `mapstructure:"colors" yaml:"colors" validate:"dive,keys,loglevel,endkeys,logcolor"`
+		NoColor bool                `mapstructure:"no_color" yaml:"no_color"`
+		Output  string              `mapstructure:"output" yaml:"output" validate:"required,oneof=stderr stdout"`
 	} `mapstructure:"console" yaml:"console" validate:"required"`
+	// StacktraceLevel is the lowest level that records a stacktrace, set to panic to disable it for errors
+	StacktraceLevel LogLevel `mapstructure:"stacktrace_level" yaml:"stacktrace_level" validate:"required,loglevel"`
+	// CallerSkip skips extra stack frames when the logger is wrapped by a helper
+	CallerSkip    int  `mapstructure:"caller_skip" yaml:"caller_skip" validate:"min=0"`
+	DisableCaller bool `mapstructure:"disable_caller" yaml:"disable_caller"`
+	// Files are additional log files written along with the main file, e.g. a debug.log capturing everything.
+	// Their levels are fixed, only the level of the main file can be changed at runtime.
+	Files []FileConfig `mapstructure:"files" yaml:"files,omitempty" validate:"dive"`
+	// ErrorFile is an additional file receiving only warnings and above, disabled when the block is absent
+	ErrorFile *ErrorFileConfig `mapstructure:"error_file" yaml:"error_file,omitempty"`
+	// Syslog is an additional output to a syslog daemon, disabled when the block is absent
+	Syslog *SyslogConfig `mapstructure:"syslog" yaml:"syslog,omitempty"`
+	// Recent keeps the most recent entries in memory for the /v1/logs/recent route, disabled when the block is absent
+	Recent *RecentLogsConfig `mapstructure:"recent" yaml:"recent,omitempty"`
+	// OTLP mirrors the entries at or above its level to an OTLP logs receiver, disabled when the block is absent
+	OTLP *OTLPLogsConfig `mapstructure:"otlp" yaml:"otlp,omitempty"`
+	// WritableCheck adds the writes to the log folders to the health and readiness checks, disabled when the block is absent
+	WritableCheck *WritableCheckConfig `mapstructure:"writable_check" yaml:"writable_check,omitempty"`
+	// Sampling is disabled when the block is absent
+	Sampling *SamplingConfig `mapstructure:"sampling" yaml:"sampling,omitempty"`
+	// WatchConfig applies changes of the log levels in the config file without a restart
+	WatchConfig bool `mapstructure:"watch_config" yaml:"watch_config"`
+	// Redact lists the field keys whose values are replaced by "***" in all outputs, e.g. password or token
+	Redact []string `mapstructure:"redact" yaml:"redact" validate:"dive,required"`
+	// ServiceName is added to every log line as the service field, it defaults to the package name and is omitted when blank
+	ServiceName string `mapstructure:"service_name" yaml:"service_name"`
+	// Fields are static labels added to every log line, e.g. region or env
+	Fields map[string]string `mapstructure:"fields" yaml:"fields" validate:"dive,keys,required,endkeys"`
+	// Levels overrides the level of the file and console outputs for the loggers created with Named, e.g. debug for "db".
+	// Nested loggers such as "db.pool" use the level of their closest parent, the other loggers keep the level of the outputs.
+	// The names are lower case, as viper lowercases the keys of the config.
+	Levels map[string]LogLevel `mapstructure:"levels" yaml:"levels" validate:"dive,keys,required,endkeys,loglevel"`
+	// FxEvents are logged at the info level by default, set the level to debug to quiet the startup
+	FxEvents FxEventsConfig `mapstructure:"fx_events" yaml:"fx_events"`
 }
 
 func init() {
 	// config must have a default value for viper to load config from env variables
 	// default value of empty string (zero value) will not pass the "required" config validation
+	viper.SetDefault("logs.file.enabled", true)
 	viper.SetDefault("logs.file.path", path.Join("/var/log", config.GetPackageName()))
-	viper.SetDefault("logs.file.level", InfoLevel)
-	viper.SetDefault("logs.console.level", InfoLevel)
+	viper.SetDefault("logs.file.name", "server.log")
+	// the levels are derived from the environment unless they are set
+	viper.SetDefault("logs.file.level", "")
+	viper.SetDefault("logs.file.max_level", "")
+	viper.SetDefault("logs.file.format", JSONFormat)
+	viper.SetDefault("logs.file.time_format", "")
+	viper.SetDefault("logs.file.keys.time", "ts")
+	viper.SetDefault("logs.file.keys.message", "msg")
+	viper.SetDefault("logs.file.keys.level", "level")
+	viper.SetDefault("logs.file.keys.caller", "caller")
+	viper.SetDefault("logs.file.keys.name", "logger")
+	viper.SetDefault("logs.file.preset", "")
+	viper.SetDefault("logs.file.show_function", false)
+	viper.SetDefault("logs.file.rotation.max_size_mb", 100)
+	viper.SetDefault("logs.file.rotation.max_backups", 0)
+	viper.SetDefault("logs.file.rotation.max_age_days", 0)
+	viper.SetDefault("logs.file.rotation.compress", false)
+	viper.SetDefault("logs.file.rotation.compress_format", GzipCompression)
+	viper.SetDefault("logs.console.enabled", true)
+	viper.SetDefault("logs.console.level", "")
+	viper.SetDefault("logs.console.max_level", "")
+	viper.SetDefault("logs.console.format", ConsoleFormat)
+	viper.SetDefault("logs.console.time_format", RFC3339TimeFormat)
+	viper.SetDefault("logs.console.show_function", false)
+	viper.SetDefault("logs.console.level_style", BracketLevelStyle)
+	viper.SetDefault("logs.console.separator", "")
+	viper.SetDefault("logs.console.output", StderrOutput)
+	viper.SetDefault("logs.stacktrace_level", ErrorLevel)
+	viper.SetDefault("logs.caller_skip", 0)
+	viper.SetDefault("logs.disable_caller", false)
+	viper.SetDefault("logs.watch_config", false)
+	viper.SetDefault("logs.redact", []string{})
+	viper.SetDefault("logs.service_name", config.GetPackageName())
+	viper.SetDefault("logs.fx_events.disabled", false)
+	viper.SetDefault("logs.fx_events.level", InfoLevel)
+	viper.SetDefault("logs.fx_events.timings", false)
+}
+
+// serviceKey is the key of the field holding the service name
+const serviceKey = "service"
+
+// AsCore annotates a constructor of a custom zapcore.Core, which is written to along with the built-in outputs
+func AsCore(core any) any {
+	return fx.Annotate(
+		core,
+		fx.As(new(zapcore.Core)),
+		fx.ResultTags(`group:"logCores"`),
+	)
+}
+
+var (
+	ErrNoLogOutput     = errors.New("both file and console log outputs are disabled")
+	ErrInvalidLogLevel = errors.New("invalid log level")
+	// ErrUnknownLoggerName is returned when changing the level of a logger name without a level in the config
+	ErrUnknownLoggerName = errors.New("logger name has no level")
+)
+
+// LogLevels holds the levels of the file and console outputs, which can be changed at runtime.
+// The initial levels still come from the validated LoggerConfig, so a misconfigured level
+// is rejected at startup rather than silently falling back to a default.
+type LogLevels struct {
+	File    zap.AtomicLevel
+	Console zap.AtomicLevel
+	// Named are the levels of the named loggers in LoggerConfig.Levels, only these names can be changed at runtime
+	Named map[string]zap.AtomicLevel
+}
+
+func NewLogLevels(config *LoggerConfig) *LogLevels {
+	named := make(map[string]zap.AtomicLevel, len(config.Levels))
+	for name, level := range config.Levels {
+		named[name] = zap.NewAtomicLevelAt(logLevelMap[level])
+	}
+	return &LogLevels{
+		File:    zap.NewAtomicLevelAt(logLevelMap[levelOrDefault(config.File.Level)]),
+		Console: zap.NewAtomicLevelAt(logLevelMap[levelOrDefault(config.Console.Level)]),
+		Named:   named,
+	}
+}
+
+// DefaultLogLevel is the level of the file and console outputs without a level in the config,
+// debug in the dev environment and info in the others
+func DefaultLogLevel() LogLevel {
+	if config.Environment() == config.DevEnvironment {
+		return DebugLevel
+	}
+	return InfoLevel
+}
+
+func levelOrDefault(level LogLevel) LogLevel {
+	if level == "" {
+		return DefaultLogLevel()
+	}
+	return level
+}
+
+func (l *LogLevels) FileLevel() LogLevel {
+	return LogLevel(l.File.Level().String())
+}
+
+func (l *LogLevels) ConsoleLevel() LogLevel {
+	return LogLevel(l.Console.Level().String())
+}
+
+func (l *LogLevels) SetFileLevel(level LogLevel) error {
+	return setLevel(l.File, level)
+}
+
+func (l *LogLevels) SetConsoleLevel(level LogLevel) error {
+	return setLevel(l.Console, level)
+}
+
+// NamedLevel returns the level of the logger name, false when the name has no level in the config
+func (l *LogLevels) NamedLevel(name string) (LogLevel, bool) {
+	level, ok := l.Named[name]
+	if !ok {
+		return "", false
+	}
+	return LogLevel(level.Level().String()), true
+}
+
+// SetNamedLevel changes the level of a logger name of the config, the names are fixed once the logger is built
+func (l *LogLevels) SetNamedLevel(name string, level LogLevel) error {
+	atomicLevel, ok := l.Named[name]
+	if !ok {
+		return fmt.Errorf("%w: %q", ErrUnknownLoggerName, name)
+	}
+	return setLevel(atomicLevel, level)
+}
+
+func setLevel(atomicLevel zap.AtomicLevel, level LogLevel) error {
+	zapLevel, ok := logLevelMap[level]
+	if !ok {
+		return fmt.Errorf("%w: %q", ErrInvalidLogLevel, level)
+	}
+	atomicLevel.SetLevel(zapLevel)
+	return nil
+}
+
+// New builds the logger from the outputs of the config and the custom cores, e.g. the core of a log vendor SDK.
+// The custom cores are redacted like the built-in outputs, but not sampled.
+func New(config *LoggerConfig, levels *LogLevels, customCores ...zapcore.Core) (*zap.SugaredLogger, error) {
+	return newLogger(config, levels, customCores, nil, nil)
 }
 
-func New(config *LoggerConfig) (*zap.SugaredLogger, error) {
-	// create directory if needed
-	err := os.MkdirAll(config.File.Path, os.ModePerm)
+// newLogger builds the logger with extra options, e.g. the hook counting the entries.
+// The writers of the log files are added to the files and the entries dropped by the sampling are counted
+// in dropped when they are not nil.
+func newLogger(config *LoggerConfig, levels *LogLevels, customCores []zapcore.Core, files *LogFiles, dropped *prometheus.CounterVec, extraOptions ...zap.Option) (*zap.SugaredLogger, error) {
+	var cores []zapcore.Core
+	reporter := &fileFailureReporter{}
+
+	sinks, err := fileSinks(config, levels)
 	if err != nil {
-		return nil, fmt.Errorf("error in creating log file folder for writing: %w", err)
+		return nil, err
+	}
+	for _, sink := range sinks {
+		enabler, withNamed := withNamedLevels(sink.enabler, levels.Named)
+		fileCore, err := newFileCore(sink, withMaxLevel(enabler, sink.MaxLevel), reporter, files)
+		if err != nil {
+			return nil, err
+		}
+		cores = append(cores, withNamed(sampleCore(config.Sampling, FileSink, redactCore(config.Redact, fileCore), dropped)))
+	}
+
+	if config.ErrorFile != nil {
+		errorFileCore, err := newErrorFileCore(config, reporter, files)
+		if err != nil {
+			return nil, err
+		}
+		cores = append(cores, sampleCore(config.Sampling, FileSink, redactCore(config.Redact, errorFileCore), dropped))
+	}
+
+	if config.Console.Enabled {
+		enabler, withNamed := withNamedLevels(levels.Console, levels.Named)
+		consoleCore := newConsoleCore(config, withMaxLevel(enabler, config.Console.MaxLevel))
+		cores = append(cores, withNamed(sampleCore(config.Sampling, ConsoleSink, redactCore(config.Redact, consoleCore), dropped)))
+	}
+
+	if config.Syslog != nil {
+		syslogCore, err := newSyslogCore(config.Syslog)
+		if err != nil {
+			return nil, err
+		}
+		cores = append(cores, sampleCore(config.Sampling, SyslogSink, redactCore(config.Redact, syslogCore), dropped))
+	}
+
+	for _, core := range customCores {
+		cores = append(cores, redactCore(config.Redact, core))
+	}
+
+	if len(cores) == 0 {
+		return nil, ErrNoLogOutput
+	}
+
+	options := []zap.Option{
+		zap.AddStacktrace(logLevelMap[config.StacktraceLevel]),
+	}
+	if !config.DisableCaller {
+		options = append(options, zap.AddCaller(), zap.AddCallerSkip(config.CallerSkip))
+	}
+	var fields []zap.Field
+	// a service label in the fields takes precedence over the service name
+	if _, ok := config.Fields[serviceKey]; !ok && config.ServiceName != "" {
+		fields = append(fields, zap.String(serviceKey, config.ServiceName))
 	}
+	if len(config.Fields) > 0 {
+		// sort the keys so that the fields are in the same order for every line
+		keys := make([]string, 0, len(config.Fields))
+		for key := range config.Fields {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			fields = append(fields, zap.String(key, config.Fields[key]))
+		}
+	}
+	if len(fields) > 0 {
+		options = append(options, zap.Fields(fields...))
+	}
+	options = append(options, extraOptions...)
+
+	logger := zap.New(zapcore.NewTee(cores...), options...)
+	reporter.logger.Store(logger)
+	return logger.Sugar(), nil
+}
 
-	// create a new writer for log rotation
-	fileWriter := zapcore.AddSync(&lumberjack.Logger{
-		Filename: path.Join(config.File.Path, "server.log"),
+// newLoggerWithSync registers the Sync hook while constructing the logger.
+// The logger is built before any invoke, so its OnStop hook runs last and
+// the shutdown messages logged by other modules still reach the file.
+// The config is validated first, so a misconfigured logger fails at startup with the failing keys.
+// The metrics and the OTLP exporter are optional, see countEntries and OTLPLogs.
+func newLoggerWithSync(lifecycle fx.Lifecycle, validate *validator.Validate, loggerConfig *LoggerConfig, levels *LogLevels, customCores []zapcore.Core, recent *RecentLogs, otlp *OTLPLogs, files *LogFiles, registry *prometheus.Registry, metricsConfig *metricsfx.MetricsConfig) (*zap.SugaredLogger, error) {
+	// registering the same validations again is a no-op, the validator is shared with the modules after the logger
+	if _, err := RegisterLogLevelValidation(validate); err != nil {
+		return nil, err
+	}
+	if err := config.ValidateStruct(validate, "logs", loggerConfig); err != nil {
+		return nil, fmt.Errorf("log config is invalid: %w", err)
+	}
+	var options []zap.Option
+	var dropped *prometheus.CounterVec
+	if registry != nil {
+		registerer := metricsfx.NewRegisterer(registry, metricsConfig)
+		option, err := countEntries(registerer)
+		if err != nil {
+			return nil, fmt.Errorf("error in registering log metrics: %w", err)
+		}
+		options = append(options, option)
+		if loggerConfig.Sampling != nil {
+			dropped, err = countDropped(registerer, loggerConfig.Sampling)
+			if err != nil {
+				return nil, fmt.Errorf("error in registering log metrics: %w", err)
+			}
+		}
+	}
+	if recent != nil {
+		customCores = append(customCores, recent.Core())
+	}
+	if otlp != nil {
+		customCores = append(customCores, otlp.Core())
+	}
+	logger, err := newLogger(loggerConfig, levels, customCores, files, dropped, options...)
+	if err != nil {
+		return nil, err
+	}
+	lifecycle.Append(fx.Hook{
+		OnStop: func(context.Context) error {
+			return syncLogger(logger)
+		},
 	})
+	return logger, nil
+}
+
+func syncLogger(logger *zap.SugaredLogger) error {
+	var errs error
+	for _, err := range multierr.Errors(logger.Sync()) {
+		// syncing stderr/stdout fails with EINVAL or ENOTTY on Linux when it is a terminal or pipe
+		if errors.Is(err, syscall.EINVAL) || errors.Is(err, syscall.ENOTTY) {
+			continue
+		}
+		errs = multierr.Append(errs, err)
+	}
+	return errs
+}
 
-	// setting the log level for file/console log output
-	fileLogLevel := logLevelMap[config.File.Level]
-	consoleLogLevel := logLevelMap[config.Console.Level]
+func newConsoleCore(config *LoggerConfig, level zapcore.LevelEnabler) zapcore.Core {
+	// when writing to a file, the *os.File need to be locked with Lock() for concurrent access
+	writer := os.Stderr
+	if config.Console.Output == StdoutOutput {
+		writer = os.Stdout
+	}
+	return zapcore.NewCore(newConsoleEncoder(config, writer), zapcore.Lock(writer), level)
+}
 
-	// setup the encoders
-	fileEncoderConfig := zap.NewProductionEncoderConfig()
-	fileEncoder := zapcore.NewJSONEncoder(fileEncoderConfig)
+func newConsoleEncoder(config *LoggerConfig, writer io.Writer) zapcore.Encoder {
 	consoleEncoderConfig := zap.NewProductionEncoderConfig()
-	colorMap := map[zapcore.Level]*color.Color{
-		zapcore.DebugLevel:  logger.DebugColor,
-		zapcore.InfoLevel:   logger.InfoColor,
-		zapcore.WarnLevel:   logger.WarnColor,
-		zapcore.ErrorLevel:  logger.ErrorColor,
-		zapcore.DPanicLevel: logger.FatalColor,
-		zapcore.FatalLevel:  logger.FatalColor,
-		zapcore.PanicLevel:  logger.FatalColor,
+	consoleEncoderConfig.EncodeTime = newTimeEncoder(config.Console.TimeFormat)
+	if consoleEncoderConfig.EncodeTime == nil {
+		consoleEncoderConfig.EncodeTime = zapcore.RFC3339TimeEncoder
+	}
+	if config.Console.ShowFunction {
+		consoleEncoderConfig.FunctionKey = functionKey
 	}
+	if config.Console.Format == JSONFormat {
+		// structured output for log shippers, level is kept as the plain lowercase string
+		return zapcore.NewJSONEncoder(consoleEncoderConfig)
+	}
+
+	colorMap := newColorMap(config.Console.Colors)
+	noColor := config.Console.NoColor || !colorSupported(writer)
+	levelStyle := config.Console.LevelStyle
 	consoleEncoderConfig.EncodeLevel = func(l zapcore.Level, pae zapcore.PrimitiveArrayEncoder) {
-		// custom encoding of level string as [INFO] style
-		pae.AppendString(colorMap[l].Sprintf("[%s]", l.CapitalString()))
+		// custom encoding of level string as [INFO] style by default
+		var level string
+		switch levelStyle {
+		case PlainLevelStyle:
+			level = l.CapitalString()
+		case LowerLevelStyle:
+			level = l.String()
+		default:
+			level = fmt.Sprintf("[%s]", l.CapitalString())
+		}
+		if noColor {
+			pae.AppendString(level)
+			return
+		}
+		pae.AppendString(colorMap[l].Sprint(level))
 	}
-	consoleEncoderConfig.EncodeTime = zapcore.RFC3339TimeEncoder
+	consoleEncoderConfig.ConsoleSeparator = config.Console.Separator
 	consoleEncoderConfig.EncodeCaller = func(ec zapcore.EntryCaller, pae zapcore.PrimitiveArrayEncoder) {
 		// custom encoding of the caller, now is set to the trimmed file path
 		pae.AppendString(ec.TrimmedPath())
 	}
-	consoleEncoder := zapcore.NewConsoleEncoder(consoleEncoderConfig)
-
-	// create the two cores for the logger
-	// when writing to a file, the *os.File need to be locked with Lock() for concurrent access
-	core := zapcore.NewTee(
-		zapcore.NewCore(fileEncoder, fileWriter, fileLogLevel),
-		zapcore.NewCore(consoleEncoder, zapcore.Lock(os.Stderr), consoleLogLevel),
-	)
-
-	return zap.New(core, zap.AddCaller()).Sugar(), nil
+	return zapcore.NewConsoleEncoder(consoleEncoderConfig)
 }
@@ -0,0 +1,1292 @@
+package loggerfx_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/spf13/viper"
+	collogspb "go.opentelemetry.io/proto/otlp/collector/logs/v1"
+	commonpb "go.opentelemetry.io/proto/otlp/common/v1"
+	logspb "go.opentelemetry.io/proto/otlp/logs/v1"
+	"go.uber.org/fx"
+	"go.uber.org/fx/fxevent"
+	"go.uber.org/fx/fxtest"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+	"google.golang.org/protobuf/proto"
+
+	scalpelconfig "github.com/prismedic/scalpel/config"
+	"github.com/prismedic/scalpel/infofx"
+	"github.com/prismedic/scalpel/loggerfx"
+	"github.com/prismedic/scalpel/metricsfx"
+)
+
+func newTestConfig(t *testing.T) *loggerfx.LoggerConfig {
+	config := &loggerfx.LoggerConfig{}
+	config.File.Enabled = true
+	config.Console.Enabled = true
+	config.File.Path = t.TempDir()
+	config.File.Name = "server.log"
+	config.File.Level = loggerfx.InfoLevel
+	config.File.Format = loggerfx.JSONFormat
+	config.Console.Level = loggerfx.InfoLevel
+	config.Console.Format = loggerfx.ConsoleFormat
+	config.Console.Output = loggerfx.StderrOutput
+	config.StacktraceLevel = loggerfx.ErrorLevel
+	return config
+}
+
+func TestNew(t *testing.T) {
+	t.Run("Test custom file name", func(t *testing.T) {
+		config := newTestConfig(t)
+		config.File.Name = "custom.log"
+		logger, err := loggerfx.New(config, loggerfx.NewLogLevels(config))
+		if err != nil {
+			t.Fatalf("failed to create logger: %v", err)
+		}
+		logger.Info("hello")
+		logger.Sync()
+		expectedPath := path.Join(config.File.Path, "custom.log")
+		if _, err := os.Stat(expectedPath); err != nil {
+			t.Errorf("log file not found at %s: %v", expectedPath, err)
+		}
+	})
+	t.Run("Test full disk", func(t *testing.T) {
+		// writes to /dev/full fail with ENOSPC like a file on a full disk
+		if _, err := os.Stat("/dev/full"); err != nil {
+			t.Skip("/dev/full is not available")
+		}
+		config := newTestConfig(t)
+		config.Console.Enabled = false
+		config.ErrorFile = &loggerfx.ErrorFileConfig{Path: config.File.Path}
+		config.File.Path = "/dev"
+		config.File.Name = "full"
+		logger, err := loggerfx.New(config, loggerfx.NewLogLevels(config))
+		if err != nil {
+			t.Fatalf("failed to create logger: %v", err)
+		}
+		for i := 0; i < 10; i++ {
+			logger.Infow("hello", "i", i)
+		}
+		logger.Sync()
+		content, err := os.ReadFile(path.Join(config.ErrorFile.Path, "errors.log"))
+		if err != nil {
+			t.Fatalf("failed to read error file: %v", err)
+		}
+		if count := strings.Count(string(content), "log file writes failed"); count != 1 {
+			t.Errorf("unexpected number of warnings, got %d, expected 1: %s", count, content)
+		}
+		if !strings.Contains(string(content), `"file":"/dev/full"`) {
+			t.Errorf("dropped file not found in warning: %s", content)
+		}
+	})
+	t.Run("Test multiple files", func(t *testing.T) {
+		config := newTestConfig(t)
+		config.File.Name = "app.log"
+		config.Files = []loggerfx.FileConfig{
+			{Level: loggerfx.DebugLevel, Path: config.File.Path, Name: "debug.log", Format: loggerfx.LogfmtFormat},
+		}
+		logger, err := loggerfx.New(config, loggerfx.NewLogLevels(config))
+		if err != nil {
+			t.Fatalf("failed to create logger: %v", err)
+		}
+		logger.Debug("debug message")
+		logger.Info("info message")
+		logger.Sync()
+		for name, expected := range map[string][]string{
+			"app.log":   {`"msg":"info message"`},
+			"debug.log": {"msg=\"debug message\"", "msg=\"info message\""},
+		} {
+			content, err := os.ReadFile(path.Join(config.File.Path, name))
+			if err != nil {
+				t.Fatalf("failed to read log file %s: %v", name, err)
+			}
+			if lines := strings.Count(string(content), "\n"); lines != len(expected) {
+				t.Errorf("unexpected number of lines in %s, got %d, expected %d: %s", name, lines, len(expected), content)
+			}
+			for _, entry := range expected {
+				if !strings.Contains(string(content), entry) {
+					t.Errorf("entry %s not found in %s: %s", entry, name, content)
+				}
+			}
+		}
+	})
+	t.Run("Test level range", func(t *testing.T) {
+		config := newTestConfig(t)
+		config.Console.Enabled = false
+		config.File.Name = "app.log"
+		config.File.Level = loggerfx.DebugLevel
+		config.File.MaxLevel = loggerfx.InfoLevel
+		config.Files = []loggerfx.FileConfig{
+			{Level: loggerfx.WarnLevel, Path: config.File.Path, Name: "warn.log"},
+		}
+		logger, err := loggerfx.New(config, loggerfx.NewLogLevels(config))
+		if err != nil {
+			t.Fatalf("failed to create logger: %v", err)
+		}
+		logger.Debug("debug message")
+		logger.Info("info message")
+		logger.Warn("warn message")
+		logger.Error("error message")
+		logger.Sync()
+		for name, expected := range map[string][]string{
+			"app.log":  {`"msg":"debug message"`, `"msg":"info message"`},
+			"warn.log": {`"msg":"warn message"`, `"msg":"error message"`},
+		} {
+			content, err := os.ReadFile(path.Join(config.File.Path, name))
+			if err != nil {
+				t.Fatalf("failed to read log file %s: %v", name, err)
+			}
+			if lines := strings.Count(string(content), "\n"); lines != len(expected) {
+				t.Errorf("unexpected number of lines in %s, got %d, expected %d: %s", name, lines, len(expected), content)
+			}
+			for _, entry := range expected {
+				if !strings.Contains(string(content), entry) {
+					t.Errorf("entry %s not found in %s: %s", entry, name, content)
+				}
+			}
+		}
+	})
+	t.Run("Test named levels", func(t *testing.T) {
+		config := newTestConfig(t)
+		config.Console.Enabled = false
+		config.Levels = map[string]loggerfx.LogLevel{"db": loggerfx.DebugLevel, "cache": loggerfx.ErrorLevel}
+		levels := loggerfx.NewLogLevels(config)
+		logger, err := loggerfx.New(config, levels)
+		if err != nil {
+			t.Fatalf("failed to create logger: %v", err)
+		}
+		logger.Named("db").Debug("db debug")
+		logger.Named("db").Named("pool").Debug("pool debug")
+		logger.Named("http").Debug("http debug")
+		logger.Named("cache").Warn("cache warn")
+		logger.Info("root info")
+		if err := levels.SetNamedLevel("db", loggerfx.InfoLevel); err != nil {
+			t.Errorf("failed to set named level: %v", err)
+		}
+		logger.Named("db").Debug("db debug after change")
+		if err := levels.SetNamedLevel("http", loggerfx.DebugLevel); !errors.Is(err, loggerfx.ErrUnknownLoggerName) {
+			t.Errorf("unexpected error, got %v, expected %v", err, loggerfx.ErrUnknownLoggerName)
+		}
+		logger.Sync()
+		content, err := os.ReadFile(path.Join(config.File.Path, config.File.Name))
+		if err != nil {
+			t.Fatalf("failed to read log file: %v", err)
+		}
+		for _, expected := range []string{`"msg":"db debug"`, `"msg":"pool debug"`, `"msg":"root info"`} {
+			if !strings.Contains(string(content), expected) {
+				t.Errorf("entry %s not found in log file: %s", expected, content)
+			}
+		}
+		for _, unexpected := range []string{"http debug", "cache warn", "db debug after change"} {
+			if strings.Contains(string(content), unexpected) {
+				t.Errorf("entry %s found in log file: %s", unexpected, content)
+			}
+		}
+	})
+	t.Run("Test zstd compression", func(t *testing.T) {
+		config := newTestConfig(t)
+		config.Console.Enabled = false
+		config.File.Rotation = loggerfx.RotationConfig{MaxSizeMB: 1, MaxBackups: 2, Compress: true, CompressFormat: loggerfx.ZstdCompression}
+		// backups left by a previous run, the oldest is removed once compressed
+		for _, name := range []string{"server-2020-01-01T00-00-00.000.log", "server-2020-01-02T00-00-00.000.log"} {
+			if err := os.WriteFile(path.Join(config.File.Path, name), []byte("{}\n"), 0600); err != nil {
+				t.Fatalf("failed to write backup: %v", err)
+			}
+		}
+		logger, err := loggerfx.New(config, loggerfx.NewLogLevels(config))
+		if err != nil {
+			t.Fatalf("failed to create logger: %v", err)
+		}
+		message := strings.Repeat("x", 1024)
+		for i := 0; i < 1100; i++ {
+			logger.Info(message)
+		}
+		logger.Sync()
+
+		var names []string
+		for deadline := time.Now().Add(5 * time.Second); time.Now().Before(deadline); time.Sleep(10 * time.Millisecond) {
+			backups, err := filepath.Glob(path.Join(config.File.Path, "server-*"))
+			if err != nil {
+				t.Fatalf("failed to list backups: %v", err)
+			}
+			names = names[:0]
+			for _, backup := range backups {
+				names = append(names, path.Base(backup))
+			}
+			if len(names) == 2 && strings.HasSuffix(names[0], ".log.zst") && strings.HasSuffix(names[1], ".log.zst") && names[0] == "server-2020-01-02T00-00-00.000.log.zst" {
+				return
+			}
+		}
+		t.Errorf("unexpected backups, got %v, expected the newest backup of the previous run and the rotated file compressed with zstd", names)
+	})
+	t.Run("Test duplicate files", func(t *testing.T) {
+		config := newTestConfig(t)
+		config.Files = []loggerfx.FileConfig{
+			{Level: loggerfx.DebugLevel, Path: config.File.Path + "/", Name: config.File.Name},
+		}
+		if _, err := loggerfx.New(config, loggerfx.NewLogLevels(config)); !errors.Is(err, loggerfx.ErrDuplicateLogFile) {
+			t.Errorf("unexpected error, got %v, expected %v", err, loggerfx.ErrDuplicateLogFile)
+		}
+	})
+	t.Run("Test duplicate error file", func(t *testing.T) {
+		// the error file is in the directory of the main file by default
+		for _, name := range []string{"server.log", "debug.log"} {
+			config := newTestConfig(t)
+			config.Files = []loggerfx.FileConfig{{Level: loggerfx.DebugLevel, Path: config.File.Path, Name: "debug.log"}}
+			config.ErrorFile = &loggerfx.ErrorFileConfig{Name: name}
+			if _, err := loggerfx.New(config, loggerfx.NewLogLevels(config)); !errors.Is(err, loggerfx.ErrDuplicateLogFile) {
+				t.Errorf("unexpected error of error file %s, got %v, expected %v", name, err, loggerfx.ErrDuplicateLogFile)
+			}
+		}
+	})
+	t.Run("Test error file", func(t *testing.T) {
+		config := newTestConfig(t)
+		config.ErrorFile = &loggerfx.ErrorFileConfig{}
+		logger, err := loggerfx.New(config, loggerfx.NewLogLevels(config))
+		if err != nil {
+			t.Fatalf("failed to create logger: %v", err)
+		}
+		logger.Info("info message")
+		logger.Warn("warn message")
+		logger.Sync()
+		content, err := os.ReadFile(path.Join(config.File.Path, "errors.log"))
+		if err != nil {
+			t.Fatalf("failed to read error log file: %v", err)
+		}
+		if strings.Contains(string(content), "info message") {
+			t.Errorf("unexpected info entry in error log file: %s", content)
+		}
+		if !strings.Contains(string(content), "warn message") {
+			t.Errorf("warn entry not found in error log file: %s", content)
+		}
+	})
+	t.Run("Test disabled file output", func(t *testing.T) {
+		config := newTestConfig(t)
+		config.File.Enabled = false
+		config.File.Path = path.Join(config.File.Path, "unused")
+		if _, err := loggerfx.New(config, loggerfx.NewLogLevels(config)); err != nil {
+			t.Fatalf("failed to create logger: %v", err)
+		}
+		if _, err := os.Stat(config.File.Path); !os.IsNotExist(err) {
+			t.Errorf("log folder %s should not be created when file output is disabled", config.File.Path)
+		}
+	})
+	t.Run("Test logfmt file format", func(t *testing.T) {
+		config := newTestConfig(t)
+		config.File.Format = loggerfx.LogfmtFormat
+		logger, err := loggerfx.New(config, loggerfx.NewLogLevels(config))
+		if err != nil {
+			t.Fatalf("failed to create logger: %v", err)
+		}
+		logger.With("request id", "abc").Infow("hello world", "count", 1, "query", "a=b")
+		logger.Sync()
+		content, err := os.ReadFile(path.Join(config.File.Path, config.File.Name))
+		if err != nil {
+			t.Fatalf("failed to read log file: %v", err)
+		}
+		for _, expected := range []string{`level=info`, `msg="hello world"`, `"request id"=abc`, `count=1`, `query="a=b"`} {
+			if !strings.Contains(string(content), expected) {
+				t.Errorf("%s not found in log entry %s", expected, content)
+			}
+		}
+	})
+	t.Run("Test file time formats", func(t *testing.T) {
+		for timeFormat, pattern := range map[string]string{
+			loggerfx.EpochMillisTimeFormat: `"ts":[0-9]{13}(\.[0-9]+)?,`,
+			loggerfx.RFC3339TimeFormat:     `"ts":"[0-9]{4}-[0-9]{2}-[0-9]{2}T[0-9]{2}:[0-9]{2}:[0-9]{2}(Z|[+-][0-9]{2}:[0-9]{2})"`,
+			"2006-01-02 15:04:05.000":      `"ts":"[0-9]{4}-[0-9]{2}-[0-9]{2} [0-9]{2}:[0-9]{2}:[0-9]{2}\.[0-9]{3}"`,
+		} {
+			config := newTestConfig(t)
+			config.File.TimeFormat = timeFormat
+			logger, err := loggerfx.New(config, loggerfx.NewLogLevels(config))
+			if err != nil {
+				t.Fatalf("failed to create logger: %v", err)
+			}
+			logger.Info("hello")
+			logger.Sync()
+			content, err := os.ReadFile(path.Join(config.File.Path, config.File.Name))
+			if err != nil {
+				t.Fatalf("failed to read log file: %v", err)
+			}
+			if !regexp.MustCompile(pattern).Match(content) {
+				t.Errorf("unexpected time with format %s: %s", timeFormat, content)
+			}
+		}
+	})
+	t.Run("Test file keys", func(t *testing.T) {
+		config := newTestConfig(t)
+		config.File.Keys = &loggerfx.FileKeysConfig{Time: "@timestamp", Message: "message", Level: "severity", Caller: "source", Name: "logger"}
+		logger, err := loggerfx.New(config, loggerfx.NewLogLevels(config))
+		if err != nil {
+			t.Fatalf("failed to create logger: %v", err)
+		}
+		logger.Info("hello")
+		logger.Sync()
+		content, err := os.ReadFile(path.Join(config.File.Path, config.File.Name))
+		if err != nil {
+			t.Fatalf("failed to read log file: %v", err)
+		}
+		var entry map[string]any
+		if err := json.Unmarshal(content, &entry); err != nil {
+			t.Fatalf("failed to parse log entry %s: %v", content, err)
+		}
+		for _, key := range []string{"@timestamp", "message", "severity", "source"} {
+			if _, ok := entry[key]; !ok {
+				t.Errorf("key %s not found in log entry %s", key, content)
+			}
+		}
+		if _, ok := entry["msg"]; ok {
+			t.Errorf("default message key found in log entry %s", content)
+		}
+	})
+	t.Run("Test show function", func(t *testing.T) {
+		config := newTestConfig(t)
+		config.File.ShowFunction = true
+		logger, err := loggerfx.New(config, loggerfx.NewLogLevels(config))
+		if err != nil {
+			t.Fatalf("failed to create logger: %v", err)
+		}
+		logger.Info("hello")
+		logger.Sync()
+		content, err := os.ReadFile(path.Join(config.File.Path, config.File.Name))
+		if err != nil {
+			t.Fatalf("failed to read log file: %v", err)
+		}
+		if !strings.Contains(string(content), `"func":"github.com/prismedic/scalpel/loggerfx_test.TestNew.func`) {
+			t.Errorf("caller function not found in log entry %s", content)
+		}
+	})
+	t.Run("Test ecs preset", func(t *testing.T) {
+		config := newTestConfig(t)
+		config.File.Preset = loggerfx.ECSPreset
+		logger, err := loggerfx.New(config, loggerfx.NewLogLevels(config))
+		if err != nil {
+			t.Fatalf("failed to create logger: %v", err)
+		}
+		logger.With("user", "alice").Warn("hello")
+		logger.Sync()
+		content, err := os.ReadFile(path.Join(config.File.Path, config.File.Name))
+		if err != nil {
+			t.Fatalf("failed to read log file: %v", err)
+		}
+		var entry map[string]any
+		if err := json.Unmarshal(content, &entry); err != nil {
+			t.Fatalf("failed to parse log entry %s: %v", content, err)
+		}
+		for key, expected := range map[string]any{"log.level": "warn", "message": "hello", "ecs.version": "8.11.0", "user": "alice"} {
+			if entry[key] != expected {
+				t.Errorf("unexpected %s, got %v, expected %v", key, entry[key], expected)
+			}
+		}
+		if timestamp, _ := entry["@timestamp"].(string); !strings.HasSuffix(timestamp, "Z") {
+			t.Errorf("unexpected @timestamp, got %v, expected UTC time", entry["@timestamp"])
+		}
+		if _, ok := entry["log.origin.file.line"].(float64); !ok {
+			t.Errorf("log.origin.file.line not found in log entry %s", content)
+		}
+	})
+	t.Run("Test service field", func(t *testing.T) {
+		for _, test := range []struct {
+			serviceName string
+			fields      map[string]string
+			expected    string
+		}{
+			{"orders", nil, `"service":"orders"`},
+			{"orders", map[string]string{"service": "billing"}, `"service":"billing"`},
+			{"", nil, ""},
+		} {
+			config := newTestConfig(t)
+			config.ServiceName = test.serviceName
+			config.Fields = test.fields
+			logger, err := loggerfx.New(config, loggerfx.NewLogLevels(config))
+			if err != nil {
+				t.Fatalf("failed to create logger: %v", err)
+			}
+			logger.Info("hello")
+			logger.Sync()
+			content, err := os.ReadFile(path.Join(config.File.Path, config.File.Name))
+			if err != nil {
+				t.Fatalf("failed to read log file: %v", err)
+			}
+			if count := strings.Count(string(content), `"service":`); test.expected == "" && count != 0 || test.expected != "" && count != 1 {
+				t.Errorf("unexpected number of service fields %d in log entry %s", count, content)
+			}
+			if !strings.Contains(string(content), test.expected) {
+				t.Errorf("%s not found in log entry %s", test.expected, content)
+			}
+		}
+	})
+	t.Run("Test redacted fields", func(t *testing.T) {
+		config := newTestConfig(t)
+		config.Redact = []string{"password"}
+		logger, err := loggerfx.New(config, loggerfx.NewLogLevels(config))
+		if err != nil {
+			t.Fatalf("failed to create logger: %v", err)
+		}
+		logger.With("Password", "secret1").Infow("login", "password", "secret2", "user", "alice")
+		logger.Sync()
+		content, err := os.ReadFile(path.Join(config.File.Path, config.File.Name))
+		if err != nil {
+			t.Fatalf("failed to read log file: %v", err)
+		}
+		var entry map[string]interface{}
+		if err := json.Unmarshal(content, &entry); err != nil {
+			t.Fatalf("failed to parse log entry %s: %v", content, err)
+		}
+		for key, expected := range map[string]string{"Password": "***", "password": "***", "user": "alice"} {
+			if entry[key] != expected {
+				t.Errorf("unexpected value of %s, got %v, expected %s", key, entry[key], expected)
+			}
+		}
+	})
+	t.Run("Test syslog output", func(t *testing.T) {
+		if runtime.GOOS == "windows" || runtime.GOOS == "plan9" {
+			t.Skip("syslog is not supported")
+		}
+		conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+		if err != nil {
+			t.Fatalf("failed to listen for syslog: %v", err)
+		}
+		defer conn.Close()
+		config := newTestConfig(t)
+		config.Syslog = &loggerfx.SyslogConfig{Network: "udp", Address: conn.LocalAddr().String(), Tag: "test"}
+		logger, err := loggerfx.New(config, loggerfx.NewLogLevels(config))
+		if err != nil {
+			t.Fatalf("failed to create logger: %v", err)
+		}
+		logger.Warn("warn message")
+
+		conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+		packet := make([]byte, 4096)
+		n, _, err := conn.ReadFrom(packet)
+		if err != nil {
+			t.Fatalf("failed to read syslog message: %v", err)
+		}
+		// priority of the user facility and the warning severity
+		message := string(packet[:n])
+		if !strings.HasPrefix(message, "<12>") || !strings.Contains(message, "warn message") {
+			t.Errorf("unexpected syslog message %s", message)
+		}
+	})
+	t.Run("Test read-only log folder", func(t *testing.T) {
+		if os.Geteuid() == 0 {
+			t.Skip("file permissions are not enforced for root")
+		}
+		config := newTestConfig(t)
+		if err := os.Chmod(config.File.Path, 0500); err != nil {
+			t.Fatalf("failed to make log folder read-only: %v", err)
+		}
+		defer os.Chmod(config.File.Path, 0700)
+		if _, err := loggerfx.New(config, loggerfx.NewLogLevels(config)); !errors.Is(err, os.ErrPermission) {
+			t.Errorf("unexpected error, got %v, expected %v", err, os.ErrPermission)
+		}
+	})
+	t.Run("Test all outputs disabled", func(t *testing.T) {
+		config := newTestConfig(t)
+		config.File.Enabled = false
+		config.Console.Enabled = false
+		if _, err := loggerfx.New(config, loggerfx.NewLogLevels(config)); !errors.Is(err, loggerfx.ErrNoLogOutput) {
+			t.Errorf("unexpected error, got %v, expected %v", err, loggerfx.ErrNoLogOutput)
+		}
+	})
+}
+
+// logFromHelper logs from a wrapper function and returns the line of the log call
+func logFromHelper(logger *zap.SugaredLogger) int {
+	_, _, line, _ := runtime.Caller(0)
+	logger.Info("hello")
+	return line + 1
+}
+
+func TestCallerSkip(t *testing.T) {
+	for _, callerSkip := range []int{0, 1} {
+		t.Run(fmt.Sprintf("Test caller skip %d", callerSkip), func(t *testing.T) {
+			config := newTestConfig(t)
+			config.Console.Enabled = false
+			config.CallerSkip = callerSkip
+			logger, err := loggerfx.New(config, loggerfx.NewLogLevels(config))
+			if err != nil {
+				t.Fatalf("failed to create logger: %v", err)
+			}
+			_, _, line, _ := runtime.Caller(0)
+			helperLine := logFromHelper(logger)
+			logger.Sync()
+
+			expectedLine := helperLine
+			if callerSkip == 1 {
+				expectedLine = line + 1
+			}
+			content, err := os.ReadFile(path.Join(config.File.Path, config.File.Name))
+			if err != nil {
+				t.Fatalf("failed to read log file: %v", err)
+			}
+			var entry struct {
+				Caller string `json:"caller"`
+			}
+			if err := json.Unmarshal(content, &entry); err != nil {
+				t.Fatalf("failed to parse log entry %s: %v", content, err)
+			}
+			expectedCaller := fmt.Sprintf("loggerfx/loggerfx_test.go:%d", expectedLine)
+			if entry.Caller != expectedCaller {
+				t.Errorf("unexpected caller, got %s, expected %s", entry.Caller, expectedCaller)
+			}
+		})
+	}
+	t.Run("Test disable caller", func(t *testing.T) {
+		config := newTestConfig(t)
+		config.Console.Enabled = false
+		config.DisableCaller = true
+		logger, err := loggerfx.New(config, loggerfx.NewLogLevels(config))
+		if err != nil {
+			t.Fatalf("failed to create logger: %v", err)
+		}
+		logger.Info("hello")
+		logger.Sync()
+		content, err := os.ReadFile(path.Join(config.File.Path, config.File.Name))
+		if err != nil {
+			t.Fatalf("failed to read log file: %v", err)
+		}
+		if strings.Contains(string(content), `"caller"`) {
+			t.Errorf("unexpected caller in log entry %s", content)
+		}
+	})
+}
+
+func TestLogLevels(t *testing.T) {
+	config := newTestConfig(t)
+	levels := loggerfx.NewLogLevels(config)
+	t.Run("Test set valid level", func(t *testing.T) {
+		if err := levels.SetConsoleLevel(loggerfx.DebugLevel); err != nil {
+			t.Fatalf("failed to set console level: %v", err)
+		}
+		if got := levels.ConsoleLevel(); got != loggerfx.DebugLevel {
+			t.Errorf("unexpected console level, got %s, expected %s", got, loggerfx.DebugLevel)
+		}
+		if got := levels.FileLevel(); got != loggerfx.InfoLevel {
+			t.Errorf("file level should be unchanged, got %s, expected %s", got, loggerfx.InfoLevel)
+		}
+	})
+	t.Run("Test set invalid level", func(t *testing.T) {
+		if err := levels.SetFileLevel("verbose"); !errors.Is(err, loggerfx.ErrInvalidLogLevel) {
+			t.Errorf("unexpected error, got %v, expected %v", err, loggerfx.ErrInvalidLogLevel)
+		}
+		if got := levels.FileLevel(); got != loggerfx.InfoLevel {
+			t.Errorf("file level should be unchanged, got %s, expected %s", got, loggerfx.InfoLevel)
+		}
+	})
+}
+
+func TestDefaultLogLevel(t *testing.T) {
+	defer viper.Set("environment", nil)
+	for _, test := range []struct {
+		environment   string
+		expectedLevel loggerfx.LogLevel
+	}{
+		{"dev", loggerfx.DebugLevel},
+		{"development", loggerfx.DebugLevel},
+		{"staging", loggerfx.InfoLevel},
+		{"prod", loggerfx.InfoLevel},
+		{"", loggerfx.InfoLevel},
+	} {
+		t.Run("Test environment "+test.environment, func(t *testing.T) {
+			viper.Set("environment", test.environment)
+			config := newTestConfig(t)
+			config.File.Level = ""
+			config.Console.Level = loggerfx.WarnLevel
+			levels := loggerfx.NewLogLevels(config)
+			if got := levels.FileLevel(); got != test.expectedLevel {
+				t.Errorf("unexpected default file level, got %s, expected %s", got, test.expectedLevel)
+			}
+			if got := levels.ConsoleLevel(); got != loggerfx.WarnLevel {
+				t.Errorf("configured console level should be kept, got %s, expected %s", got, loggerfx.WarnLevel)
+			}
+		})
+	}
+}
+
+func TestLoggerConfigValidation(t *testing.T) {
+	validate, err := loggerfx.RegisterLogLevelValidation(validator.New())
+	if err != nil {
+		t.Fatalf("failed to register validation: %v", err)
+	}
+	t.Run("Test file name with path separator", func(t *testing.T) {
+		for _, name := range []string{"../server.log", "logs/server.log", `logs\server.log`} {
+			config := newTestConfig(t)
+			config.File.Name = name
+			if err := validate.Struct(config); err == nil {
+				t.Errorf("expected file name %q to be rejected", name)
+			}
+		}
+	})
+	t.Run("Test rotation defaults", func(t *testing.T) {
+		config, err := scalpelconfig.Unmarshal[loggerfx.LoggerConfig](validate, "logs")
+		if err != nil {
+			t.Fatalf("failed to load default config: %v", err)
+		}
+		if rotation := config.File.Rotation; rotation.MaxSizeMB != 100 || rotation.MaxBackups != 0 || rotation.MaxAgeDays != 0 ||
+			rotation.Compress || rotation.CompressFormat != loggerfx.GzipCompression {
+			t.Errorf("unexpected default rotation %+v", rotation)
+		}
+	})
+	t.Run("Test negative rotation values", func(t *testing.T) {
+		for _, negative := range []func(*loggerfx.RotationConfig){
+			func(rotation *loggerfx.RotationConfig) { rotation.MaxSizeMB = -1 },
+			func(rotation *loggerfx.RotationConfig) { rotation.MaxBackups = -1 },
+			func(rotation *loggerfx.RotationConfig) { rotation.MaxAgeDays = -1 },
+		} {
+			config := newTestConfig(t)
+			config.Files = []loggerfx.FileConfig{{Level: loggerfx.DebugLevel, Path: config.File.Path, Name: "debug.log"}}
+			config.ErrorFile = &loggerfx.ErrorFileConfig{}
+			for _, rotation := range []*loggerfx.RotationConfig{&config.File.Rotation, &config.Files[0].Rotation, &config.ErrorFile.Rotation} {
+				negative(rotation)
+				if err := validate.Struct(config); err == nil {
+					t.Errorf("expected negative rotation %+v to be rejected", *rotation)
+				}
+				*rotation = loggerfx.RotationConfig{}
+			}
+		}
+	})
+	t.Run("Test console colors", func(t *testing.T) {
+		config := newTestConfig(t)
+		config.Console.Colors = map[loggerfx.LogLevel]string{loggerfx.InfoLevel: "cyan bold"}
+		if err := validate.Struct(config); err != nil {
+			t.Errorf("unexpected validation error: %v", err)
+		}
+		config.Console.Colors = map[loggerfx.LogLevel]string{loggerfx.InfoLevel: "purple"}
+		if err := validate.Struct(config); err == nil {
+			t.Errorf("expected unknown color to be rejected")
+		}
+		config.Console.Colors = map[loggerfx.LogLevel]string{"verbose": "red"}
+		if err := validate.Struct(config); err == nil {
+			t.Errorf("expected unknown level to be rejected")
+		}
+	})
+	t.Run("Test empty file key", func(t *testing.T) {
+		config := newTestConfig(t)
+		config.File.Keys = &loggerfx.FileKeysConfig{Time: "@timestamp", Message: "", Level: "severity", Caller: "caller", Name: "logger"}
+		if err := validate.Struct(config); err == nil {
+			t.Errorf("expected empty message key to be rejected")
+		}
+	})
+	t.Run("Test ecs preset with logfmt", func(t *testing.T) {
+		config := newTestConfig(t)
+		config.File.Preset = loggerfx.ECSPreset
+		config.File.Format = loggerfx.LogfmtFormat
+		if err := validate.Struct(config); err == nil {
+			t.Errorf("expected ecs preset with logfmt format to be rejected")
+		}
+	})
+	t.Run("Test named levels", func(t *testing.T) {
+		config := newTestConfig(t)
+		config.Levels = map[string]loggerfx.LogLevel{"db": "verbose"}
+		if err := validate.Struct(config); err == nil {
+			t.Errorf("expected unknown named level to be rejected")
+		}
+	})
+	t.Run("Test valid config", func(t *testing.T) {
+		if err := validate.Struct(newTestConfig(t)); err != nil {
+			t.Errorf("unexpected validation error: %v", err)
+		}
+	})
+}
+
+func TestTestModule(t *testing.T) {
+	var logger *zap.SugaredLogger
+	app := fxtest.New(t, loggerfx.TestModule, fx.Populate(&logger))
+	app.RequireStart().RequireStop()
+	if logger == nil {
+		t.Fatalf("logger is not provided")
+	}
+	logger.Info("discarded")
+}
+
+func TestNewObserved(t *testing.T) {
+	observedLogger, logs := loggerfx.NewObserved(zapcore.InfoLevel)
+	var logger *zap.SugaredLogger
+	app := fxtest.New(t, loggerfx.TestModule, fx.Replace(observedLogger), fx.Populate(&logger))
+	app.RequireStart().RequireStop()
+	logger.Debugw("debug message")
+	logger.Infow("info message", "user", "alice")
+	if logs.Len() != 1 {
+		t.Fatalf("unexpected number of entries, got %d, expected 1", logs.Len())
+	}
+	entry := logs.All()[0]
+	if entry.Message != "info message" || entry.ContextMap()["user"] != "alice" {
+		t.Errorf("unexpected entry %+v", entry)
+	}
+}
+
+func TestCustomCores(t *testing.T) {
+	config := newTestConfig(t)
+	config.Console.Enabled = false
+	config.Redact = []string{"token"}
+	core, logs := observer.New(zapcore.InfoLevel)
+	var logger *zap.SugaredLogger
+	app := fxtest.New(t,
+		loggerfx.Module,
+		fx.Supply(config),
+		fx.Provide(validator.New),
+		fx.Provide(loggerfx.AsCore(func() zapcore.Core { return core })),
+		fx.Populate(&logger),
+	)
+	defer app.RequireStart().RequireStop()
+
+	logger.Infow("custom core", "token", "secret")
+	entries := logs.FilterMessage("custom core").All()
+	if len(entries) != 1 {
+		t.Fatalf("unexpected number of entries in custom core, got %d, expected 1", len(entries))
+	}
+	if token := entries[0].ContextMap()["token"]; token != "***" {
+		t.Errorf("field not redacted in custom core, got %v", token)
+	}
+}
+
+// filteringCore writes only the entries of the message, its level is the one of the wrapped core
+type filteringCore struct {
+	zapcore.Core
+	message string
+}
+
+func (c *filteringCore) With(fields []zapcore.Field) zapcore.Core {
+	return &filteringCore{Core: c.Core.With(fields), message: c.message}
+}
+
+func (c *filteringCore) Check(entry zapcore.Entry, checked *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if entry.Message == c.message {
+		return c.Core.Check(entry, checked)
+	}
+	return checked
+}
+
+func TestCustomCoreCheck(t *testing.T) {
+	config := newTestConfig(t)
+	config.Console.Enabled = false
+	config.Redact = []string{"token"}
+	observed, logs := observer.New(zapcore.WarnLevel)
+	core := &filteringCore{Core: observed, message: "kept"}
+	var logger *zap.SugaredLogger
+	app := fxtest.New(t,
+		loggerfx.Module,
+		fx.Supply(config),
+		fx.Provide(validator.New),
+		fx.Provide(loggerfx.AsCore(func() zapcore.Core { return core })),
+		fx.Populate(&logger),
+	)
+	defer app.RequireStart().RequireStop()
+
+	logger.Warnw("filtered", "token", "secret")
+	logger.Infow("kept", "token", "secret")
+	logger.With("token", "secret").Warnw("kept", "user", "alice")
+	entries := logs.All()
+	if len(entries) != 1 {
+		t.Fatalf("unexpected number of entries in custom core, got %d, expected 1", len(entries))
+	}
+	if fields := entries[0].ContextMap(); fields["token"] != "***" || fields["user"] != "alice" {
+		t.Errorf("unexpected fields in custom core, got %v", fields)
+	}
+}
+
+func TestEntryMetrics(t *testing.T) {
+	config := newTestConfig(t)
+	config.Console.Enabled = false
+	registry := prometheus.NewRegistry()
+	var logger *zap.SugaredLogger
+	app := fxtest.New(t,
+		loggerfx.Module,
+		fx.Supply(config, registry),
+		fx.Provide(validator.New),
+		fx.Populate(&logger),
+	)
+	defer app.RequireStart().RequireStop()
+
+	logger.Debug("not logged")
+	logger.Error("first error")
+	logger.Error("second error")
+	// the info entries of fx are counted too, so only some of the levels are compared
+	metrics, err := registry.Gather()
+	if err != nil {
+		t.Fatalf("failed to gather metrics: %v", err)
+	}
+	counts := map[string]float64{}
+	for _, family := range metrics {
+		if family.GetName() != "log_messages_total" {
+			continue
+		}
+		for _, metric := range family.GetMetric() {
+			counts[metric.GetLabel()[0].GetValue()] = metric.GetCounter().GetValue()
+		}
+	}
+	if counts["error"] != 2 || counts["debug"] != 0 {
+		t.Errorf("unexpected log entry counts %v", counts)
+	}
+	if _, ok := counts["fatal"]; !ok {
+		t.Errorf("fatal level is not initialized in %v", counts)
+	}
+}
+
+func TestEntryMetricsNamespace(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	metricsConfig := &metricsfx.MetricsConfig{Namespace: "arsenal"}
+	// the apps share the registry, the second logger counts on the collector registered by the first one
+	for i := 0; i < 2; i++ {
+		config := newTestConfig(t)
+		config.Console.Enabled = false
+		config.Sampling = &loggerfx.SamplingConfig{Initial: 1, Thereafter: 0, Sinks: []string{loggerfx.FileSink}}
+		var logger *zap.SugaredLogger
+		app := fxtest.New(t,
+			loggerfx.Module,
+			scalpelconfig.ValidationModule,
+			fx.Supply(config, registry, metricsConfig),
+			fx.Provide(validator.New),
+			fx.Populate(&logger),
+		)
+		app.RequireStart()
+		// the second entry is dropped by the sampling of the only output, so it is not counted in log_messages_total
+		logger.Error("error")
+		logger.Error("error")
+		app.RequireStop()
+	}
+
+	metrics, err := registry.Gather()
+	if err != nil {
+		t.Fatalf("failed to gather metrics: %v", err)
+	}
+	counts := map[string]float64{}
+	for _, family := range metrics {
+		if family.GetName() == "log_messages_total" || family.GetName() == "log_entries_dropped_total" {
+			t.Errorf("unexpected metric without namespace %s", family.GetName())
+		}
+		for _, metric := range family.GetMetric() {
+			for _, label := range metric.GetLabel() {
+				if label.GetName() == "level" && label.GetValue() == "error" {
+					counts[family.GetName()] = metric.GetCounter().GetValue()
+				}
+			}
+		}
+	}
+	for _, name := range []string{"arsenal_log_messages_total", "arsenal_log_entries_dropped_total"} {
+		if counts[name] != 2 {
+			t.Errorf("unexpected error entry count of %s, got %f, expected 2", name, counts[name])
+		}
+	}
+}
+
+func TestDroppedEntryMetrics(t *testing.T) {
+	config := newTestConfig(t)
+	config.Console.Enabled = false
+	config.Sampling = &loggerfx.SamplingConfig{Initial: 2, Thereafter: 0, Sinks: []string{loggerfx.FileSink}}
+	registry := prometheus.NewRegistry()
+	var logger *zap.SugaredLogger
+	app := fxtest.New(t,
+		loggerfx.Module,
+		scalpelconfig.ValidationModule,
+		fx.Supply(config, registry),
+		fx.Provide(validator.New),
+		fx.Populate(&logger),
+	)
+	defer app.RequireStart().RequireStop()
+
+	for i := 0; i < 5; i++ {
+		logger.Warn("repeated warning")
+	}
+	metrics, err := registry.Gather()
+	if err != nil {
+		t.Fatalf("failed to gather metrics: %v", err)
+	}
+	counts := map[string]float64{}
+	for _, family := range metrics {
+		if family.GetName() != "log_entries_dropped_total" {
+			continue
+		}
+		for _, metric := range family.GetMetric() {
+			labels := map[string]string{}
+			for _, label := range metric.GetLabel() {
+				labels[label.GetName()] = label.GetValue()
+			}
+			counts[labels["sink"]+" "+labels["level"]] = metric.GetCounter().GetValue()
+		}
+	}
+	if counts["file warn"] != 3 || counts["file error"] != 0 {
+		t.Errorf("unexpected dropped entry counts %v", counts)
+	}
+	if _, ok := counts["console warn"]; ok {
+		t.Errorf("unsampled sink is initialized in %v", counts)
+	}
+}
+
+func TestSampling(t *testing.T) {
+	for _, test := range []struct {
+		name       string
+		initial    int
+		thereafter int
+		kept       int
+	}{
+		{"Test initial entries", 2, 0, 2},
+		{"Test thereafter entries", 1, 2, 3},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			config := newTestConfig(t)
+			config.Console.Enabled = false
+			config.Sampling = &loggerfx.SamplingConfig{Initial: test.initial, Thereafter: test.thereafter}
+			registry := prometheus.NewRegistry()
+			var logger *zap.SugaredLogger
+			app := fxtest.New(t,
+				loggerfx.Module,
+				scalpelconfig.ValidationModule,
+				fx.Supply(config, registry),
+				fx.Provide(validator.New),
+				fx.Populate(&logger),
+			)
+			app.RequireStart()
+			for i := 0; i < 5; i++ {
+				logger.Warn("repeated warning")
+				logger.Error("repeated error")
+			}
+			app.RequireStop()
+
+			content, err := os.ReadFile(path.Join(config.File.Path, config.File.Name))
+			if err != nil {
+				t.Fatalf("failed to read log file: %v", err)
+			}
+			for _, message := range []string{"repeated warning", "repeated error"} {
+				if kept := strings.Count(string(content), message); kept != test.kept {
+					t.Errorf("unexpected number of kept entries of %s, got %d, expected %d", message, kept, test.kept)
+				}
+			}
+			metrics, err := registry.Gather()
+			if err != nil {
+				t.Fatalf("failed to gather metrics: %v", err)
+			}
+			counts := map[string]float64{}
+			for _, family := range metrics {
+				if family.GetName() != "log_entries_dropped_total" {
+					continue
+				}
+				for _, metric := range family.GetMetric() {
+					labels := map[string]string{}
+					for _, label := range metric.GetLabel() {
+						labels[label.GetName()] = label.GetValue()
+					}
+					counts[labels["sink"]+" "+labels["level"]] = metric.GetCounter().GetValue()
+				}
+			}
+			dropped := float64(5 - test.kept)
+			if counts["file warn"] != dropped || counts["file error"] != dropped || counts["console warn"] != 0 {
+				t.Errorf("unexpected dropped entry counts %v, expected %.0f warnings and errors", counts, dropped)
+			}
+		})
+	}
+}
+
+func TestFxEventLogger(t *testing.T) {
+	for _, test := range []struct {
+		name          string
+		events        loggerfx.FxEventsConfig
+		expectedLevel zapcore.Level
+	}{
+		{"Test default level", loggerfx.FxEventsConfig{}, zapcore.InfoLevel},
+		{"Test debug level", loggerfx.FxEventsConfig{Level: loggerfx.DebugLevel}, zapcore.DebugLevel},
+		{"Test disabled", loggerfx.FxEventsConfig{Disabled: true}, zapcore.InfoLevel},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			logger, logs := loggerfx.NewObserved(zapcore.DebugLevel)
+			config := newTestConfig(t)
+			config.FxEvents = test.events
+			eventLogger := loggerfx.NewFxEventLogger(logger, config, nil)
+			eventLogger.LogEvent(&fxevent.Provided{ConstructorName: "main.NewServer", OutputTypeNames: []string{"*http.Server"}})
+			eventLogger.LogEvent(&fxevent.Invoked{FunctionName: "main.Run", Err: errors.New("connection refused")})
+
+			if test.events.Disabled {
+				if logs.Len() != 0 {
+					t.Errorf("unexpected events logged while disabled: %v", logs.All())
+				}
+				return
+			}
+			entries := logs.All()
+			if len(entries) != 2 {
+				t.Fatalf("unexpected number of entries, got %d, expected 2", len(entries))
+			}
+			if entries[0].Message != "provided" || entries[0].Level != test.expectedLevel {
+				t.Errorf("unexpected routine event, got %s at %s, expected provided at %s", entries[0].Message, entries[0].Level, test.expectedLevel)
+			}
+			if entries[1].Message != "invoke failed" || entries[1].Level != zapcore.ErrorLevel {
+				t.Errorf("unexpected error event, got %s at %s, expected invoke failed at error", entries[1].Message, entries[1].Level)
+			}
+		})
+	}
+	t.Run("Test timings", func(t *testing.T) {
+		logger, logs := loggerfx.NewObserved(zapcore.DebugLevel)
+		config := newTestConfig(t)
+		config.FxEvents = loggerfx.FxEventsConfig{Disabled: true, Timings: true}
+		registry := prometheus.NewRegistry()
+		eventLogger := loggerfx.NewFxEventLogger(logger, config, registry)
+		eventLogger.LogEvent(&fxevent.Invoking{FunctionName: "main.Run"})
+		eventLogger.LogEvent(&fxevent.Invoked{FunctionName: "main.Run"})
+		eventLogger.LogEvent(&fxevent.OnStartExecuted{FunctionName: "main.Start", CallerName: "main.NewServer", Runtime: 2 * time.Second})
+
+		timings := logs.FilterMessage("fx timing").All()
+		if len(timings) != 2 {
+			t.Fatalf("unexpected number of timings, got %d, expected 2: %v", len(timings), logs.All())
+		}
+		if timings[1].Level != zapcore.DebugLevel || timings[1].ContextMap()["event"] != "on_start" || timings[1].ContextMap()["duration"] != 2*time.Second {
+			t.Errorf("unexpected hook timing, got %v at %s", timings[1].ContextMap(), timings[1].Level)
+		}
+		families, err := registry.Gather()
+		if err != nil {
+			t.Fatalf("failed to gather metrics: %v", err)
+		}
+		if len(families) != 1 || families[0].GetName() != "fx_duration_seconds" || len(families[0].GetMetric()) != 2 {
+			t.Errorf("unexpected metrics of the timings: %v", families)
+		}
+	})
+}
+
+func TestRecentLogs(t *testing.T) {
+	config := newTestConfig(t)
+	config.File.Enabled = false
+	config.Console.Enabled = false
+	config.Redact = []string{"token"}
+	config.Recent = &loggerfx.RecentLogsConfig{Size: 2}
+	recent := loggerfx.NewRecentLogs(config)
+	logger, err := loggerfx.New(config, loggerfx.NewLogLevels(config), recent.Core())
+	if err != nil {
+		t.Fatalf("failed to create logger: %v", err)
+	}
+	logger.Debug("dropped by level")
+	logger.Info("first")
+	logger.With("user", "alice").Infow("second", "token", "secret")
+	logger.Warn("third")
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	controller := loggerfx.NewRecentLogsController(recent, "secret")
+	controller.RegisterControllerRoutes(router.Group(controller.RoutePattern()))
+	get := func(path string, token string) *httptest.ResponseRecorder {
+		request := httptest.NewRequest(http.MethodGet, path, nil)
+		if token != "" {
+			request.Header.Set("Authorization", "Bearer "+token)
+		}
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, request)
+		return recorder
+	}
+
+	t.Run("Test missing token", func(t *testing.T) {
+		if code := get("/logs/recent/", "").Code; code != http.StatusUnauthorized {
+			t.Errorf("unexpected status code, got %d, expected %d", code, http.StatusUnauthorized)
+		}
+	})
+	t.Run("Test most recent entries", func(t *testing.T) {
+		recorder := get("/logs/recent/", "secret")
+		var response loggerfx.RecentLogsResponse
+		if err := json.Unmarshal(recorder.Body.Bytes(), &response); err != nil {
+			t.Fatalf("failed to decode response %s: %v", recorder.Body.String(), err)
+		}
+		if len(response.Entries) != 2 || response.Entries[0].Message != "second" || response.Entries[1].Message != "third" {
+			t.Fatalf("unexpected entries %s", recorder.Body.String())
+		}
+		if fields := response.Entries[0].Fields; fields["user"] != "alice" || fields["token"] != "***" {
+			t.Errorf("unexpected fields, got %v", fields)
+		}
+	})
+	t.Run("Test limit", func(t *testing.T) {
+		recorder := get("/logs/recent/?limit=1", "secret")
+		var response loggerfx.RecentLogsResponse
+		if err := json.Unmarshal(recorder.Body.Bytes(), &response); err != nil {
+			t.Fatalf("failed to decode response %s: %v", recorder.Body.String(), err)
+		}
+		if len(response.Entries) != 1 || response.Entries[0].Message != "third" {
+			t.Errorf("unexpected entries %s", recorder.Body.String())
+		}
+		if code := get("/logs/recent/?limit=x", "secret").Code; code != http.StatusBadRequest {
+			t.Errorf("unexpected status code of invalid limit, got %d, expected %d", code, http.StatusBadRequest)
+		}
+	})
+}
+
+func TestLogRotation(t *testing.T) {
+	config := newTestConfig(t)
+	config.Console.Enabled = false
+	config.ErrorFile = &loggerfx.ErrorFileConfig{}
+	var logger *zap.SugaredLogger
+	var files *loggerfx.LogFiles
+	app := fxtest.New(t,
+		loggerfx.Module,
+		scalpelconfig.ValidationModule,
+		fx.Supply(config),
+		fx.Provide(validator.New),
+		fx.Populate(&logger, &files),
+	)
+	defer app.RequireStart().RequireStop()
+	logger.Warn("before rotation")
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	controller := loggerfx.NewLogRotationController(files, "secret", logger)
+	controller.RegisterControllerRoutes(router.Group(controller.RoutePattern()))
+	post := func(token string) *httptest.ResponseRecorder {
+		request := httptest.NewRequest(http.MethodPost, "/logs/rotate/", nil)
+		if token != "" {
+			request.Header.Set("Authorization", "Bearer "+token)
+		}
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, request)
+		return recorder
+	}
+
+	if code := post("").Code; code != http.StatusUnauthorized {
+		t.Errorf("unexpected status code without token, got %d, expected %d", code, http.StatusUnauthorized)
+	}
+	recorder := post("secret")
+	var response loggerfx.LogRotationResponse
+	if err := json.Unmarshal(recorder.Body.Bytes(), &response); err != nil {
+		t.Fatalf("failed to decode response %s: %v", recorder.Body.String(), err)
+	}
+	expectedFiles := []string{path.Join(config.File.Path, config.File.Name), path.Join(config.File.Path, "errors.log")}
+	if fmt.Sprint(response.Files) != fmt.Sprint(expectedFiles) {
+		t.Errorf("unexpected files, got %v, expected %v", response.Files, expectedFiles)
+	}
+	for _, pattern := range []string{"server-*.log", "errors-*.log"} {
+		if backups, _ := filepath.Glob(path.Join(config.File.Path, pattern)); len(backups) != 1 {
+			t.Errorf("unexpected backups of %s, got %v, expected 1", pattern, backups)
+		}
+	}
+}
+
+func TestWritableCheck(t *testing.T) {
+	config := newTestConfig(t)
+	config.Console.Enabled = false
+	config.WritableCheck = &loggerfx.WritableCheckConfig{Interval: time.Hour}
+	var files *loggerfx.LogFiles
+	var logger *zap.SugaredLogger
+	var checks []infofx.HealthCheck
+	app := fxtest.New(t,
+		loggerfx.Module,
+		scalpelconfig.ValidationModule,
+		fx.Supply(config),
+		fx.Provide(validator.New),
+		// the logger opens the log files
+		fx.Populate(&files, &logger),
+		fx.Invoke(fx.Annotate(func(c []infofx.HealthCheck) { checks = c }, fx.ParamTags(`group:"healthChecks"`))),
+	)
+	defer app.RequireStart().RequireStop()
+	if len(checks) != 1 {
+		t.Fatalf("unexpected checks, got %d, expected 1", len(checks))
+	}
+	if err := checks[0].Check(context.Background()); err != nil {
+		t.Errorf("unexpected error of a writable folder: %v", err)
+	}
+
+	// the folder is removed rather than made read-only, as the tests may run as root
+	check := loggerfx.NewWritableCheck(files, zap.NewNop().Sugar())
+	if err := os.RemoveAll(config.File.Path); err != nil {
+		t.Fatalf("failed to remove log folder: %v", err)
+	}
+	if err := check.Probe(); err == nil {
+		t.Errorf("expected probe to fail without the log folder")
+	}
+	if err := check.Check(context.Background()); err == nil {
+		t.Errorf("expected check to report the failed probe")
+	}
+}
+
+func TestOTLPLogs(t *testing.T) {
+	var mutex sync.Mutex
+	var records []*logspb.LogRecord
+	var authorization string
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		var request collogspb.ExportLogsServiceRequest
+		if r.URL.Path != "/v1/logs" || proto.Unmarshal(body, &request) != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		mutex.Lock()
+		defer mutex.Unlock()
+		authorization = r.Header.Get("Authorization")
+		for _, resourceLogs := range request.ResourceLogs {
+			for _, scopeLogs := range resourceLogs.ScopeLogs {
+				records = append(records, scopeLogs.LogRecords...)
+			}
+		}
+	}))
+	defer receiver.Close()
+
+	config := newTestConfig(t)
+	config.File.Enabled = false
+	config.Redact = []string{"token"}
+	config.OTLP = &loggerfx.OTLPLogsConfig{
+		Endpoint:      strings.TrimPrefix(receiver.URL, "http://"),
+		Insecure:      true,
+		Headers:       map[string]string{"Authorization": "Bearer secret"},
+		Level:         loggerfx.WarnLevel,
+		FlushInterval: time.Hour,
+	}
+	var logger *zap.SugaredLogger
+	app := fxtest.New(t,
+		loggerfx.Module,
+		scalpelconfig.ValidationModule,
+		fx.Supply(config),
+		fx.Provide(validator.New),
+		fx.Populate(&logger),
+	)
+	app.RequireStart()
+	logger.Info("below the level")
+	logger.Warnw("mirrored", "token", "secret", "attempt", 2)
+	logger.Error("failed")
+	// the batches are flushed on stop
+	app.RequireStop()
+
+	mutex.Lock()
+	defer mutex.Unlock()
+	t.Run("Test exported entries", func(t *testing.T) {
+		if len(records) != 2 || records[0].Body.GetStringValue() != "mirrored" || records[1].Body.GetStringValue() != "failed" {
+			t.Fatalf("unexpected records %v", records)
+		}
+		if records[1].SeverityNumber != logspb.SeverityNumber_SEVERITY_NUMBER_ERROR {
+			t.Errorf("unexpected severity, got %v", records[1].SeverityNumber)
+		}
+		attributes := make(map[string]*commonpb.AnyValue)
+		for _, attribute := range records[0].Attributes {
+			attributes[attribute.Key] = attribute.Value
+		}
+		if attributes["token"].GetStringValue() != "***" || attributes["attempt"].GetIntValue() != 2 {
+			t.Errorf("unexpected attributes, got %v", records[0].Attributes)
+		}
+	})
+	t.Run("Test headers", func(t *testing.T) {
+		if authorization != "Bearer secret" {
+			t.Errorf("unexpected authorization header, got %q", authorization)
+		}
+	})
+}
+
+func TestOTLPLogsDisabled(t *testing.T) {
+	if logs := loggerfx.NewOTLPLogs(newTestConfig(t)); logs != nil {
+		t.Errorf("expected no exporter without the otlp block")
+	}
+}
@@ -0,0 +1,120 @@
+package loggerfx
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+	"go.uber.org/zap"
+
+	"github.com/prismedic/scalpel/metricsfx"
+	"github.com/prismedic/scalpel/routerfx"
+)
+
+type LogLevelController struct {
+	levels   *LogLevels
+	validate *validator.Validate
+	// token protects the route changing the levels, which is not registered without it
+	token string
+}
+
+type LogLevelResponse struct {
+	Console LogLevel `json:"console"`
+	File    LogLevel `json:"file"`
+}
+
+type LogLevelRequest struct {
+	Console LogLevel `json:"console" validate:"omitempty,loglevel"`
+	File    LogLevel `json:"file" validate:"omitempty,loglevel"`
+}
+
+func NewLogLevelController(levels *LogLevels, validate *validator.Validate, token string) *LogLevelController {
+	return &LogLevelController{
+		levels:   levels,
+		validate: validate,
+		token:    token,
+	}
+}
+
+// newLogLevelController registers the route changing the levels with the metrics token, like the other admin routes,
+// the metrics config is optional
+func newLogLevelController(levels *LogLevels, validate *validator.Validate, metricsConfig *metricsfx.MetricsConfig, logger *zap.SugaredLogger) *LogLevelController {
+	var token string
+	if metricsConfig != nil {
+		token = metricsConfig.Auth.Token
+	}
+	if token == "" {
+		logger.Warn("Log level change route is not registered, metrics.auth.token is required to protect it")
+	}
+	return NewLogLevelController(levels, validate, token)
+}
+
+func (lc *LogLevelController) currentLevels() *LogLevelResponse {
+	return &LogLevelResponse{
+		Console: lc.levels.ConsoleLevel(),
+		File:    lc.levels.FileLevel(),
+	}
+}
+
+// getLogLevel godoc
+//
+//	@Summary		Get log levels
+//	@Description	Get the current log levels of the console and file outputs
+//	@Produce		json
+//	@Success		200	{object}	LogLevelResponse
+//	@Router			/loglevel [get]
+func (lc *LogLevelController) getLogLevel(c *gin.Context) {
+	c.JSON(http.StatusOK, lc.currentLevels())
+}
+
+// putLogLevel godoc
+//
+//	@Summary		Set log levels
+//	@Description	Change the log levels of the console and file outputs, omitted outputs are left unchanged
+//	@Accept			json
+//	@Produce		json
+//	@Param			levels	body		LogLevelRequest	true	"New log levels"
+//	@Success		200		{object}	LogLevelResponse
+//	@Failure		400		{object}	routerfx.ErrorResponse
+//	@Failure		401
+//	@Router			/loglevel [put]
+func (lc *LogLevelController) putLogLevel(c *gin.Context) {
+	var request LogLevelRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		routerfx.AbortWithStatusError(c, http.StatusBadRequest, routerfx.CodeBadRequest, err.Error())
+		return
+	}
+	// validate both levels before applying any, so a bad request changes nothing
+	if err := lc.validate.Struct(&request); err != nil {
+		routerfx.AbortWithStatusError(c, http.StatusBadRequest, routerfx.CodeBadRequest, err.Error())
+		return
+	}
+	if request.Console != "" {
+		lc.levels.SetConsoleLevel(request.Console)
+	}
+	if request.File != "" {
+		lc.levels.SetFileLevel(request.File)
+	}
+	c.JSON(http.StatusOK, lc.currentLevels())
+}
+
+func (lc *LogLevelController) RegisterControllerRoutes(rg *gin.RouterGroup) {
+	rg.GET("/", lc.getLogLevel)
+	if lc.token != "" {
+		rg.PUT("/", metricsfx.WithBearerToken(lc.token, lc.putLogLevel))
+	}
+}
+
+func (lc *LogLevelController) RouteDocs() []routerfx.RouteDoc {
+	docs := []routerfx.RouteDoc{
+		{Method: http.MethodGet, Path: "/", Summary: "Get log levels", Response: LogLevelResponse{}},
+	}
+	if lc.token != "" {
+		docs = append(docs, routerfx.RouteDoc{Method: http.MethodPut, Path: "/", Summary: "Set log levels", Request: LogLevelRequest{}, Response: LogLevelResponse{}})
+	}
+	return docs
+}
+
+func (lc *LogLevelController) RoutePattern() string {
+	return "/loglevel"
+}
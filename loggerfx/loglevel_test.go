@@ -0,0 +1,91 @@
+package loggerfx_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+
+	"github.com/prismedic/scalpel/loggerfx"
+)
+
+func TestLogLevelController(t *testing.T) {
+	newRouter := func(t *testing.T, token string) (*gin.Engine, *loggerfx.LogLevels) {
+		config := newTestConfig(t)
+		levels := loggerfx.NewLogLevels(config)
+		validate, err := loggerfx.RegisterLogLevelValidation(validator.New())
+		if err != nil {
+			t.Fatalf("failed to register validations: %v", err)
+		}
+		controller := loggerfx.NewLogLevelController(levels, validate, token)
+		gin.SetMode(gin.TestMode)
+		router := gin.New()
+		controller.RegisterControllerRoutes(router.Group(controller.RoutePattern()))
+		return router, levels
+	}
+	putLevels := func(router *gin.Engine, body string, token string) *httptest.ResponseRecorder {
+		request := httptest.NewRequest(http.MethodPut, "/loglevel/", strings.NewReader(body))
+		request.Header.Set("Content-Type", "application/json")
+		if token != "" {
+			request.Header.Set("Authorization", "Bearer "+token)
+		}
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, request)
+		return recorder
+	}
+
+	t.Run("Test get levels", func(t *testing.T) {
+		router, _ := newRouter(t, "secret")
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/loglevel/", nil))
+		var response loggerfx.LogLevelResponse
+		if err := json.Unmarshal(recorder.Body.Bytes(), &response); err != nil {
+			t.Fatalf("failed to parse response %s: %v", recorder.Body.String(), err)
+		}
+		if recorder.Code != http.StatusOK || response.File != loggerfx.InfoLevel || response.Console != loggerfx.InfoLevel {
+			t.Errorf("unexpected response, got %d %+v", recorder.Code, response)
+		}
+	})
+	t.Run("Test valid levels", func(t *testing.T) {
+		router, levels := newRouter(t, "secret")
+		recorder := putLevels(router, `{"file":"debug"}`, "secret")
+		if recorder.Code != http.StatusOK {
+			t.Fatalf("unexpected status code, got %d, expected %d", recorder.Code, http.StatusOK)
+		}
+		if levels.FileLevel() != loggerfx.DebugLevel || levels.ConsoleLevel() != loggerfx.InfoLevel {
+			t.Errorf("unexpected levels, got file %s and console %s", levels.FileLevel(), levels.ConsoleLevel())
+		}
+	})
+	t.Run("Test invalid levels", func(t *testing.T) {
+		router, levels := newRouter(t, "secret")
+		for _, body := range []string{`{"console":"debug","file":"verbose"}`, `{"file":`} {
+			if recorder := putLevels(router, body, "secret"); recorder.Code != http.StatusBadRequest {
+				t.Errorf("unexpected status code of %s, got %d, expected %d", body, recorder.Code, http.StatusBadRequest)
+			}
+		}
+		if levels.FileLevel() != loggerfx.InfoLevel || levels.ConsoleLevel() != loggerfx.InfoLevel {
+			t.Errorf("levels changed by an invalid request, got file %s and console %s", levels.FileLevel(), levels.ConsoleLevel())
+		}
+	})
+	t.Run("Test missing token", func(t *testing.T) {
+		router, levels := newRouter(t, "secret")
+		for _, token := range []string{"", "wrong"} {
+			if recorder := putLevels(router, `{"file":"debug"}`, token); recorder.Code != http.StatusUnauthorized {
+				t.Errorf("unexpected status code with token %q, got %d, expected %d", token, recorder.Code, http.StatusUnauthorized)
+			}
+		}
+		if levels.FileLevel() != loggerfx.InfoLevel {
+			t.Errorf("level changed without the token, got %s", levels.FileLevel())
+		}
+	})
+	t.Run("Test disabled without token", func(t *testing.T) {
+		router, _ := newRouter(t, "")
+		if recorder := putLevels(router, `{"file":"debug"}`, ""); recorder.Code == http.StatusOK {
+			t.Errorf("unexpected status code without a configured token, got %d", recorder.Code)
+		}
+	})
+}
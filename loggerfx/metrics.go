@@ -0,0 +1,54 @@
+package loggerfx
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+
+	"github.com/prismedic/scalpel/metricsfx"
+)
+
+// countEntries returns an option counting the entries by level in log_messages_total, prefixed like the built-in metrics.
+// The count is registered by the hook of the core, so entries dropped by the levels or the sampling of every output are not counted.
+func countEntries(registerer prometheus.Registerer) (zap.Option, error) {
+	messages, err := metricsfx.RegisterOrExisting(registerer, prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "log_messages_total",
+		Help: "Total number of log entries by level.",
+	}, []string{"level"}))
+	if err != nil {
+		return nil, err
+	}
+	// initialized to 0, so the rate of a level is known before its first entry
+	for _, level := range logLevelMap {
+		messages.WithLabelValues(level.String())
+	}
+	return zap.Hooks(func(entry zapcore.Entry) error {
+		messages.WithLabelValues(entry.Level.String()).Inc()
+		return nil
+	}), nil
+}
+
+// sampledSinks are the sinks of the built-in outputs that can be sampled
+var sampledSinks = []string{FileSink, ConsoleSink, SyslogSink}
+
+// countDropped registers log_entries_dropped_total, the entries dropped by the sampling of each sink by level,
+// prefixed like log_messages_total.
+// Each output has its own sampler, so an entry dropped by two outputs counts twice, e.g. by the file and the error file.
+func countDropped(registerer prometheus.Registerer, config *SamplingConfig) (*prometheus.CounterVec, error) {
+	dropped, err := metricsfx.RegisterOrExisting(registerer, prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "log_entries_dropped_total",
+		Help: "Total number of log entries dropped by the sampling by level and sink.",
+	}, []string{"level", "sink"}))
+	if err != nil {
+		return nil, err
+	}
+	for _, sink := range sampledSinks {
+		if !config.appliesTo(sink) {
+			continue
+		}
+		for _, level := range logLevelMap {
+			dropped.WithLabelValues(level.String(), sink)
+		}
+	}
+	return dropped, nil
+}
@@ -0,0 +1,77 @@
+package loggerfx
+
+import (
+	"strings"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// namedLevelOf returns the level of the logger name, or of its closest parent for the names of nested loggers,
+// e.g. "db" for "db.pool". It is false when the name inherits the level of the outputs.
+func namedLevelOf(levels map[string]zap.AtomicLevel, name string) (zap.AtomicLevel, bool) {
+	for name != "" {
+		if level, ok := levels[name]; ok {
+			return level, true
+		}
+		index := strings.LastIndexByte(name, '.')
+		if index < 0 {
+			break
+		}
+		name = name[:index]
+	}
+	return zap.AtomicLevel{}, false
+}
+
+// anyLevel enables a level enabled by the level of the output or by any of the named levels,
+// so that the entries of a named logger below the level of the output reach the namedLevelCore
+type anyLevel struct {
+	base  zapcore.LevelEnabler
+	named map[string]zap.AtomicLevel
+}
+
+func (l *anyLevel) Enabled(level zapcore.Level) bool {
+	if l.base.Enabled(level) {
+		return true
+	}
+	for _, named := range l.named {
+		if named.Enabled(level) {
+			return true
+		}
+	}
+	return false
+}
+
+// namedLevelCore checks the entries against the level of their logger name, or the level of the output for the other names.
+// The wrapped core is built with the anyLevel of the output, which still applies the max level of the output.
+type namedLevelCore struct {
+	zapcore.Core
+	base  zapcore.LevelEnabler
+	named map[string]zap.AtomicLevel
+}
+
+// withNamedLevels returns the enabler to build the core of an output with and the function wrapping the built core,
+// the enabler and the core are kept as they are without named levels
+func withNamedLevels(base zapcore.LevelEnabler, named map[string]zap.AtomicLevel) (zapcore.LevelEnabler, func(zapcore.Core) zapcore.Core) {
+	if len(named) == 0 {
+		return base, func(core zapcore.Core) zapcore.Core { return core }
+	}
+	return &anyLevel{base: base, named: named}, func(core zapcore.Core) zapcore.Core {
+		return &namedLevelCore{Core: core, base: base, named: named}
+	}
+}
+
+func (c *namedLevelCore) With(fields []zapcore.Field) zapcore.Core {
+	return &namedLevelCore{Core: c.Core.With(fields), base: c.base, named: c.named}
+}
+
+func (c *namedLevelCore) Check(entry zapcore.Entry, checked *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	enabler := c.base
+	if level, ok := namedLevelOf(c.named, entry.LoggerName); ok {
+		enabler = level
+	}
+	if !enabler.Enabled(entry.Level) {
+		return checked
+	}
+	return c.Core.Check(entry, checked)
+}
@@ -0,0 +1,411 @@
+package loggerfx
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel/trace"
+	collogspb "go.opentelemetry.io/proto/otlp/collector/logs/v1"
+	commonpb "go.opentelemetry.io/proto/otlp/common/v1"
+	logspb "go.opentelemetry.io/proto/otlp/logs/v1"
+	resourcepb "go.opentelemetry.io/proto/otlp/resource/v1"
+	"go.uber.org/fx"
+	"go.uber.org/zap/zapcore"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/prismedic/scalpel/config"
+)
+
+// OTLPLogsConfig mirrors the entries to an OTLP logs receiver, like the spans of tracingfx
+type OTLPLogsConfig struct {
+	// Endpoint is the host:port of the OTLP HTTP receiver, the entries are sent to /v1/logs
+	Endpoint string `mapstructure:"endpoint" yaml:"endpoint" validate:"required,hostname_port"`
+	Insecure bool   `mapstructure:"insecure" yaml:"insecure"`
+	// Headers are added to the export requests, e.g. the API key of the backend
+	Headers map[string]string `mapstructure:"headers" yaml:"headers" validate:"dive,keys,required,endkeys"`
+	// Level is the lowest level exported, it defaults to info
+	Level LogLevel `mapstructure:"level" yaml:"level" validate:"omitempty,loglevel"`
+	// BatchSize is the number of entries of an export request, 512 when zero
+	BatchSize int `mapstructure:"batch_size" yaml:"batch_size" validate:"min=0"`
+	// FlushInterval is the longest time an entry waits for its batch, 5s when zero
+	FlushInterval time.Duration `mapstructure:"flush_interval" yaml:"flush_interval" validate:"min=0"`
+}
+
+// defaults of the optional OTLP settings, the queue holds a few batches while an export is slow
+const (
+	defaultOTLPLevel         = "info"
+	defaultOTLPBatchSize     = 512
+	defaultOTLPFlushInterval = 5 * time.Second
+	otlpQueueBatches         = 4
+	otlpExportTimeout        = 10 * time.Second
+	otlpScopeName            = "github.com/prismedic/scalpel/loggerfx"
+)
+
+// OTLPLogs batches the entries of its core and exports them to the receiver over OTLP/HTTP with protobuf.
+// Entries are dropped while the queue is full, so a slow receiver does not block the logger.
+type OTLPLogs struct {
+	config   *OTLPLogsConfig
+	url      string
+	client   *http.Client
+	resource *resourcepb.Resource
+	level    zapcore.Level
+
+	mutex   sync.Mutex
+	queue   []*logspb.LogRecord
+	dropped int
+	failing bool
+
+	// exportMutex keeps the exports in the order of the entries
+	exportMutex sync.Mutex
+	flush       chan struct{}
+	stop        chan struct{}
+	done        chan struct{}
+}
+
+// NewOTLPLogs returns the exporter of the entries, nil when the otlp block of the config is absent.
+// The batches are exported in the background until Shutdown.
+func NewOTLPLogs(loggerConfig *LoggerConfig) *OTLPLogs {
+	otlpConfig := loggerConfig.OTLP
+	if otlpConfig == nil || otlpConfig.Endpoint == "" {
+		return nil
+	}
+	scheme := "https"
+	if otlpConfig.Insecure {
+		scheme = "http"
+	}
+	serviceName := loggerConfig.ServiceName
+	if serviceName == "" {
+		serviceName = config.GetPackageName()
+	}
+	level := logLevelMap[LogLevel(defaultOTLPLevel)]
+	if otlpConfig.Level != "" {
+		level = logLevelMap[otlpConfig.Level]
+	}
+	l := &OTLPLogs{
+		config: otlpConfig,
+		url:    fmt.Sprintf("%s://%s/v1/logs", scheme, otlpConfig.Endpoint),
+		client: &http.Client{Timeout: otlpExportTimeout},
+		resource: &resourcepb.Resource{
+			Attributes: []*commonpb.KeyValue{{Key: "service.name", Value: stringValue(serviceName)}},
+		},
+		level: level,
+		flush: make(chan struct{}, 1),
+		stop:  make(chan struct{}),
+		done:  make(chan struct{}),
+	}
+	go l.run()
+	return l
+}
+
+func (l *OTLPLogs) batchSize() int {
+	if l.config.BatchSize > 0 {
+		return l.config.BatchSize
+	}
+	return defaultOTLPBatchSize
+}
+
+func (l *OTLPLogs) run() {
+	defer close(l.done)
+	interval := l.config.FlushInterval
+	if interval <= 0 {
+		interval = defaultOTLPFlushInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+		case <-l.flush:
+		case <-l.stop:
+			return
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), otlpExportTimeout)
+		_ = l.Flush(ctx)
+		cancel()
+	}
+}
+
+func (l *OTLPLogs) add(record *logspb.LogRecord) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	batchSize := l.batchSize()
+	if len(l.queue) >= batchSize*otlpQueueBatches {
+		l.dropped++
+		return
+	}
+	l.queue = append(l.queue, record)
+	if len(l.queue) >= batchSize {
+		select {
+		case l.flush <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// Flush exports the queued entries in batches
+func (l *OTLPLogs) Flush(ctx context.Context) error {
+	l.exportMutex.Lock()
+	defer l.exportMutex.Unlock()
+	for {
+		l.mutex.Lock()
+		n := len(l.queue)
+		if n > l.batchSize() {
+			n = l.batchSize()
+		}
+		batch := l.queue[:n:n]
+		l.queue = l.queue[n:]
+		dropped := l.dropped
+		l.dropped = 0
+		l.mutex.Unlock()
+		if dropped > 0 {
+			fmt.Fprintf(os.Stderr, "OTLP log queue is full, %d entries are dropped\n", dropped)
+		}
+		if len(batch) == 0 {
+			return nil
+		}
+		if err := l.report(l.export(ctx, batch)); err != nil {
+			return err
+		}
+	}
+}
+
+// report writes the state changes of the exports to stderr, the entries of the logger would be queued for the failing receiver
+func (l *OTLPLogs) report(err error) error {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	if err != nil && !l.failing {
+		fmt.Fprintf(os.Stderr, "OTLP log export failed, the batches are dropped until %s accepts them: %v\n", l.url, err)
+	} else if err == nil && l.failing {
+		fmt.Fprintf(os.Stderr, "OTLP log export to %s is resumed\n", l.url)
+	}
+	l.failing = err != nil
+	return err
+}
+
+func (l *OTLPLogs) export(ctx context.Context, records []*logspb.LogRecord) error {
+	body, err := proto.Marshal(&collogspb.ExportLogsServiceRequest{
+		ResourceLogs: []*logspb.ResourceLogs{{
+			Resource: l.resource,
+			ScopeLogs: []*logspb.ScopeLogs{{
+				Scope:      &commonpb.InstrumentationScope{Name: otlpScopeName},
+				LogRecords: records,
+			}},
+		}},
+	})
+	if err != nil {
+		return fmt.Errorf("error in encoding OTLP logs: %w", err)
+	}
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, l.url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("error in exporting OTLP logs: %w", err)
+	}
+	request.Header.Set("Content-Type", "application/x-protobuf")
+	for key, value := range l.config.Headers {
+		request.Header.Set(key, value)
+	}
+	response, err := l.client.Do(request)
+	if err != nil {
+		return fmt.Errorf("error in exporting OTLP logs: %w", err)
+	}
+	defer response.Body.Close()
+	// the body is drained so the connection is reused
+	_, _ = io.Copy(io.Discard, response.Body)
+	if response.StatusCode < 200 || response.StatusCode >= 300 {
+		return fmt.Errorf("error in exporting OTLP logs: receiver answered %s", response.Status)
+	}
+	return nil
+}
+
+// Shutdown stops the background exports and exports the remaining entries
+func (l *OTLPLogs) Shutdown(ctx context.Context) error {
+	select {
+	case <-l.stop:
+	default:
+		close(l.stop)
+	}
+	<-l.done
+	return l.Flush(ctx)
+}
+
+// Core returns the core queueing the entries for the receiver
+func (l *OTLPLogs) Core() zapcore.Core {
+	return &otlpCore{LevelEnabler: l.level, logs: l}
+}
+
+// newOTLPLogs shuts the exporter down on stop. It is constructed before the logger,
+// so its OnStop hook runs after the one of the logger and the shutdown messages are exported.
+func newOTLPLogs(lifecycle fx.Lifecycle, config *LoggerConfig) *OTLPLogs {
+	logs := NewOTLPLogs(config)
+	if logs == nil {
+		return nil
+	}
+	lifecycle.Append(fx.Hook{OnStop: logs.Shutdown})
+	return logs
+}
+
+type otlpCore struct {
+	zapcore.LevelEnabler
+	logs   *OTLPLogs
+	fields []zapcore.Field
+}
+
+func (c *otlpCore) With(fields []zapcore.Field) zapcore.Core {
+	return &otlpCore{
+		LevelEnabler: c.LevelEnabler,
+		logs:         c.logs,
+		fields:       append(append([]zapcore.Field{}, c.fields...), fields...),
+	}
+}
+
+func (c *otlpCore) Check(entry zapcore.Entry, checked *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(entry.Level) {
+		return checked.AddCore(entry, c)
+	}
+	return checked
+}
+
+func (c *otlpCore) Write(entry zapcore.Entry, fields []zapcore.Field) error {
+	encoder := zapcore.NewMapObjectEncoder()
+	for _, field := range c.fields {
+		field.AddTo(encoder)
+	}
+	for _, field := range fields {
+		field.AddTo(encoder)
+	}
+	record := &logspb.LogRecord{
+		TimeUnixNano:         uint64(entry.Time.UnixNano()),
+		ObservedTimeUnixNano: uint64(time.Now().UnixNano()),
+		SeverityNumber:       otlpSeverity(entry.Level),
+		SeverityText:         entry.Level.CapitalString(),
+		Body:                 stringValue(entry.Message),
+	}
+	// the IDs of loggers from WithContext link the entry to its span
+	if traceID, ok := encoder.Fields["trace_id"].(string); ok {
+		if id, err := trace.TraceIDFromHex(traceID); err == nil {
+			record.TraceId = id[:]
+			delete(encoder.Fields, "trace_id")
+		}
+	}
+	if spanID, ok := encoder.Fields["span_id"].(string); ok {
+		if id, err := trace.SpanIDFromHex(spanID); err == nil {
+			record.SpanId = id[:]
+			delete(encoder.Fields, "span_id")
+		}
+	}
+	if entry.LoggerName != "" {
+		record.Attributes = append(record.Attributes, &commonpb.KeyValue{Key: "logger", Value: stringValue(entry.LoggerName)})
+	}
+	if entry.Caller.Defined {
+		record.Attributes = append(record.Attributes, &commonpb.KeyValue{Key: "caller", Value: stringValue(entry.Caller.TrimmedPath())})
+	}
+	if entry.Stack != "" {
+		record.Attributes = append(record.Attributes, &commonpb.KeyValue{Key: "stacktrace", Value: stringValue(entry.Stack)})
+	}
+	record.Attributes = append(record.Attributes, otlpAttributes(encoder.Fields)...)
+	c.logs.add(record)
+	return nil
+}
+
+// Sync exports the queued entries, e.g. before a fatal entry exits the program
+func (c *otlpCore) Sync() error {
+	ctx, cancel := context.WithTimeout(context.Background(), otlpExportTimeout)
+	defer cancel()
+	return c.logs.Flush(ctx)
+}
+
+func otlpSeverity(level zapcore.Level) logspb.SeverityNumber {
+	switch level {
+	case zapcore.DebugLevel:
+		return logspb.SeverityNumber_SEVERITY_NUMBER_DEBUG
+	case zapcore.InfoLevel:
+		return logspb.SeverityNumber_SEVERITY_NUMBER_INFO
+	case zapcore.WarnLevel:
+		return logspb.SeverityNumber_SEVERITY_NUMBER_WARN
+	case zapcore.ErrorLevel:
+		return logspb.SeverityNumber_SEVERITY_NUMBER_ERROR
+	case zapcore.DPanicLevel:
+		return logspb.SeverityNumber_SEVERITY_NUMBER_ERROR2
+	case zapcore.PanicLevel:
+		return logspb.SeverityNumber_SEVERITY_NUMBER_ERROR3
+	case zapcore.FatalLevel:
+		return logspb.SeverityNumber_SEVERITY_NUMBER_FATAL
+	default:
+		return logspb.SeverityNumber_SEVERITY_NUMBER_UNSPECIFIED
+	}
+}
+
+// otlpAttributes converts the fields encoded by zapcore.MapObjectEncoder, sorted by key so the records are stable
+func otlpAttributes(fields map[string]any) []*commonpb.KeyValue {
+	keys := make([]string, 0, len(fields))
+	for key := range fields {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	attributes := make([]*commonpb.KeyValue, 0, len(keys))
+	for _, key := range keys {
+		attributes = append(attributes, &commonpb.KeyValue{Key: key, Value: otlpValue(fields[key])})
+	}
+	return attributes
+}
+
+func otlpValue(value any) *commonpb.AnyValue {
+	switch v := value.(type) {
+	case string:
+		return stringValue(v)
+	case bool:
+		return &commonpb.AnyValue{Value: &commonpb.AnyValue_BoolValue{BoolValue: v}}
+	case int:
+		return intValue(int64(v))
+	case int8:
+		return intValue(int64(v))
+	case int16:
+		return intValue(int64(v))
+	case int32:
+		return intValue(int64(v))
+	case int64:
+		return intValue(v)
+	case uint:
+		return intValue(int64(v))
+	case uint8:
+		return intValue(int64(v))
+	case uint16:
+		return intValue(int64(v))
+	case uint32:
+		return intValue(int64(v))
+	case uint64:
+		return intValue(int64(v))
+	case float32:
+		return &commonpb.AnyValue{Value: &commonpb.AnyValue_DoubleValue{DoubleValue: float64(v)}}
+	case float64:
+		return &commonpb.AnyValue{Value: &commonpb.AnyValue_DoubleValue{DoubleValue: v}}
+	case []byte:
+		return &commonpb.AnyValue{Value: &commonpb.AnyValue_BytesValue{BytesValue: v}}
+	case time.Time:
+		return stringValue(v.Format(time.RFC3339Nano))
+	case []any:
+		values := make([]*commonpb.AnyValue, 0, len(v))
+		for _, item := range v {
+			values = append(values, otlpValue(item))
+		}
+		return &commonpb.AnyValue{Value: &commonpb.AnyValue_ArrayValue{ArrayValue: &commonpb.ArrayValue{Values: values}}}
+	case map[string]any:
+		return &commonpb.AnyValue{Value: &commonpb.AnyValue_KvlistValue{KvlistValue: &commonpb.KeyValueList{Values: otlpAttributes(v)}}}
+	default:
+		return stringValue(fmt.Sprint(v))
+	}
+}
+
+func stringValue(value string) *commonpb.AnyValue {
+	return &commonpb.AnyValue{Value: &commonpb.AnyValue_StringValue{StringValue: value}}
+}
+
+func intValue(value int64) *commonpb.AnyValue {
+	return &commonpb.AnyValue{Value: &commonpb.AnyValue_IntValue{IntValue: value}}
+}
@@ -0,0 +1,196 @@
+package loggerfx
+
+import (
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+
+	"github.com/prismedic/scalpel/metricsfx"
+	"github.com/prismedic/scalpel/routerfx"
+)
+
+// RecentLogsConfig configures the in-memory buffer of the most recent entries served at /v1/logs/recent
+type RecentLogsConfig struct {
+	// Size is the number of entries kept, the oldest entries are dropped first
+	Size int `mapstructure:"size" yaml:"size" validate:"min=1"`
+	// Level is the lowest level kept in the buffer, it defaults to info
+	Level LogLevel `mapstructure:"level" yaml:"level" validate:"omitempty,loglevel"`
+}
+
+type RecentEntry struct {
+	Time    time.Time      `json:"time"`
+	Level   string         `json:"level"`
+	Logger  string         `json:"logger,omitempty"`
+	Caller  string         `json:"caller,omitempty"`
+	Message string         `json:"message"`
+	Fields  map[string]any `json:"fields,omitempty"`
+}
+
+// RecentLogs is a ring buffer of the most recent entries of the logger
+type RecentLogs struct {
+	mutex   sync.Mutex
+	entries []RecentEntry
+	next    int
+	full    bool
+	level   zapcore.Level
+}
+
+// NewRecentLogs returns the buffer of the recent entries, nil when the recent block of the config is absent.
+// An invalid size also returns nil, it is reported by the validation of the config.
+func NewRecentLogs(config *LoggerConfig) *RecentLogs {
+	if config.Recent == nil || config.Recent.Size < 1 {
+		return nil
+	}
+	level := zapcore.InfoLevel
+	if config.Recent.Level != "" {
+		level = logLevelMap[config.Recent.Level]
+	}
+	return &RecentLogs{entries: make([]RecentEntry, config.Recent.Size), level: level}
+}
+
+func (r *RecentLogs) add(entry RecentEntry) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.entries[r.next] = entry
+	r.next = (r.next + 1) % len(r.entries)
+	if r.next == 0 {
+		r.full = true
+	}
+}
+
+// Entries returns up to limit of the most recent entries from the oldest to the newest, all of them when limit is not positive
+func (r *RecentLogs) Entries(limit int) []RecentEntry {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	entries := make([]RecentEntry, 0, len(r.entries))
+	if r.full {
+		entries = append(entries, r.entries[r.next:]...)
+	}
+	entries = append(entries, r.entries[:r.next]...)
+	if limit > 0 && limit < len(entries) {
+		entries = entries[len(entries)-limit:]
+	}
+	return entries
+}
+
+// Core returns the core adding the entries to the buffer
+func (r *RecentLogs) Core() zapcore.Core {
+	return &recentCore{LevelEnabler: r.level, logs: r}
+}
+
+type recentCore struct {
+	zapcore.LevelEnabler
+	logs   *RecentLogs
+	fields []zapcore.Field
+}
+
+func (c *recentCore) With(fields []zapcore.Field) zapcore.Core {
+	return &recentCore{
+		LevelEnabler: c.LevelEnabler,
+		logs:         c.logs,
+		fields:       append(append([]zapcore.Field{}, c.fields...), fields...),
+	}
+}
+
+func (c *recentCore) Check(entry zapcore.Entry, checked *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(entry.Level) {
+		return checked.AddCore(entry, c)
+	}
+	return checked
+}
+
+func (c *recentCore) Write(entry zapcore.Entry, fields []zapcore.Field) error {
+	encoder := zapcore.NewMapObjectEncoder()
+	for _, field := range c.fields {
+		field.AddTo(encoder)
+	}
+	for _, field := range fields {
+		field.AddTo(encoder)
+	}
+	recent := RecentEntry{
+		Time:    entry.Time,
+		Level:   entry.Level.String(),
+		Logger:  entry.LoggerName,
+		Message: entry.Message,
+	}
+	if entry.Caller.Defined {
+		recent.Caller = entry.Caller.TrimmedPath()
+	}
+	if len(encoder.Fields) > 0 {
+		recent.Fields = encoder.Fields
+	}
+	c.logs.add(recent)
+	return nil
+}
+
+func (c *recentCore) Sync() error {
+	return nil
+}
+
+// newRecentLogsRoutes registers the recent logs controller if the buffer is enabled and the metrics have a token,
+// the metrics config is optional
+func newRecentLogsRoutes(recent *RecentLogs, metricsConfig *metricsfx.MetricsConfig, logger *zap.SugaredLogger) []routerfx.ControllerRoute {
+	if recent == nil {
+		return nil
+	}
+	if metricsConfig == nil || metricsConfig.Auth.Token == "" {
+		logger.Warn("Recent logs route is not registered, metrics.auth.token is required to protect it")
+		return nil
+	}
+	return []routerfx.ControllerRoute{NewRecentLogsController(recent, metricsConfig.Auth.Token)}
+}
+
+type RecentLogsController struct {
+	recent *RecentLogs
+	token  string
+}
+
+type RecentLogsResponse struct {
+	Entries []RecentEntry `json:"entries"`
+}
+
+func NewRecentLogsController(recent *RecentLogs, token string) *RecentLogsController {
+	return &RecentLogsController{recent: recent, token: token}
+}
+
+// getRecentLogs godoc
+//
+//	@Summary		Get recent logs
+//	@Description	Get the most recent log entries kept in memory, from the oldest to the newest
+//	@Produce		json
+//	@Param			limit	query		int	false	"Maximum number of entries"
+//	@Success		200		{object}	RecentLogsResponse
+//	@Failure		400		{object}	routerfx.ErrorResponse
+//	@Failure		401
+//	@Router			/logs/recent [get]
+func (rc *RecentLogsController) getRecentLogs(c *gin.Context) {
+	limit := 0
+	if value := c.Query("limit"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 0 {
+			routerfx.AbortWithStatusError(c, http.StatusBadRequest, routerfx.CodeBadRequest, "limit must be a positive integer")
+			return
+		}
+		limit = parsed
+	}
+	c.JSON(http.StatusOK, &RecentLogsResponse{Entries: rc.recent.Entries(limit)})
+}
+
+func (rc *RecentLogsController) RegisterControllerRoutes(rg *gin.RouterGroup) {
+	rg.GET("/", metricsfx.WithBearerToken(rc.token, rc.getRecentLogs))
+}
+
+func (rc *RecentLogsController) RouteDocs() []routerfx.RouteDoc {
+	return []routerfx.RouteDoc{
+		{Method: http.MethodGet, Path: "/", Summary: "Get the most recent log entries", Response: RecentLogsResponse{}},
+	}
+}
+
+func (rc *RecentLogsController) RoutePattern() string {
+	return "/logs/recent"
+}
@@ -0,0 +1,79 @@
+package loggerfx
+
+import (
+	"strings"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+const redactedValue = "***"
+
+// redactingCore replaces the values of the configured field keys before the fields reach the wrapped core.
+// Check of the wrapped core decides which cores write the entry, e.g. the custom cores filtering their entries.
+type redactingCore struct {
+	zapcore.Core
+	keys map[string]struct{}
+}
+
+// redactCore wraps the core when there are keys to redact, keys are matched case-insensitively
+func redactCore(keys []string, core zapcore.Core) zapcore.Core {
+	if len(keys) == 0 {
+		return core
+	}
+	keySet := make(map[string]struct{}, len(keys))
+	for _, key := range keys {
+		keySet[strings.ToLower(key)] = struct{}{}
+	}
+	return &redactingCore{Core: core, keys: keySet}
+}
+
+func (c *redactingCore) redact(fields []zapcore.Field) []zapcore.Field {
+	var redacted []zapcore.Field
+	for i, field := range fields {
+		if _, ok := c.keys[strings.ToLower(field.Key)]; !ok {
+			continue
+		}
+		// copy the fields before the first change, the caller may reuse the slice
+		if redacted == nil {
+			redacted = append([]zapcore.Field{}, fields...)
+		}
+		redacted[i] = zap.String(field.Key, redactedValue)
+	}
+	if redacted == nil {
+		return fields
+	}
+	return redacted
+}
+
+func (c *redactingCore) With(fields []zapcore.Field) zapcore.Core {
+	return &redactingCore{Core: c.Core.With(c.redact(fields)), keys: c.keys}
+}
+
+func (c *redactingCore) Check(entry zapcore.Entry, checked *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	wrapped := c.Core.Check(entry, nil)
+	if wrapped == nil {
+		return checked
+	}
+	write := &redactedWrite{redactingCore: c, checked: wrapped}
+	write.parent = checked.AddCore(entry, write)
+	return write.parent
+}
+
+func (c *redactingCore) Write(entry zapcore.Entry, fields []zapcore.Field) error {
+	return c.Core.Write(entry, c.redact(fields))
+}
+
+// redactedWrite writes the entry checked by the wrapped core with the redacted fields
+type redactedWrite struct {
+	*redactingCore
+	checked *zapcore.CheckedEntry
+	// parent is the entry of the logger, its error output reports the write errors of the wrapped cores
+	parent *zapcore.CheckedEntry
+}
+
+func (w *redactedWrite) Write(_ zapcore.Entry, fields []zapcore.Field) error {
+	w.checked.ErrorOutput = w.parent.ErrorOutput
+	w.checked.Write(w.redact(fields)...)
+	return nil
+}
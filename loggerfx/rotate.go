@@ -0,0 +1,131 @@
+package loggerfx
+
+import (
+	"fmt"
+	"net/http"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/multierr"
+	"go.uber.org/zap"
+
+	"github.com/prismedic/scalpel/metricsfx"
+	"github.com/prismedic/scalpel/routerfx"
+)
+
+// LogFiles are the rotating writers of the log files, the main file, the additional files and the error file,
+// so they can be rotated on demand, e.g. before archiving the backups
+type LogFiles struct {
+	mutex sync.Mutex
+	files []logFile
+}
+
+type logFile struct {
+	filename string
+	rotator  interface{ Rotate() error }
+}
+
+func NewLogFiles() *LogFiles {
+	return &LogFiles{}
+}
+
+// add is a no-op on nil files, the logger built with New does not expose its files
+func (f *LogFiles) add(filename string, rotator interface{ Rotate() error }) {
+	if f == nil {
+		return
+	}
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	f.files = append(f.files, logFile{filename: filename, rotator: rotator})
+}
+
+// Filenames returns the paths of the active log files
+func (f *LogFiles) Filenames() []string {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	filenames := make([]string, 0, len(f.files))
+	for _, file := range f.files {
+		filenames = append(filenames, file.filename)
+	}
+	return filenames
+}
+
+// Rotate moves each log file to a timestamped backup and opens a new file under the same path like a rotation by size.
+// All the files are rotated even when one fails, the compression and the removal of the old backups follow the rotation config.
+func (f *LogFiles) Rotate() error {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	var errs error
+	for _, file := range f.files {
+		if err := file.rotator.Rotate(); err != nil {
+			errs = multierr.Append(errs, fmt.Errorf("error in rotating log file %s: %w", file.filename, err))
+		}
+	}
+	return errs
+}
+
+// Rotate rotates the file of lumberjack and compresses the backup with zstd
+func (w *zstdRotationWriter) Rotate() error {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	err := w.Logger.Rotate()
+	w.size = 0
+	w.mill()
+	return err
+}
+
+// newLogRotationRoutes registers the rotation route, which requires the metrics token as it changes the files of the host
+func newLogRotationRoutes(files *LogFiles, metricsConfig *metricsfx.MetricsConfig, logger *zap.SugaredLogger) []routerfx.ControllerRoute {
+	if metricsConfig == nil || metricsConfig.Auth.Token == "" {
+		logger.Debug("Log rotation route is not registered, metrics.auth.token is required to protect it")
+		return nil
+	}
+	return []routerfx.ControllerRoute{NewLogRotationController(files, metricsConfig.Auth.Token, logger)}
+}
+
+type LogRotationController struct {
+	files  *LogFiles
+	token  string
+	logger *zap.SugaredLogger
+}
+
+type LogRotationResponse struct {
+	// Files are the active log files, which keep their paths after the rotation
+	Files []string `json:"files"`
+}
+
+func NewLogRotationController(files *LogFiles, token string, logger *zap.SugaredLogger) *LogRotationController {
+	return &LogRotationController{files: files, token: token, logger: logger}
+}
+
+// rotateLogs godoc
+//
+//	@Summary		Rotate log files
+//	@Description	Rotate all the log files to timestamped backups, the new active files keep the configured paths
+//	@Produce		json
+//	@Success		200	{object}	LogRotationResponse
+//	@Failure		401
+//	@Failure		500	{object}	routerfx.ErrorResponse
+//	@Router			/logs/rotate [post]
+func (rc *LogRotationController) rotateLogs(c *gin.Context) {
+	if err := rc.files.Rotate(); err != nil {
+		routerfx.AbortWithError(c, err)
+		return
+	}
+	rc.logger.Infow("Rotated log files", "files", rc.files.Filenames())
+	c.JSON(http.StatusOK, &LogRotationResponse{Files: rc.files.Filenames()})
+}
+
+func (rc *LogRotationController) RegisterControllerRoutes(rg *gin.RouterGroup) {
+	rg.POST("/", metricsfx.WithBearerToken(rc.token, rc.rotateLogs))
+}
+
+func (rc *LogRotationController) RouteDocs() []routerfx.RouteDoc {
+	return []routerfx.RouteDoc{
+		{Method: http.MethodPost, Path: "/", Summary: "Rotate the log files", Response: LogRotationResponse{}},
+	}
+}
+
+func (rc *LogRotationController) RoutePattern() string {
+	return "/logs/rotate"
+}
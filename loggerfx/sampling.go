@@ -0,0 +1,53 @@
+package loggerfx
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"go.uber.org/zap/zapcore"
+)
+
+// names of the log sinks that sampling can be applied to
+const (
+	FileSink    = "file"
+	ConsoleSink = "console"
+	SyslogSink  = "syslog"
+)
+
+// SamplingConfig limits repeated log entries, for every second the first Initial entries
+// with the same level and message are logged, then only every Thereafter-th entry is logged.
+type SamplingConfig struct {
+	Initial    int `mapstructure:"initial" yaml:"initial" validate:"required,min=1"`
+	Thereafter int `mapstructure:"thereafter" yaml:"thereafter" validate:"min=0"`
+	// Sinks to apply sampling to, all sinks are sampled when empty
+	Sinks []string `mapstructure:"sinks" yaml:"sinks" validate:"dive,oneof=file console syslog"`
+}
+
+func (s *SamplingConfig) appliesTo(sink string) bool {
+	if len(s.Sinks) == 0 {
+		return true
+	}
+	for _, sampledSink := range s.Sinks {
+		if sampledSink == sink {
+			return true
+		}
+	}
+	return false
+}
+
+// sampleCore wraps the core of the sink with its own sampler, so that each sink is sampled independently.
+// The dropped entries are counted when dropped is not nil.
+func sampleCore(config *SamplingConfig, sink string, core zapcore.Core, dropped *prometheus.CounterVec) zapcore.Core {
+	if config == nil || !config.appliesTo(sink) {
+		return core
+	}
+	var options []zapcore.SamplerOption
+	if dropped != nil {
+		options = append(options, zapcore.SamplerHook(func(entry zapcore.Entry, decision zapcore.SamplingDecision) {
+			if decision&zapcore.LogDropped != 0 {
+				dropped.WithLabelValues(entry.Level.String(), sink).Inc()
+			}
+		}))
+	}
+	return zapcore.NewSamplerWithOptions(core, time.Second, config.Initial, config.Thereafter, options...)
+}
@@ -0,0 +1,33 @@
+package loggerfx
+
+import (
+	"errors"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// SyslogConfig sends the logs to a syslog daemon in addition to the other outputs.
+// Network and Address are empty for the local daemon, e.g. "udp" and "logs.example.com:514" for a remote one.
+type SyslogConfig struct {
+	Network  string   `mapstructure:"network" yaml:"network" validate:"omitempty,oneof=tcp udp unix unixgram"`
+	Address  string   `mapstructure:"address" yaml:"address" validate:"required_with=Network"`
+	Facility string   `mapstructure:"facility" yaml:"facility" validate:"omitempty,oneof=kern user mail daemon auth syslog lpr news uucp cron authpriv ftp local0 local1 local2 local3 local4 local5 local6 local7"`
+	Tag      string   `mapstructure:"tag" yaml:"tag"`
+	Level    LogLevel `mapstructure:"level" yaml:"level" validate:"omitempty,loglevel"`
+}
+
+// defaults of the optional syslog settings, the tag defaults to the program name in log/syslog
+const (
+	defaultSyslogFacility = "user"
+	defaultSyslogLevel    = "info"
+)
+
+var ErrSyslogUnsupported = errors.New("syslog output is not supported on this platform")
+
+func newSyslogEncoder() zapcore.Encoder {
+	syslogEncoderConfig := zap.NewProductionEncoderConfig()
+	// syslog adds its own timestamp
+	syslogEncoderConfig.TimeKey = ""
+	return zapcore.NewJSONEncoder(syslogEncoderConfig)
+}
@@ -0,0 +1,11 @@
+//go:build windows || plan9
+
+package loggerfx
+
+import (
+	"go.uber.org/zap/zapcore"
+)
+
+func newSyslogCore(config *SyslogConfig) (zapcore.Core, error) {
+	return nil, ErrSyslogUnsupported
+}
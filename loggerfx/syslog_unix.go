@@ -0,0 +1,110 @@
+//go:build !windows && !plan9
+
+package loggerfx
+
+import (
+	"fmt"
+	"log/syslog"
+	"strings"
+
+	"go.uber.org/zap/zapcore"
+)
+
+var syslogFacilityMap = map[string]syslog.Priority{
+	"kern":     syslog.LOG_KERN,
+	"user":     syslog.LOG_USER,
+	"mail":     syslog.LOG_MAIL,
+	"daemon":   syslog.LOG_DAEMON,
+	"auth":     syslog.LOG_AUTH,
+	"syslog":   syslog.LOG_SYSLOG,
+	"lpr":      syslog.LOG_LPR,
+	"news":     syslog.LOG_NEWS,
+	"uucp":     syslog.LOG_UUCP,
+	"cron":     syslog.LOG_CRON,
+	"authpriv": syslog.LOG_AUTHPRIV,
+	"ftp":      syslog.LOG_FTP,
+	"local0":   syslog.LOG_LOCAL0,
+	"local1":   syslog.LOG_LOCAL1,
+	"local2":   syslog.LOG_LOCAL2,
+	"local3":   syslog.LOG_LOCAL3,
+	"local4":   syslog.LOG_LOCAL4,
+	"local5":   syslog.LOG_LOCAL5,
+	"local6":   syslog.LOG_LOCAL6,
+	"local7":   syslog.LOG_LOCAL7,
+}
+
+// syslogCore writes every entry with the syslog severity of its level
+type syslogCore struct {
+	zapcore.LevelEnabler
+	encoder zapcore.Encoder
+	writer  *syslog.Writer
+}
+
+func newSyslogCore(config *SyslogConfig) (zapcore.Core, error) {
+	facility := config.Facility
+	if facility == "" {
+		facility = defaultSyslogFacility
+	}
+	level := config.Level
+	if level == "" {
+		level = defaultSyslogLevel
+	}
+	writer, err := syslog.Dial(config.Network, config.Address, syslogFacilityMap[facility]|syslog.LOG_INFO, config.Tag)
+	if err != nil {
+		return nil, fmt.Errorf("error in connecting to syslog: %w", err)
+	}
+	return &syslogCore{
+		LevelEnabler: logLevelMap[level],
+		encoder:      newSyslogEncoder(),
+		writer:       writer,
+	}, nil
+}
+
+func (c *syslogCore) With(fields []zapcore.Field) zapcore.Core {
+	encoder := c.encoder.Clone()
+	for _, field := range fields {
+		field.AddTo(encoder)
+	}
+	return &syslogCore{
+		LevelEnabler: c.LevelEnabler,
+		encoder:      encoder,
+		writer:       c.writer,
+	}
+}
+
+func (c *syslogCore) Check(entry zapcore.Entry, checked *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(entry.Level) {
+		return checked.AddCore(entry, c)
+	}
+	return checked
+}
+
+func (c *syslogCore) Write(entry zapcore.Entry, fields []zapcore.Field) error {
+	buf, err := c.encoder.EncodeEntry(entry, fields)
+	if err != nil {
+		return err
+	}
+	defer buf.Free()
+	message := strings.TrimSuffix(buf.String(), "\n")
+
+	switch entry.Level {
+	case zapcore.DebugLevel:
+		return c.writer.Debug(message)
+	case zapcore.InfoLevel:
+		return c.writer.Info(message)
+	case zapcore.WarnLevel:
+		return c.writer.Warning(message)
+	case zapcore.ErrorLevel:
+		return c.writer.Err(message)
+	case zapcore.DPanicLevel:
+		return c.writer.Crit(message)
+	case zapcore.PanicLevel:
+		return c.writer.Alert(message)
+	default:
+		return c.writer.Emerg(message)
+	}
+}
+
+func (c *syslogCore) Sync() error {
+	return nil
+}
@@ -0,0 +1,35 @@
+package loggerfx
+
+import (
+	"go.uber.org/fx"
+	"go.uber.org/fx/fxevent"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+// TestModule provides a no-op logger in place of Module for unit tests.
+// It needs no LoggerConfig, the config is not validated and no log file is created.
+var TestModule = fx.Options(
+	fx.Provide(NewNop),
+	fx.WithLogger(func() fxevent.Logger {
+		return fxevent.NopLogger
+	}),
+)
+
+// NewNop returns a logger that discards all entries, without validating a config or touching the disk
+func NewNop() *zap.SugaredLogger {
+	return zap.NewNop().Sugar()
+}
+
+// NewObserved returns a logger recording the entries at or above the level in memory, so tests can assert on them.
+// It can replace the logger of an app under test:
+//
+//	logger, logs := loggerfx.NewObserved(zapcore.DebugLevel)
+//	app := fxtest.New(t, loggerfx.TestModule, fx.Replace(logger), ...)
+//	...
+//	if logs.FilterMessage("request done").Len() != 1 { ... }
+func NewObserved(level zapcore.LevelEnabler) (*zap.SugaredLogger, *observer.ObservedLogs) {
+	core, logs := observer.New(level)
+	return zap.New(core).Sugar(), logs
+}
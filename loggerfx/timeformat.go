@@ -0,0 +1,35 @@
+package loggerfx
+
+import "go.uber.org/zap/zapcore"
+
+// named time formats of the outputs, other values are Go time layouts, e.g. "2006-01-02 15:04:05.000"
+const (
+	RFC3339TimeFormat     = "rfc3339"
+	RFC3339NanoTimeFormat = "rfc3339nano"
+	ISO8601TimeFormat     = "iso8601"
+	// EpochTimeFormat writes floating point seconds since the Unix epoch
+	EpochTimeFormat       = "epoch"
+	EpochMillisTimeFormat = "epoch_millis"
+	EpochNanosTimeFormat  = "epoch_nanos"
+)
+
+// newTimeEncoder returns the encoder of a named time format or of a Go time layout, nil for an empty format
+func newTimeEncoder(format string) zapcore.TimeEncoder {
+	switch format {
+	case "":
+		return nil
+	case RFC3339TimeFormat:
+		return zapcore.RFC3339TimeEncoder
+	case RFC3339NanoTimeFormat:
+		return zapcore.RFC3339NanoTimeEncoder
+	case ISO8601TimeFormat:
+		return zapcore.ISO8601TimeEncoder
+	case EpochTimeFormat:
+		return zapcore.EpochTimeEncoder
+	case EpochMillisTimeFormat:
+		return zapcore.EpochMillisTimeEncoder
+	case EpochNanosTimeFormat:
+		return zapcore.EpochNanosTimeEncoder
+	}
+	return zapcore.TimeEncoderOfLayout(format)
+}
@@ -0,0 +1,76 @@
+package loggerfx
+
+import (
+	"reflect"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/spf13/viper"
+	"go.uber.org/fx"
+	"go.uber.org/zap"
+
+	"github.com/prismedic/scalpel/config"
+)
+
+type WatchParams struct {
+	fx.In
+	Config   *LoggerConfig
+	Levels   *LogLevels
+	Validate *validator.Validate
+	Logger   *zap.SugaredLogger
+}
+
+// WatchLogLevels applies the log levels when the config file changes, if enabled with logs.watch_config.
+// Other settings cannot be changed without a restart, changes to them are ignored with a warning.
+func WatchLogLevels(p WatchParams) {
+	if !p.Config.WatchConfig {
+		return
+	}
+	config.OnConfigChange(func() {
+		// unmarshal from all settings rather than with UnmarshalKey, which ignores the nested defaults
+		var settings struct {
+			Logs LoggerConfig `mapstructure:"logs"`
+		}
+		if err := viper.Unmarshal(&settings); err != nil {
+			p.Logger.Warnw("Fail to unmarshal changed log config, keeping the current config", "err", err)
+			return
+		}
+		newConfig := settings.Logs
+		if err := config.ValidateStruct(p.Validate, "logs", &newConfig); err != nil {
+			p.Logger.Warnw("Changed log config is invalid, keeping the current config", "err", err)
+			return
+		}
+
+		if fileLevel := levelOrDefault(newConfig.File.Level); fileLevel != p.Levels.FileLevel() {
+			p.Levels.SetFileLevel(fileLevel)
+			p.Logger.Infow("Changed file log level", "level", fileLevel)
+		}
+		if consoleLevel := levelOrDefault(newConfig.Console.Level); consoleLevel != p.Levels.ConsoleLevel() {
+			p.Levels.SetConsoleLevel(consoleLevel)
+			p.Logger.Infow("Changed console log level", "level", consoleLevel)
+		}
+		for name, level := range newConfig.Levels {
+			if currentLevel, ok := p.Levels.NamedLevel(name); ok && currentLevel != level {
+				p.Levels.SetNamedLevel(name, level)
+				p.Logger.Infow("Changed named log level", "logger", name, "level", level)
+			}
+		}
+
+		// compare the settings other than the levels with the config at startup
+		currentConfig := *p.Config
+		currentConfig.File.Level = newConfig.File.Level
+		currentConfig.Console.Level = newConfig.Console.Level
+		// the names are fixed, so only the levels of the names already in the config are applied
+		if currentConfig.Levels != nil {
+			currentConfig.Levels = make(map[string]LogLevel, len(p.Config.Levels))
+			for name, level := range p.Config.Levels {
+				if newLevel, ok := newConfig.Levels[name]; ok {
+					level = newLevel
+				}
+				currentConfig.Levels[name] = level
+			}
+		}
+		if !reflect.DeepEqual(&currentConfig, &newConfig) {
+			p.Logger.Warn("Log settings other than the levels are changed, they are ignored until restart")
+		}
+	})
+}
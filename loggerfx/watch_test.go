@@ -0,0 +1,79 @@
+package loggerfx_test
+
+import (
+	"os"
+	"path"
+	"testing"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+
+	"github.com/prismedic/scalpel/config"
+	"github.com/prismedic/scalpel/loggerfx"
+)
+
+func TestWatchLogLevels(t *testing.T) {
+	dir := t.TempDir()
+	filename := path.Join(dir, "config.yaml")
+	writeConfig := func(fileLevel string) {
+		content := "logs:\n  file:\n    path: " + dir + "\n    level: " + fileLevel + "\n  console:\n    level: warn\n"
+		// the file is replaced rather than rewritten, so the watcher does not read a truncated file
+		if err := os.WriteFile(filename+".tmp", []byte(content), 0600); err != nil {
+			t.Fatalf("failed to write config file: %v", err)
+		}
+		if err := os.Rename(filename+".tmp", filename); err != nil {
+			t.Fatalf("failed to replace config file: %v", err)
+		}
+	}
+	writeConfig("info")
+	if err := config.InitConfig(filename); err != nil {
+		t.Fatalf("failed to init config: %v", err)
+	}
+
+	loggerConfig := newTestConfig(t)
+	loggerConfig.WatchConfig = true
+	loggerConfig.Console.Level = loggerfx.WarnLevel
+	levels := loggerfx.NewLogLevels(loggerConfig)
+	validate, err := loggerfx.RegisterLogLevelValidation(validator.New())
+	if err != nil {
+		t.Fatalf("failed to register validations: %v", err)
+	}
+	loggerfx.WatchLogLevels(loggerfx.WatchParams{
+		Config:   loggerConfig,
+		Levels:   levels,
+		Validate: validate,
+		Logger:   zap.NewNop().Sugar(),
+	})
+	// the handlers run in the order of registration, so this one runs once the levels are applied
+	reloaded := make(chan struct{}, 10)
+	config.OnConfigChange(func() {
+		reloaded <- struct{}{}
+	})
+	waitReload := func(t *testing.T) {
+		select {
+		case <-reloaded:
+		case <-time.After(5 * time.Second):
+			t.Fatalf("config file change not applied")
+		}
+	}
+
+	t.Run("Test changed level", func(t *testing.T) {
+		writeConfig("debug")
+		waitReload(t)
+		if level := levels.File.Level(); level != zapcore.DebugLevel {
+			t.Errorf("unexpected file level, got %s, expected %s", level, zapcore.DebugLevel)
+		}
+		if level := levels.Console.Level(); level != zapcore.WarnLevel {
+			t.Errorf("unexpected console level, got %s, expected %s", level, zapcore.WarnLevel)
+		}
+	})
+	t.Run("Test invalid level", func(t *testing.T) {
+		writeConfig("verbose")
+		waitReload(t)
+		if level := levels.File.Level(); level != zapcore.DebugLevel {
+			t.Errorf("invalid level should keep the current level, got %s, expected %s", level, zapcore.DebugLevel)
+		}
+	})
+}
@@ -0,0 +1,129 @@
+package loggerfx
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path"
+	"sync"
+	"time"
+
+	"go.uber.org/fx"
+	"go.uber.org/multierr"
+	"go.uber.org/zap"
+
+	"github.com/prismedic/scalpel/infofx"
+)
+
+// WritableCheckConfig configures the probe writing a small file to each log folder, e.g. to detect a volume remounted read-only
+type WritableCheckConfig struct {
+	// Interval between the probes, the checks report the result of the last probe
+	Interval time.Duration `mapstructure:"interval" yaml:"interval" validate:"required,gt=0"`
+}
+
+// writableCheckName is the name of the check in the readiness response
+const writableCheckName = "log_files"
+
+// WritableCheck periodically writes a probe file to the folders of the log files, the check fails while a folder is not writable.
+// The probes run in the background, so the check does not touch the disk on each request.
+type WritableCheck struct {
+	files  *LogFiles
+	logger *zap.SugaredLogger
+	mutex  sync.RWMutex
+	err    error
+}
+
+func NewWritableCheck(files *LogFiles, logger *zap.SugaredLogger) *WritableCheck {
+	return &WritableCheck{files: files, logger: logger}
+}
+
+func (c *WritableCheck) Name() string {
+	return writableCheckName
+}
+
+func (c *WritableCheck) Check(context.Context) error {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+	return c.err
+}
+
+// Probe writes a probe file to each folder of the log files and keeps the result for the check
+func (c *WritableCheck) Probe() error {
+	var errs error
+	seen := make(map[string]bool)
+	for _, filename := range c.files.Filenames() {
+		dir := path.Dir(filename)
+		if seen[dir] {
+			continue
+		}
+		seen[dir] = true
+		if err := probeDir(dir); err != nil {
+			errs = multierr.Append(errs, fmt.Errorf("error in writing to log folder %s: %w", dir, err))
+		}
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	// logged on changes only, the console still works when the folder of the files does not
+	if errs != nil && c.err == nil {
+		c.logger.Errorw("Log folder is not writable, entries of the log files are lost", "err", errs)
+	} else if errs == nil && c.err != nil {
+		c.logger.Info("Log folder is writable again")
+	}
+	c.err = errs
+	return errs
+}
+
+// probeDir creates, syncs and removes a probe file, so a full disk fails the probe like a read-only one
+func probeDir(dir string) error {
+	file, err := os.CreateTemp(dir, ".writable-check-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(file.Name())
+	if _, err := file.Write([]byte("ok\n")); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Sync(); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// newWritableChecks registers the check as a health check when the block of the config is present.
+// The first probe runs on start, the log files are opened by then.
+func newWritableChecks(lifecycle fx.Lifecycle, config *LoggerConfig, files *LogFiles, logger *zap.SugaredLogger) []infofx.HealthCheck {
+	if config.WritableCheck == nil {
+		return nil
+	}
+	check := NewWritableCheck(files, logger)
+	stop := make(chan struct{})
+	done := make(chan struct{})
+	lifecycle.Append(fx.Hook{
+		OnStart: func(context.Context) error {
+			_ = check.Probe()
+			go func() {
+				defer close(done)
+				ticker := time.NewTicker(config.WritableCheck.Interval)
+				defer ticker.Stop()
+				for {
+					select {
+					case <-ticker.C:
+						_ = check.Probe()
+					case <-stop:
+						return
+					}
+				}
+			}()
+			return nil
+		},
+		OnStop: func(context.Context) error {
+			close(stop)
+			<-done
+			return nil
+		},
+	})
+	return []infofx.HealthCheck{check}
+}
@@ -0,0 +1,186 @@
+package loggerfx
+
+import (
+	"io"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/klauspost/compress/zstd"
+	"gopkg.in/natefinch/lumberjack.v2"
+)
+
+// compression formats of the rotated log files
+const (
+	GzipCompression = "gzip"
+	ZstdCompression = "zstd"
+)
+
+const (
+	zstdSuffix = ".zst"
+	// backupTimeFormat is the timestamp lumberjack adds to the names of the backups, in UTC
+	backupTimeFormat = "2006-01-02T15-04-05.000"
+	// defaultMaxSizeMB is the size lumberjack rotates at without MaxSizeMB
+	defaultMaxSizeMB = 100
+)
+
+// zstdRotationWriter compresses the backups of lumberjack with zstd, lumberjack only compresses with gzip.
+// Lumberjack has no rotation hook, so the writer tracks the size of the file like lumberjack
+// and compresses the backups after the writes that rotate the file. It also applies MaxBackups and MaxAgeDays
+// to the compressed backups, which lumberjack does not recognize.
+type zstdRotationWriter struct {
+	*lumberjack.Logger
+	rotation RotationConfig
+	mutex    sync.Mutex
+	size     int64
+	maxSize  int64
+	millCh   chan struct{}
+}
+
+// newZstdRotationWriter returns the writer of the file, the backups left uncompressed by a previous run are compressed first
+func newZstdRotationWriter(filename string, rotation RotationConfig) *zstdRotationWriter {
+	maxSizeMB := rotation.MaxSizeMB
+	if maxSizeMB == 0 {
+		maxSizeMB = defaultMaxSizeMB
+	}
+	writer := &zstdRotationWriter{
+		// the backups are removed by the mill of the writer, so lumberjack keeps them all
+		Logger:   &lumberjack.Logger{Filename: filename, MaxSize: rotation.MaxSizeMB},
+		rotation: rotation,
+		maxSize:  int64(maxSizeMB) * 1024 * 1024,
+		millCh:   make(chan struct{}, 1),
+	}
+	if info, err := os.Stat(filename); err == nil {
+		writer.size = info.Size()
+	}
+	go writer.millRun()
+	writer.mill()
+	return writer
+}
+
+func (w *zstdRotationWriter) Write(p []byte) (int, error) {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	rotated := w.size+int64(len(p)) >= w.maxSize
+	if rotated {
+		w.size = 0
+	}
+	n, err := w.Logger.Write(p)
+	w.size += int64(n)
+	if rotated {
+		w.mill()
+	}
+	return n, err
+}
+
+// mill wakes the goroutine compressing the backups, a pending wake up already covers the new backup
+func (w *zstdRotationWriter) mill() {
+	select {
+	case w.millCh <- struct{}{}:
+	default:
+	}
+}
+
+// millRun compresses the backups and removes the old ones, the errors are ignored like in the mill of lumberjack
+func (w *zstdRotationWriter) millRun() {
+	for range w.millCh {
+		_ = w.millRunOnce()
+	}
+}
+
+func (w *zstdRotationWriter) millRunOnce() error {
+	dir := path.Dir(w.Filename)
+	name := path.Base(w.Filename)
+	ext := path.Ext(name)
+	prefix := strings.TrimSuffix(name, ext) + "-"
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+
+	var compressed []string
+	for _, entry := range entries {
+		backup := entry.Name()
+		if entry.IsDir() || !strings.HasPrefix(backup, prefix) {
+			continue
+		}
+		switch {
+		case strings.HasSuffix(backup, ext+zstdSuffix):
+			compressed = append(compressed, backup)
+		case strings.HasSuffix(backup, ext):
+			if _, err := time.Parse(backupTimeFormat, strings.TrimSuffix(strings.TrimPrefix(backup, prefix), ext)); err != nil {
+				continue
+			}
+			if err := compressZstd(path.Join(dir, backup)); err != nil {
+				return err
+			}
+			compressed = append(compressed, backup+zstdSuffix)
+		}
+	}
+
+	// the timestamps sort the backups from the oldest to the newest
+	sort.Strings(compressed)
+	var removed []string
+	if w.rotation.MaxBackups > 0 && len(compressed) > w.rotation.MaxBackups {
+		removed = append(removed, compressed[:len(compressed)-w.rotation.MaxBackups]...)
+		compressed = compressed[len(compressed)-w.rotation.MaxBackups:]
+	}
+	if w.rotation.MaxAgeDays > 0 {
+		cutoff := time.Now().Add(-time.Duration(w.rotation.MaxAgeDays) * 24 * time.Hour)
+		for _, backup := range compressed {
+			timestamp, err := time.Parse(backupTimeFormat, strings.TrimSuffix(strings.TrimPrefix(backup, prefix), ext+zstdSuffix))
+			if err == nil && timestamp.Before(cutoff) {
+				removed = append(removed, backup)
+			}
+		}
+	}
+	for _, backup := range removed {
+		if err := os.Remove(path.Join(dir, backup)); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+// compressZstd writes the file to a temporary file renamed with the .zst suffix once it is complete, then removes the file
+func compressZstd(filename string) error {
+	source, err := os.Open(filename)
+	if err != nil {
+		return err
+	}
+	defer source.Close()
+	info, err := source.Stat()
+	if err != nil {
+		return err
+	}
+
+	temporary := filename + zstdSuffix + ".tmp"
+	target, err := os.OpenFile(temporary, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, info.Mode())
+	if err != nil {
+		return err
+	}
+	encoder, err := zstd.NewWriter(target)
+	if err != nil {
+		target.Close()
+		return err
+	}
+	if _, err := io.Copy(encoder, source); err != nil {
+		encoder.Close()
+		target.Close()
+		return err
+	}
+	if err := encoder.Close(); err != nil {
+		target.Close()
+		return err
+	}
+	if err := target.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(temporary, filename+zstdSuffix); err != nil {
+		return err
+	}
+	return os.Remove(filename)
+}
@@ -0,0 +1,31 @@
+package metricsfx
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/prismedic/scalpel/routerfx"
+)
+
+const bearerPrefix = "Bearer "
+
+// WithBearerToken wraps handler to require an "Authorization: Bearer <token>" header, responding 401 otherwise.
+// The handler is returned unchanged when token is empty.
+func WithBearerToken(token string, handler gin.HandlerFunc) gin.HandlerFunc {
+	if token == "" {
+		return handler
+	}
+	return func(c *gin.Context) {
+		authorization := c.GetHeader("Authorization")
+		if !strings.HasPrefix(authorization, bearerPrefix) ||
+			subtle.ConstantTimeCompare([]byte(strings.TrimPrefix(authorization, bearerPrefix)), []byte(token)) != 1 {
+			c.Header("WWW-Authenticate", "Bearer")
+			routerfx.AbortWithStatusError(c, http.StatusUnauthorized, routerfx.CodeUnauthorized, "missing or invalid bearer token")
+			return
+		}
+		handler(c)
+	}
+}
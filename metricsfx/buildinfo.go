@@ -0,0 +1,30 @@
+package metricsfx
+
+import (
+	"fmt"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/prismedic/scalpel/infofx"
+)
+
+// NewBuildInfoCollector returns the build_info gauge, which is always 1 and carries the build of the binary as labels,
+// so that the running builds can be queried, e.g. count by (commit) (build_info)
+func NewBuildInfoCollector() (prometheus.Collector, error) {
+	info, err := infofx.GetInfo()
+	if err != nil {
+		return nil, fmt.Errorf("error in reading build info: %w", err)
+	}
+	buildInfo := prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "build_info",
+		Help: "Build of the running binary, the value is always 1.",
+		ConstLabels: prometheus.Labels{
+			"version":    info.Version,
+			"commit":     info.BuildCommit,
+			"date":       info.BuildDate,
+			"go_version": info.Runtime,
+		},
+	})
+	buildInfo.Set(1)
+	return buildInfo, nil
+}
@@ -0,0 +1,70 @@
+package metricsfx
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/prismedic/scalpel/routerfx"
+)
+
+// unmatchedPath is the path label of requests not matching any route, so raw URLs never become labels
+const unmatchedPath = "unmatched"
+
+// NewHttpMetricsMiddleware returns a middleware recording the count and duration of HTTP requests and the requests in flight.
+// The path label is the route template, requests to the excluded paths and their sub-paths are not recorded.
+func NewHttpMetricsMiddleware(config *MetricsConfig, registry *prometheus.Registry) (gin.HandlerFunc, error) {
+	requests := prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "http_requests_total",
+		Help: "Total number of HTTP requests.",
+	}, []string{"method", "path", "status"})
+	duration := prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "http_request_duration_seconds",
+		Help:    "Duration of HTTP requests in seconds.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"method", "path", "status"})
+	inFlight := prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "http_requests_in_flight",
+		Help: "Number of HTTP requests being served.",
+	})
+	if err := Register(prometheus.WrapRegistererWithPrefix(metricPrefix(config), registry), requests, duration, inFlight); err != nil {
+		return nil, err
+	}
+
+	// the metrics endpoint itself is never recorded
+	excludePaths := append([]string{config.Path}, config.Http.ExcludePaths...)
+
+	return func(c *gin.Context) {
+		for _, excludePath := range excludePaths {
+			if routerfx.MatchesPath(c.Request.URL.Path, excludePath) {
+				c.Next()
+				return
+			}
+		}
+
+		inFlight.Inc()
+		start := time.Now()
+		completed := false
+		// deferred, so the panics recovered by the recovery middleware are still recorded
+		defer func() {
+			inFlight.Dec()
+			path := c.FullPath()
+			if path == "" {
+				path = unmatchedPath
+			}
+			statusCode := c.Writer.Status()
+			// the recovery middleware answers 500 once the panic reaches it, after this middleware
+			if !completed && !c.Writer.Written() {
+				statusCode = http.StatusInternalServerError
+			}
+			status := strconv.Itoa(statusCode)
+			requests.WithLabelValues(c.Request.Method, path, status).Inc()
+			duration.WithLabelValues(c.Request.Method, path, status).Observe(time.Since(start).Seconds())
+		}()
+		c.Next()
+		completed = true
+	}, nil
+}
@@ -1,11 +1,90 @@
 package metricsfx
 
 import (
+	"time"
+
+	"github.com/spf13/viper"
 	"go.uber.org/fx"
 
+	"github.com/prismedic/scalpel/config"
 	"github.com/prismedic/scalpel/routerfx"
 )
 
 var Module = fx.Module("metrics",
-	fx.Provide(routerfx.AsHandlerRoute(NewPrometheusHandler)),
+	fx.Provide(NewRegistry),
+	fx.Provide(NewPrometheusHandler),
+	fx.Provide(
+		fx.Annotate(
+			newHandlerRoutes,
+			fx.ResultTags(`group:"handlerRoutes,flatten"`),
+		),
+	),
+	fx.Provide(routerfx.AsMiddleware(NewHttpMetricsMiddleware)),
+	fx.Invoke(RunPushGateway),
+	fx.Invoke(RunFinalScrape),
+	fx.Invoke(RegisterRuntimeMetrics),
 )
+
+// newHandlerRoutes registers the Prometheus handler unless it is disabled
+func newHandlerRoutes(config *MetricsConfig, handler *PrometheusHandler) []routerfx.HandlerRoute {
+	if config.DisableHandler {
+		return nil
+	}
+	return []routerfx.HandlerRoute{handler}
+}
+
+type MetricsConfig struct {
+	// Namespace and Subsystem prefix the built-in HTTP, runtime and log metrics,
+	// collectors registered by other modules are not renamed unless they use NewRegisterer
+	Namespace string `mapstructure:"namespace" yaml:"namespace"`
+	Subsystem string `mapstructure:"subsystem" yaml:"subsystem"`
+	// PrefixStandardCollectors prefixes the go_* and process_* metrics of the Go and process collectors as well
+	PrefixStandardCollectors bool `mapstructure:"prefix_standard_collectors" yaml:"prefix_standard_collectors"`
+	// Path is the route of the Prometheus handler
+	Path string `mapstructure:"path" yaml:"path" validate:"required,startswith=/"`
+	// DisableHandler removes the Prometheus handler, e.g. for batch jobs only using the push gateway
+	DisableHandler bool `mapstructure:"disable_handler" yaml:"disable_handler"`
+	// Server is the name of the server of http.servers serving the Prometheus handler, the default server when empty
+	Server string `mapstructure:"server" yaml:"server"`
+	// FinalScrapeDelay delays the stop of the app so that a last scrape collects the terminal metrics, zero disables it.
+	// It counts in the shutdown timeout and the http server keeps serving during the delay.
+	FinalScrapeDelay time.Duration `mapstructure:"final_scrape_delay" yaml:"final_scrape_delay" validate:"min=0"`
+	Auth             struct {
+		// Token is the bearer token required to access the metrics, the metrics are open when it is empty
+		Token string `mapstructure:"token" yaml:"token"`
+	} `mapstructure:"auth" yaml:"auth"`
+	Http struct {
+		// ExcludePaths are the paths of requests that are not recorded in the HTTP metrics, with their sub-paths
+		ExcludePaths []string `mapstructure:"exclude_paths" yaml:"exclude_paths"`
+	} `mapstructure:"http" yaml:"http"`
+	// Runtime collects runtime/metrics samples missing from the Go collector, e.g. the GC pauses and the scheduler latencies
+	Runtime struct {
+		Enabled bool `mapstructure:"enabled" yaml:"enabled"`
+		// Metrics are the names of the runtime/metrics samples, e.g. /sched/latencies:seconds
+		Metrics []string `mapstructure:"metrics" yaml:"metrics" validate:"required_if=Enabled true,dive,startswith=/"`
+	} `mapstructure:"runtime" yaml:"runtime"`
+	PushGateway struct {
+		// Url of the push gateway, metrics are not pushed when it is empty
+		Url      string        `mapstructure:"url" yaml:"url" validate:"omitempty,url"`
+		Job      string        `mapstructure:"job" yaml:"job" validate:"required_with=Url"`
+		Interval time.Duration `mapstructure:"interval" yaml:"interval" validate:"required_with=Url,min=0"`
+	} `mapstructure:"pushgateway" yaml:"pushgateway"`
+}
+
+func init() {
+	// config must have a default value for viper to load config from env variables
+	viper.SetDefault("metrics.namespace", SanitizeMetricName(config.GetPackageName()))
+	viper.SetDefault("metrics.subsystem", "")
+	viper.SetDefault("metrics.prefix_standard_collectors", false)
+	viper.SetDefault("metrics.path", "/metrics")
+	viper.SetDefault("metrics.disable_handler", false)
+	viper.SetDefault("metrics.server", "")
+	viper.SetDefault("metrics.final_scrape_delay", 0)
+	viper.SetDefault("metrics.auth.token", "")
+	viper.SetDefault("metrics.http.exclude_paths", []string{"/metrics", "/v1/healthz", "/v1/readyz"})
+	viper.SetDefault("metrics.runtime.enabled", false)
+	viper.SetDefault("metrics.runtime.metrics", []string{"/gc/pauses:seconds", "/sched/latencies:seconds"})
+	viper.SetDefault("metrics.pushgateway.url", "")
+	viper.SetDefault("metrics.pushgateway.job", config.GetPackageName())
+	viper.SetDefault("metrics.pushgateway.interval", 15*time.Second)
+}
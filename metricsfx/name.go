@@ -0,0 +1,49 @@
+package metricsfx
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+var metricNameRegexp = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+
+// validateMetricPrefix checks that the namespace and subsystem follow the Prometheus naming rules
+func validateMetricPrefix(config *MetricsConfig) error {
+	for key, value := range map[string]string{
+		"metrics.namespace": config.Namespace,
+		"metrics.subsystem": config.Subsystem,
+	} {
+		if value != "" && !metricNameRegexp.MatchString(value) {
+			return fmt.Errorf("invalid %s %q, must match %s", key, value, metricNameRegexp)
+		}
+	}
+	return nil
+}
+
+// SanitizeMetricName replaces the characters not allowed in Prometheus metric names with underscores
+func SanitizeMetricName(name string) string {
+	sanitized := []rune(name)
+	for i, char := range sanitized {
+		isLetter := (char >= 'a' && char <= 'z') || (char >= 'A' && char <= 'Z') || char == '_'
+		isDigit := char >= '0' && char <= '9'
+		if !isLetter && !(isDigit && i > 0) {
+			sanitized[i] = '_'
+		}
+	}
+	return string(sanitized)
+}
+
+// metricPrefix joins the namespace and subsystem the same way as prometheus.BuildFQName
+func metricPrefix(config *MetricsConfig) string {
+	var parts []string
+	for _, part := range []string{config.Namespace, config.Subsystem} {
+		if part != "" {
+			parts = append(parts, part)
+		}
+	}
+	if len(parts) == 0 {
+		return ""
+	}
+	return strings.Join(parts, "_") + "_"
+}
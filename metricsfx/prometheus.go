@@ -3,21 +3,40 @@ package metricsfx
 import (
 	"github.com/gin-gonic/gin"
 	"github.com/prismedic/scalpel/routerfx"
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 )
 
-type PrometheusHandler struct{}
+type PrometheusHandler struct {
+	path     string
+	server   string
+	token    string
+	registry *prometheus.Registry
+}
 
-func NewPrometheusHandler() *PrometheusHandler {
-	return &PrometheusHandler{}
+func NewPrometheusHandler(config *MetricsConfig, registry *prometheus.Registry) *PrometheusHandler {
+	return &PrometheusHandler{
+		path:     config.Path,
+		server:   config.Server,
+		token:    config.Auth.Token,
+		registry: registry,
+	}
 }
 
 func (ph *PrometheusHandler) Handler() gin.HandlerFunc {
-	return gin.WrapH(promhttp.Handler())
+	handler := gin.WrapH(promhttp.HandlerFor(ph.registry, promhttp.HandlerOpts{Registry: ph.registry}))
+	return WithBearerToken(ph.token, handler)
 }
 
 func (ph *PrometheusHandler) RoutePattern() string {
-	return "/metrics"
+	return ph.path
+}
+
+func (ph *PrometheusHandler) RouteServer() string {
+	return ph.server
 }
 
-var _ routerfx.HandlerRoute = (*PrometheusHandler)(nil)
+var (
+	_ routerfx.HandlerRoute = (*PrometheusHandler)(nil)
+	_ routerfx.ServerRoute  = (*PrometheusHandler)(nil)
+)
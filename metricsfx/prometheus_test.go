@@ -0,0 +1,455 @@
+package metricsfx_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"runtime"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"go.uber.org/fx"
+	"go.uber.org/fx/fxtest"
+	"go.uber.org/zap"
+
+	"github.com/prismedic/scalpel/loggerfx"
+	"github.com/prismedic/scalpel/metricsfx"
+)
+
+func TestPrometheusHandler(t *testing.T) {
+	t.Run("Test configured path", func(t *testing.T) {
+		config := &metricsfx.MetricsConfig{Path: "/internal/metrics"}
+		registry, err := metricsfx.NewRegistry(config)
+		if err != nil {
+			t.Fatalf("failed to create registry: %v", err)
+		}
+		handler := metricsfx.NewPrometheusHandler(config, registry)
+
+		gin.SetMode(gin.TestMode)
+		router := gin.New()
+		router.Any(handler.RoutePattern(), handler.Handler())
+
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/internal/metrics", nil))
+		if recorder.Code != http.StatusOK {
+			t.Fatalf("unexpected status code, got %d, expected %d", recorder.Code, http.StatusOK)
+		}
+		if contentType := recorder.Header().Get("Content-Type"); !strings.HasPrefix(contentType, "text/plain") {
+			t.Errorf("unexpected content type, got %s, expected Prometheus text format", contentType)
+		}
+		if !strings.Contains(recorder.Body.String(), "# TYPE go_goroutines gauge") {
+			t.Errorf("go collector metrics not found in response")
+		}
+
+		recorder = httptest.NewRecorder()
+		router.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+		if recorder.Code != http.StatusNotFound {
+			t.Errorf("unexpected status code for default path, got %d, expected %d", recorder.Code, http.StatusNotFound)
+		}
+	})
+	t.Run("Test bearer token", func(t *testing.T) {
+		config := &metricsfx.MetricsConfig{Path: "/metrics"}
+		config.Auth.Token = "secret"
+		registry, err := metricsfx.NewRegistry(config)
+		if err != nil {
+			t.Fatalf("failed to create registry: %v", err)
+		}
+		handler := metricsfx.NewPrometheusHandler(config, registry)
+
+		gin.SetMode(gin.TestMode)
+		router := gin.New()
+		router.Any(handler.RoutePattern(), handler.Handler())
+
+		for authorization, expectedCode := range map[string]int{
+			"":              http.StatusUnauthorized,
+			"Bearer wrong":  http.StatusUnauthorized,
+			"secret":        http.StatusUnauthorized,
+			"Bearer secret": http.StatusOK,
+		} {
+			request := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+			if authorization != "" {
+				request.Header.Set("Authorization", authorization)
+			}
+			recorder := httptest.NewRecorder()
+			router.ServeHTTP(recorder, request)
+			if recorder.Code != expectedCode {
+				t.Errorf("unexpected status code with authorization %q, got %d, expected %d", authorization, recorder.Code, expectedCode)
+			}
+		}
+	})
+	t.Run("Test namespace and subsystem", func(t *testing.T) {
+		config := &metricsfx.MetricsConfig{Path: "/metrics", Namespace: "arsenal", Subsystem: "api"}
+		registry, err := metricsfx.NewRegistry(config)
+		if err != nil {
+			t.Fatalf("failed to create registry: %v", err)
+		}
+		handler := metricsfx.NewPrometheusHandler(config, registry)
+
+		gin.SetMode(gin.TestMode)
+		router := gin.New()
+		router.Any(handler.RoutePattern(), handler.Handler())
+
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+		if !strings.Contains(recorder.Body.String(), "# TYPE go_goroutines gauge") || strings.Contains(recorder.Body.String(), "arsenal_api_go_goroutines") {
+			t.Errorf("go collector metrics without prefix not found in response")
+		}
+		expected := fmt.Sprintf(`go_version="%s",version=`, runtime.Version())
+		if !strings.Contains(recorder.Body.String(), "arsenal_api_build_info{commit=") || !strings.Contains(recorder.Body.String(), expected) {
+			t.Errorf("build info metric not found in response")
+		}
+	})
+	t.Run("Test prefixed standard collectors", func(t *testing.T) {
+		config := &metricsfx.MetricsConfig{Path: "/metrics", Namespace: "arsenal", PrefixStandardCollectors: true}
+		registry, err := metricsfx.NewRegistry(config)
+		if err != nil {
+			t.Fatalf("failed to create registry: %v", err)
+		}
+		if value := gaugeValue(t, registry, "arsenal_go_goroutines"); value == 0 {
+			t.Errorf("unexpected value of the prefixed go collector, got %f", value)
+		}
+	})
+	t.Run("Test invalid namespace", func(t *testing.T) {
+		config := &metricsfx.MetricsConfig{Path: "/metrics", Namespace: "http-server"}
+		if _, err := metricsfx.NewRegistry(config); err == nil {
+			t.Errorf("expected namespace %s to be rejected", config.Namespace)
+		}
+	})
+}
+
+func TestSanitizeMetricName(t *testing.T) {
+	for name, expected := range map[string]string{
+		"arsenal":     "arsenal",
+		"http-server": "http_server",
+		"2fa.service": "_fa_service",
+	} {
+		if got := metricsfx.SanitizeMetricName(name); got != expected {
+			t.Errorf("unexpected sanitized name of %s, got %s, expected %s", name, got, expected)
+		}
+	}
+}
+
+func TestHttpMetricsMiddleware(t *testing.T) {
+	t.Run("Test requests in flight", func(t *testing.T) {
+		config := &metricsfx.MetricsConfig{Path: "/metrics"}
+		registry, err := metricsfx.NewRegistry(config)
+		if err != nil {
+			t.Fatalf("failed to create registry: %v", err)
+		}
+		middleware, err := metricsfx.NewHttpMetricsMiddleware(config, registry)
+		if err != nil {
+			t.Fatalf("failed to create middleware: %v", err)
+		}
+
+		gin.SetMode(gin.TestMode)
+		router := gin.New()
+		router.Use(gin.Recovery(), middleware)
+		router.GET("/serving", func(c *gin.Context) {
+			c.String(http.StatusOK, "%.0f", gaugeValue(t, registry, "http_requests_in_flight"))
+		})
+		router.GET("/panic", func(c *gin.Context) {
+			panic("handler failed")
+		})
+
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/serving", nil))
+		if recorder.Body.String() != "1" {
+			t.Errorf("unexpected requests in flight while serving, got %s, expected 1", recorder.Body.String())
+		}
+		recorder = httptest.NewRecorder()
+		router.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/panic", nil))
+		if recorder.Code != http.StatusInternalServerError {
+			t.Errorf("unexpected status code, got %d, expected %d", recorder.Code, http.StatusInternalServerError)
+		}
+		if value := gaugeValue(t, registry, "http_requests_in_flight"); value != 0 {
+			t.Errorf("unexpected requests in flight after the requests, got %f, expected 0", value)
+		}
+	})
+	t.Run("Test excluded paths", func(t *testing.T) {
+		config := &metricsfx.MetricsConfig{Path: "/metrics"}
+		registry, err := metricsfx.NewRegistry(config)
+		if err != nil {
+			t.Fatalf("failed to create registry: %v", err)
+		}
+		middleware, err := metricsfx.NewHttpMetricsMiddleware(config, registry)
+		if err != nil {
+			t.Fatalf("failed to create middleware: %v", err)
+		}
+
+		gin.SetMode(gin.TestMode)
+		router := gin.New()
+		router.Use(middleware)
+		for _, path := range []string{"/metrics", "/metrics/job", "/metricsfoo"} {
+			router.GET(path, func(c *gin.Context) {
+				c.Status(http.StatusNoContent)
+			})
+			router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+		}
+		// only the path sharing the prefix of the metrics path is recorded
+		labels := map[string]string{"path": "/metricsfoo", "status": "204"}
+		if value := counterValue(t, registry, "http_requests_total", labels); value != 1 {
+			t.Errorf("unexpected number of requests of /metricsfoo, got %f, expected 1", value)
+		}
+		families, err := registry.Gather()
+		if err != nil {
+			t.Fatalf("failed to gather metrics: %v", err)
+		}
+		for _, family := range families {
+			if family.GetName() == "http_requests_total" && len(family.GetMetric()) != 1 {
+				t.Errorf("unexpected number of recorded paths, got %d, expected 1", len(family.GetMetric()))
+			}
+		}
+	})
+	t.Run("Test recovered panic", func(t *testing.T) {
+		config := &metricsfx.MetricsConfig{Path: "/metrics"}
+		registry, err := metricsfx.NewRegistry(config)
+		if err != nil {
+			t.Fatalf("failed to create registry: %v", err)
+		}
+		middleware, err := metricsfx.NewHttpMetricsMiddleware(config, registry)
+		if err != nil {
+			t.Fatalf("failed to create middleware: %v", err)
+		}
+
+		gin.SetMode(gin.TestMode)
+		router := gin.New()
+		router.Use(gin.Recovery(), middleware)
+		router.GET("/panic", func(c *gin.Context) {
+			panic("handler failed")
+		})
+
+		router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/panic", nil))
+		labels := map[string]string{"method": http.MethodGet, "path": "/panic", "status": "500"}
+		if value := counterValue(t, registry, "http_requests_total", labels); value != 1 {
+			t.Errorf("unexpected number of recovered requests, got %f, expected 1", value)
+		}
+	})
+}
+
+func TestRegister(t *testing.T) {
+	newCounter := func() prometheus.Counter {
+		return prometheus.NewCounter(prometheus.CounterOpts{Name: "jobs_total", Help: "Total number of jobs."})
+	}
+	t.Run("Test modules sharing the registry", func(t *testing.T) {
+		config := &metricsfx.MetricsConfig{Path: "/metrics", Namespace: "arsenal"}
+		registry, err := metricsfx.NewRegistry(config)
+		if err != nil {
+			t.Fatalf("failed to create registry: %v", err)
+		}
+		for i := 0; i < 2; i++ {
+			if _, err := metricsfx.NewHttpMetricsMiddleware(config, registry); err != nil {
+				t.Fatalf("failed to create middleware on the shared registry: %v", err)
+			}
+			if err := metricsfx.Register(registry, newCounter()); err != nil {
+				t.Errorf("unexpected error of an already registered collector: %v", err)
+			}
+		}
+	})
+	t.Run("Test existing collector", func(t *testing.T) {
+		registry := prometheus.NewRegistry()
+		registerer := metricsfx.NewRegisterer(registry, &metricsfx.MetricsConfig{Namespace: "arsenal"})
+		registered, err := metricsfx.RegisterOrExisting(registerer, newCounter())
+		if err != nil {
+			t.Fatalf("failed to register collector: %v", err)
+		}
+		existing, err := metricsfx.RegisterOrExisting(registerer, newCounter())
+		if err != nil {
+			t.Fatalf("failed to register collector twice: %v", err)
+		}
+		if existing != registered {
+			t.Errorf("expected the already registered collector to be returned")
+		}
+		existing.Inc()
+		if value := counterValue(t, registry, "arsenal_jobs_total", nil); value != 1 {
+			t.Errorf("unexpected value of the existing collector, got %f, expected 1", value)
+		}
+	})
+	t.Run("Test existing collector of another type", func(t *testing.T) {
+		registry := prometheus.NewRegistry()
+		if _, err := metricsfx.RegisterOrExisting(registry, newCounter()); err != nil {
+			t.Fatalf("failed to register collector: %v", err)
+		}
+		vector := prometheus.NewCounterVec(prometheus.CounterOpts{Name: "jobs_total", Help: "Total number of jobs."}, nil)
+		if _, err := metricsfx.RegisterOrExisting(registry, vector); err == nil {
+			t.Errorf("expected an error for a collector of another type")
+		}
+	})
+}
+
+func TestRuntimeMetrics(t *testing.T) {
+	config := &metricsfx.MetricsConfig{Path: "/metrics"}
+	config.Runtime.Enabled = true
+	config.Runtime.Metrics = []string{"/gc/pauses:seconds", "/sched/latencies:seconds", "/gc/cycles/total:gc-cycles", "/unknown/metric:seconds"}
+	registry, err := metricsfx.NewRegistry(config)
+	if err != nil {
+		t.Fatalf("failed to create registry: %v", err)
+	}
+	logger, logs := loggerfx.NewObserved(zap.WarnLevel)
+	if err := metricsfx.RegisterRuntimeMetrics(metricsfx.RuntimeMetricsParams{Config: config, Registry: registry, Logger: logger}); err != nil {
+		t.Fatalf("failed to register runtime metrics: %v", err)
+	}
+	families, err := registry.Gather()
+	if err != nil {
+		t.Fatalf("failed to gather metrics: %v", err)
+	}
+	types := make(map[string]string)
+	for _, family := range families {
+		types[family.GetName()] = family.GetType().String()
+	}
+	for name, expected := range map[string]string{
+		"go_gc_pauses_seconds":               "HISTOGRAM",
+		"go_sched_latencies_seconds":         "HISTOGRAM",
+		"go_gc_cycles_total_gc_cycles_total": "COUNTER",
+	} {
+		if types[name] != expected {
+			t.Errorf("unexpected type of %s, got %q, expected %s", name, types[name], expected)
+		}
+	}
+	if warnings := logs.FilterMessageSnippet("Unknown runtime metrics").All(); len(warnings) != 1 {
+		t.Errorf("unexpected number of warnings for the unknown metric, got %d, expected 1", len(warnings))
+	}
+}
+
+func TestFinalScrape(t *testing.T) {
+	config := &metricsfx.MetricsConfig{Path: "/metrics", FinalScrapeDelay: 100 * time.Millisecond}
+	registry, err := metricsfx.NewRegistry(config)
+	if err != nil {
+		t.Fatalf("failed to create registry: %v", err)
+	}
+	lifecycle := fxtest.NewLifecycle(t)
+	if err := metricsfx.RunFinalScrape(metricsfx.FinalScrapeParams{Lifecycle: lifecycle, Config: config, Registry: registry}); err != nil {
+		t.Fatalf("failed to register final scrape: %v", err)
+	}
+	lifecycle.RequireStart()
+	if value := gaugeValue(t, registry, "process_shutting_down"); value != 0 {
+		t.Errorf("unexpected process_shutting_down before stop, got %f, expected 0", value)
+	}
+
+	stopped := make(chan struct{})
+	start := time.Now()
+	go func() {
+		defer close(stopped)
+		lifecycle.RequireStop()
+	}()
+	time.Sleep(20 * time.Millisecond)
+	if value := gaugeValue(t, registry, "process_shutting_down"); value != 1 {
+		t.Errorf("unexpected process_shutting_down during stop, got %f, expected 1", value)
+	}
+	<-stopped
+	if elapsed := time.Since(start); elapsed < config.FinalScrapeDelay {
+		t.Errorf("stop is not delayed, took %s, expected at least %s", elapsed, config.FinalScrapeDelay)
+	}
+}
+
+func TestPushGateway(t *testing.T) {
+	newGateway := func(t *testing.T) (url string, pushes func() int) {
+		var mutex sync.Mutex
+		count := 0
+		gateway := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method != http.MethodPut || r.URL.Path != "/metrics/job/batch" {
+				t.Errorf("unexpected push request %s %s", r.Method, r.URL.Path)
+			}
+			mutex.Lock()
+			defer mutex.Unlock()
+			count++
+		}))
+		t.Cleanup(gateway.Close)
+		return gateway.URL, func() int {
+			mutex.Lock()
+			defer mutex.Unlock()
+			return count
+		}
+	}
+	newApp := func(t *testing.T, url string, interval time.Duration) (*fxtest.App, *metricsfx.PrometheusHandler) {
+		config := &metricsfx.MetricsConfig{Path: "/metrics"}
+		config.PushGateway.Url = url
+		config.PushGateway.Job = "batch"
+		config.PushGateway.Interval = interval
+		var handler *metricsfx.PrometheusHandler
+		app := fxtest.New(t,
+			fx.Supply(config),
+			fx.Provide(metricsfx.NewRegistry, metricsfx.NewPrometheusHandler),
+			fx.Invoke(metricsfx.RunPushGateway),
+			fx.Populate(&handler),
+		)
+		return app, handler
+	}
+
+	t.Run("Test interval pushes", func(t *testing.T) {
+		url, pushes := newGateway(t)
+		app, _ := newApp(t, url, 10*time.Millisecond)
+		app.RequireStart()
+		defer app.RequireStop()
+		for deadline := time.Now().Add(5 * time.Second); pushes() < 2; time.Sleep(10 * time.Millisecond) {
+			if time.Now().After(deadline) {
+				t.Fatalf("unexpected number of pushes, got %d, expected at least 2", pushes())
+			}
+		}
+	})
+	t.Run("Test final push", func(t *testing.T) {
+		url, pushes := newGateway(t)
+		app, handler := newApp(t, url, time.Hour)
+		app.RequireStart()
+		if count := pushes(); count != 0 {
+			t.Errorf("unexpected number of pushes before the interval, got %d, expected 0", count)
+		}
+
+		// the Prometheus handler is still served next to the push gateway
+		gin.SetMode(gin.TestMode)
+		router := gin.New()
+		router.Any(handler.RoutePattern(), handler.Handler())
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+		if recorder.Code != http.StatusOK {
+			t.Errorf("unexpected status code of the Prometheus handler, got %d, expected %d", recorder.Code, http.StatusOK)
+		}
+
+		app.RequireStop()
+		if count := pushes(); count != 1 {
+			t.Errorf("unexpected number of pushes after stop, got %d, expected 1", count)
+		}
+	})
+}
+
+func gaugeValue(t *testing.T, registry *prometheus.Registry, name string) float64 {
+	families, err := registry.Gather()
+	if err != nil {
+		t.Fatalf("failed to gather metrics: %v", err)
+	}
+	for _, family := range families {
+		if family.GetName() == name {
+			return family.GetMetric()[0].GetGauge().GetValue()
+		}
+	}
+	t.Fatalf("%s not found in registry", name)
+	return 0
+}
+
+func counterValue(t *testing.T, registry *prometheus.Registry, name string, labels map[string]string) float64 {
+	families, err := registry.Gather()
+	if err != nil {
+		t.Fatalf("failed to gather metrics: %v", err)
+	}
+	for _, family := range families {
+		if family.GetName() != name {
+			continue
+		}
+		for _, metric := range family.GetMetric() {
+			matched := 0
+			for _, label := range metric.GetLabel() {
+				if labels[label.GetName()] == label.GetValue() {
+					matched++
+				}
+			}
+			if matched == len(labels) {
+				return metric.GetCounter().GetValue()
+			}
+		}
+	}
+	t.Fatalf("%s with labels %v not found in registry", name, labels)
+	return 0
+}
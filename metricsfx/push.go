@@ -0,0 +1,56 @@
+package metricsfx
+
+import (
+	"context"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/push"
+	"go.uber.org/fx"
+	"go.uber.org/zap"
+)
+
+type PushGatewayParams struct {
+	fx.In
+	Lifecycle fx.Lifecycle
+	Config    *MetricsConfig
+	Registry  *prometheus.Registry
+	Logger    *zap.SugaredLogger `optional:"true"`
+}
+
+// RunPushGateway pushes the registry to the push gateway on an interval and once more on stop,
+// so that the metrics of short-lived jobs are collected. It does nothing when no push gateway url is configured.
+func RunPushGateway(p PushGatewayParams) {
+	if p.Config.PushGateway.Url == "" {
+		return
+	}
+	pusher := push.New(p.Config.PushGateway.Url, p.Config.PushGateway.Job).Gatherer(p.Registry)
+	done := make(chan struct{})
+	stopped := make(chan struct{})
+
+	p.Lifecycle.Append(fx.Hook{
+		OnStart: func(context.Context) error {
+			go func() {
+				defer close(stopped)
+				ticker := time.NewTicker(p.Config.PushGateway.Interval)
+				defer ticker.Stop()
+				for {
+					select {
+					case <-ticker.C:
+						if err := pusher.Push(); err != nil && p.Logger != nil {
+							p.Logger.Warnw("failed to push metrics to push gateway", "err", err)
+						}
+					case <-done:
+						return
+					}
+				}
+			}()
+			return nil
+		},
+		OnStop: func(ctx context.Context) error {
+			close(done)
+			<-stopped
+			return pusher.PushContext(ctx)
+		},
+	})
+}
@@ -0,0 +1,80 @@
+package metricsfx
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+)
+
+// NewRegistry creates the registry served by the metrics handler, with the Go runtime, process and build info collectors.
+// The Go and process collectors keep their standard names, e.g. go_goroutines, unless PrefixStandardCollectors is set.
+// Other modules can register their own metrics on the same registry.
+func NewRegistry(config *MetricsConfig) (*prometheus.Registry, error) {
+	if err := validateMetricPrefix(config); err != nil {
+		return nil, err
+	}
+	buildInfo, err := NewBuildInfoCollector()
+	if err != nil {
+		return nil, err
+	}
+	registry := prometheus.NewRegistry()
+	registerer := prometheus.WrapRegistererWithPrefix(metricPrefix(config), registry)
+	// the dashboards and alerts built on the standard names keep working
+	standardRegisterer := prometheus.Registerer(registry)
+	if config.PrefixStandardCollectors {
+		standardRegisterer = registerer
+	}
+	err = Register(standardRegisterer,
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	)
+	if err != nil {
+		return nil, err
+	}
+	if err := Register(registerer, buildInfo); err != nil {
+		return nil, err
+	}
+	return registry, nil
+}
+
+// NewRegisterer returns the registerer of the registry prefixing the metrics with the namespace and subsystem of the config,
+// for the modules whose metrics belong to the service like the built-in ones. The config is optional.
+func NewRegisterer(registry *prometheus.Registry, config *MetricsConfig) prometheus.Registerer {
+	if config == nil {
+		return registry
+	}
+	return prometheus.WrapRegistererWithPrefix(metricPrefix(config), registry)
+}
+
+// RegisterOrExisting registers the collector, or returns the collector already registered with the same descriptors,
+// e.g. by another app sharing the registry, so the caller updates the metrics that are gathered
+func RegisterOrExisting[T prometheus.Collector](registerer prometheus.Registerer, collector T) (T, error) {
+	if err := registerer.Register(collector); err != nil {
+		var alreadyRegisteredErr prometheus.AlreadyRegisteredError
+		if !errors.As(err, &alreadyRegisteredErr) {
+			return collector, err
+		}
+		existing, ok := alreadyRegisteredErr.ExistingCollector.(T)
+		if !ok {
+			return collector, fmt.Errorf("error in registering collector: %T is already registered with the same descriptors", alreadyRegisteredErr.ExistingCollector)
+		}
+		return existing, nil
+	}
+	return collector, nil
+}
+
+// Register registers the collectors, collectors that are already registered are skipped instead of panicking
+func Register(registerer prometheus.Registerer, collectors ...prometheus.Collector) error {
+	for _, collector := range collectors {
+		if err := registerer.Register(collector); err != nil {
+			var alreadyRegisteredErr prometheus.AlreadyRegisteredError
+			if errors.As(err, &alreadyRegisteredErr) {
+				continue
+			}
+			return err
+		}
+	}
+	return nil
+}
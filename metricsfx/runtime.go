@@ -0,0 +1,131 @@
+package metricsfx
+
+import (
+	"math"
+	"runtime/metrics"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"go.uber.org/fx"
+	"go.uber.org/zap"
+)
+
+type RuntimeMetricsParams struct {
+	fx.In
+	Config   *MetricsConfig
+	Registry *prometheus.Registry
+	Logger   *zap.SugaredLogger `optional:"true"`
+}
+
+// RegisterRuntimeMetrics registers the collector of the runtime/metrics samples of the config, when it is enabled.
+// The names unknown to the Go version of the binary are skipped with a warning, so the config can list newer metrics.
+func RegisterRuntimeMetrics(p RuntimeMetricsParams) error {
+	if !p.Config.Runtime.Enabled {
+		return nil
+	}
+	collector, unknown := NewRuntimeMetricsCollector(p.Config.Runtime.Metrics)
+	if len(unknown) > 0 && p.Logger != nil {
+		p.Logger.Warnw("Unknown runtime metrics are not collected, see the runtime/metrics package of the Go version", "metrics", unknown)
+	}
+	return Register(prometheus.WrapRegistererWithPrefix(metricPrefix(p.Config), p.Registry), collector)
+}
+
+type runtimeMetric struct {
+	desc       *prometheus.Desc
+	cumulative bool
+}
+
+// runtimeMetricsCollector reads the samples of runtime/metrics on each scrape.
+// Cumulative values are counters, the other values are gauges and the distributions are histograms.
+type runtimeMetricsCollector struct {
+	names   []string
+	metrics map[string]runtimeMetric
+}
+
+// NewRuntimeMetricsCollector returns the collector of the runtime/metrics names, e.g. /sched/latencies:seconds,
+// and the names that are unknown or whose kind is not supported
+func NewRuntimeMetricsCollector(names []string) (prometheus.Collector, []string) {
+	descriptions := make(map[string]metrics.Description)
+	for _, description := range metrics.All() {
+		descriptions[description.Name] = description
+	}
+	collector := &runtimeMetricsCollector{metrics: make(map[string]runtimeMetric, len(names))}
+	var unknown []string
+	for _, name := range names {
+		description, ok := descriptions[name]
+		if !ok || description.Kind == metrics.KindBad {
+			unknown = append(unknown, name)
+			continue
+		}
+		if _, ok := collector.metrics[name]; ok {
+			continue
+		}
+		collector.names = append(collector.names, name)
+		collector.metrics[name] = runtimeMetric{
+			desc:       prometheus.NewDesc(runtimeMetricName(description), description.Description, nil, nil),
+			cumulative: description.Cumulative,
+		}
+	}
+	return collector, unknown
+}
+
+// runtimeMetricName converts a runtime/metrics name like the Go collector of client_golang,
+// e.g. /sched/latencies:seconds is go_sched_latencies_seconds and the counters end with _total
+func runtimeMetricName(description metrics.Description) string {
+	path, unit, _ := strings.Cut(description.Name, ":")
+	name := SanitizeMetricName("go" + strings.ReplaceAll(path, "/", "_") + "_" + unit)
+	if description.Cumulative && description.Kind != metrics.KindFloat64Histogram && !strings.HasSuffix(name, "_total") {
+		name += "_total"
+	}
+	return name
+}
+
+func (c *runtimeMetricsCollector) Describe(descs chan<- *prometheus.Desc) {
+	for _, name := range c.names {
+		descs <- c.metrics[name].desc
+	}
+}
+
+func (c *runtimeMetricsCollector) Collect(collected chan<- prometheus.Metric) {
+	samples := make([]metrics.Sample, len(c.names))
+	for i, name := range c.names {
+		samples[i].Name = name
+	}
+	metrics.Read(samples)
+	for _, sample := range samples {
+		metric := c.metrics[sample.Name]
+		valueType := prometheus.GaugeValue
+		if metric.cumulative {
+			valueType = prometheus.CounterValue
+		}
+		switch sample.Value.Kind() {
+		case metrics.KindUint64:
+			collected <- prometheus.MustNewConstMetric(metric.desc, valueType, float64(sample.Value.Uint64()))
+		case metrics.KindFloat64:
+			collected <- prometheus.MustNewConstMetric(metric.desc, valueType, sample.Value.Float64())
+		case metrics.KindFloat64Histogram:
+			count, sum, buckets := runtimeHistogram(sample.Value.Float64Histogram())
+			collected <- prometheus.MustNewConstHistogram(metric.desc, count, sum, buckets)
+		}
+	}
+}
+
+// runtimeHistogram converts the counts between the boundaries of the runtime histogram to cumulative Prometheus buckets.
+// The runtime does not record the sum, it is estimated with the middle of the finite buckets.
+func runtimeHistogram(histogram *metrics.Float64Histogram) (uint64, float64, map[float64]uint64) {
+	buckets := make(map[float64]uint64, len(histogram.Counts))
+	var count uint64
+	var sum float64
+	for i, bucketCount := range histogram.Counts {
+		count += bucketCount
+		lower, upper := histogram.Buckets[i], histogram.Buckets[i+1]
+		if !math.IsInf(lower, 0) && !math.IsInf(upper, 0) {
+			sum += (lower + upper) / 2 * float64(bucketCount)
+		}
+		// the +Inf bucket is implied by the count
+		if !math.IsInf(upper, 1) {
+			buckets[upper] = count
+		}
+	}
+	return count, sum, buckets
+}
@@ -0,0 +1,50 @@
+package metricsfx
+
+import (
+	"context"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"go.uber.org/fx"
+	"go.uber.org/zap"
+)
+
+type FinalScrapeParams struct {
+	fx.In
+	Lifecycle fx.Lifecycle
+	Config    *MetricsConfig
+	Registry  *prometheus.Registry
+	Logger    *zap.SugaredLogger `optional:"true"`
+}
+
+// RunFinalScrape registers the process_shutting_down gauge, which is set to 1 when the app stops.
+// With the Prometheus handler and a final scrape delay, the stop waits for the delay so that a last scrape
+// collects the terminal metrics. The hook is appended after the one of the http server, so it runs before the server is shut down.
+func RunFinalScrape(p FinalScrapeParams) error {
+	shuttingDown := prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "process_shutting_down",
+		Help: "Whether the process is shutting down, 1 during the stop of the app.",
+	})
+	if err := Register(prometheus.WrapRegistererWithPrefix(metricPrefix(p.Config), p.Registry), shuttingDown); err != nil {
+		return err
+	}
+	p.Lifecycle.Append(fx.Hook{
+		OnStop: func(ctx context.Context) error {
+			shuttingDown.Set(1)
+			if p.Config.DisableHandler || p.Config.FinalScrapeDelay <= 0 {
+				return nil
+			}
+			if p.Logger != nil {
+				p.Logger.Infow("Delaying shutdown for a final metrics scrape", "delay", p.Config.FinalScrapeDelay)
+			}
+			timer := time.NewTimer(p.Config.FinalScrapeDelay)
+			defer timer.Stop()
+			select {
+			case <-timer.C:
+			case <-ctx.Done():
+			}
+			return nil
+		},
+	})
+	return nil
+}
@@ -0,0 +1,150 @@
+package openapifx
+
+import (
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/fx"
+
+	"github.com/prismedic/scalpel/config"
+	"github.com/prismedic/scalpel/routerfx"
+)
+
+// Module serves an OpenAPI 3 document of the routes registered on the router at /openapi.json.
+// It is not included in the scalpel module, add it to the app to opt in.
+var Module = fx.Module("openapi",
+	fx.Invoke(
+		fx.Annotate(
+			RegisterOpenAPI,
+			fx.ParamTags(``, `group:"controllerRoutes"`, `group:"routeGroups"`),
+		),
+	),
+)
+
+const OpenAPIPath = "/openapi.json"
+
+// methods of the operations in OpenAPI 3.0, routes registered for any method also have CONNECT
+var operationMethods = map[string]bool{
+	http.MethodGet:     true,
+	http.MethodPut:     true,
+	http.MethodPost:    true,
+	http.MethodDelete:  true,
+	http.MethodOptions: true,
+	http.MethodHead:    true,
+	http.MethodPatch:   true,
+	http.MethodTrace:   true,
+}
+
+// RegisterOpenAPI adds the OpenAPI route to the router after the other routes are registered.
+// The document is built from the routes of the router, with the details of the controllers implementing routerfx.DocumentedRoute.
+func RegisterOpenAPI(handler http.Handler, controllerRoutes []routerfx.ControllerRoute, groups []routerfx.Group) {
+	router, ok := handler.(*gin.Engine)
+	if !ok {
+		return
+	}
+	docs := make(map[string]routerfx.RouteDoc)
+	for _, route := range controllerRoutes {
+		documented, ok := route.(routerfx.DocumentedRoute)
+		if !ok {
+			continue
+		}
+		for _, doc := range documented.RouteDocs() {
+			fullPath := routerfx.ControllerPath(route, doc.Path, groups)
+			docs[operationKey(doc.Method, fullPath)] = doc
+		}
+	}
+
+	router.GET(OpenAPIPath, func(c *gin.Context) {
+		c.JSON(http.StatusOK, newDocument(router.Routes(), docs))
+	})
+}
+
+func operationKey(method string, path string) string {
+	return strings.ToUpper(method) + " " + path
+}
+
+// openAPIPath converts the parameters of a gin path to the OpenAPI syntax, e.g. /users/:id to /users/{id}
+func openAPIPath(path string) (string, []string) {
+	segments := strings.Split(path, "/")
+	var parameters []string
+	for i, segment := range segments {
+		if strings.HasPrefix(segment, ":") || strings.HasPrefix(segment, "*") {
+			name := segment[1:]
+			parameters = append(parameters, name)
+			segments[i] = "{" + name + "}"
+		}
+	}
+	return strings.Join(segments, "/"), parameters
+}
+
+func newDocument(routes gin.RoutesInfo, docs map[string]routerfx.RouteDoc) map[string]any {
+	sort.Slice(routes, func(i, j int) bool {
+		if routes[i].Path != routes[j].Path {
+			return routes[i].Path < routes[j].Path
+		}
+		return routes[i].Method < routes[j].Method
+	})
+
+	paths := make(map[string]any)
+	for _, route := range routes {
+		if route.Path == OpenAPIPath || !operationMethods[route.Method] {
+			continue
+		}
+		path, parameters := openAPIPath(route.Path)
+		operations, ok := paths[path].(map[string]any)
+		if !ok {
+			operations = make(map[string]any)
+			paths[path] = operations
+		}
+		operations[strings.ToLower(route.Method)] = newOperation(docs[operationKey(route.Method, route.Path)], parameters)
+	}
+
+	return map[string]any{
+		"openapi": "3.0.3",
+		"info": map[string]any{
+			"title":   config.GetPackageName(),
+			"version": "v1",
+		},
+		"paths": paths,
+	}
+}
+
+func newOperation(doc routerfx.RouteDoc, parameters []string) map[string]any {
+	operation := make(map[string]any)
+	if doc.Summary != "" {
+		operation["summary"] = doc.Summary
+	}
+	if len(parameters) > 0 {
+		pathParameters := make([]any, 0, len(parameters))
+		for _, name := range parameters {
+			pathParameters = append(pathParameters, map[string]any{
+				"name":     name,
+				"in":       "path",
+				"required": true,
+				"schema":   map[string]any{"type": "string"},
+			})
+		}
+		operation["parameters"] = pathParameters
+	}
+	if doc.Request != nil {
+		operation["requestBody"] = map[string]any{
+			"content": jsonContent(doc.Request),
+		}
+	}
+	response := map[string]any{"description": "OK"}
+	if doc.Response != nil {
+		response["content"] = jsonContent(doc.Response)
+	}
+	operation["responses"] = map[string]any{"200": response}
+	return operation
+}
+
+func jsonContent(value any) map[string]any {
+	return map[string]any{
+		"application/json": map[string]any{
+			"schema": newSchema(value),
+		},
+	}
+}
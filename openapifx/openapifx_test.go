@@ -0,0 +1,90 @@
+package openapifx_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/prismedic/scalpel/openapifx"
+	"github.com/prismedic/scalpel/routerfx"
+)
+
+type testUser struct {
+	ID   int    `json:"id"`
+	Name string `json:"name,omitempty"`
+}
+
+type testController struct{}
+
+func (tc *testController) RegisterControllerRoutes(rg *gin.RouterGroup) {
+	rg.GET("/:id", func(c *gin.Context) {})
+	rg.DELETE("/:id", func(c *gin.Context) {})
+}
+
+func (tc *testController) RoutePattern() string {
+	return "/users"
+}
+
+func (tc *testController) RouteDocs() []routerfx.RouteDoc {
+	return []routerfx.RouteDoc{
+		{Method: http.MethodGet, Path: "/:id", Summary: "Get a user", Response: testUser{}},
+	}
+}
+
+func TestRegisterOpenAPI(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	controller := &testController{}
+	controller.RegisterControllerRoutes(router.Group(routerfx.ControllerPath(controller, "", nil)))
+	openapifx.RegisterOpenAPI(router, []routerfx.ControllerRoute{controller}, nil)
+
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, openapifx.OpenAPIPath, nil))
+	var document struct {
+		Paths map[string]map[string]struct {
+			Summary    string `json:"summary"`
+			Parameters []struct {
+				Name string `json:"name"`
+			} `json:"parameters"`
+			Responses map[string]struct {
+				Content map[string]struct {
+					Schema struct {
+						Properties map[string]any `json:"properties"`
+					} `json:"schema"`
+				} `json:"content"`
+			} `json:"responses"`
+		} `json:"paths"`
+	}
+	if err := json.Unmarshal(recorder.Body.Bytes(), &document); err != nil {
+		t.Fatalf("failed to parse document %s: %v", recorder.Body.String(), err)
+	}
+
+	operations, ok := document.Paths["/v1/users/{id}"]
+	if !ok {
+		t.Fatalf("user path not found in %v", document.Paths)
+	}
+	t.Run("Test documented operation", func(t *testing.T) {
+		get := operations["get"]
+		if get.Summary != "Get a user" {
+			t.Errorf("unexpected summary, got %q", get.Summary)
+		}
+		if len(get.Parameters) != 1 || get.Parameters[0].Name != "id" {
+			t.Errorf("unexpected parameters %+v", get.Parameters)
+		}
+		properties := get.Responses["200"].Content["application/json"].Schema.Properties
+		if _, ok := properties["id"]; !ok {
+			t.Errorf("id not found in response properties %v", properties)
+		}
+		if _, ok := properties["name"]; !ok {
+			t.Errorf("name not found in response properties %v", properties)
+		}
+	})
+	t.Run("Test undocumented operation", func(t *testing.T) {
+		if _, ok := operations["delete"]; !ok {
+			t.Errorf("delete operation not found in %v", operations)
+		}
+	})
+}
@@ -0,0 +1,90 @@
+package openapifx
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"time"
+)
+
+var (
+	timeType      = reflect.TypeOf(time.Time{})
+	marshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+)
+
+// newSchema builds the JSON schema of the type of the value from its json tags
+func newSchema(value any) map[string]any {
+	return schemaOf(reflect.TypeOf(value), make(map[reflect.Type]bool))
+}
+
+func schemaOf(valueType reflect.Type, visiting map[reflect.Type]bool) map[string]any {
+	for valueType.Kind() == reflect.Pointer {
+		valueType = valueType.Elem()
+	}
+	if valueType == timeType {
+		return map[string]any{"type": "string", "format": "date-time"}
+	}
+	// the JSON of custom marshalers is unknown
+	if valueType.Implements(marshalerType) || reflect.PointerTo(valueType).Implements(marshalerType) {
+		return map[string]any{}
+	}
+
+	switch valueType.Kind() {
+	case reflect.Bool:
+		return map[string]any{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]any{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]any{"type": "number"}
+	case reflect.String:
+		return map[string]any{"type": "string"}
+	case reflect.Slice, reflect.Array:
+		if valueType.Elem().Kind() == reflect.Uint8 {
+			return map[string]any{"type": "string", "format": "byte"}
+		}
+		return map[string]any{"type": "array", "items": schemaOf(valueType.Elem(), visiting)}
+	case reflect.Map:
+		return map[string]any{"type": "object", "additionalProperties": schemaOf(valueType.Elem(), visiting)}
+	case reflect.Struct:
+		// recursive types are not expanded again
+		if visiting[valueType] {
+			return map[string]any{"type": "object"}
+		}
+		visiting[valueType] = true
+		defer delete(visiting, valueType)
+		properties := make(map[string]any)
+		addProperties(valueType, properties, visiting)
+		return map[string]any{"type": "object", "properties": properties}
+	default:
+		return map[string]any{}
+	}
+}
+
+func addProperties(structType reflect.Type, properties map[string]any, visiting map[reflect.Type]bool) {
+	for i := 0; i < structType.NumField(); i++ {
+		field := structType.Field(i)
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+		// fields of embedded structs without a name are promoted like encoding/json does
+		if field.Anonymous && name == "" {
+			embeddedType := field.Type
+			if embeddedType.Kind() == reflect.Pointer {
+				embeddedType = embeddedType.Elem()
+			}
+			if embeddedType.Kind() == reflect.Struct {
+				addProperties(embeddedType, properties, visiting)
+				continue
+			}
+		}
+		if !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		properties[name] = schemaOf(field.Type, visiting)
+	}
+}
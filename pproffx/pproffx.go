@@ -0,0 +1,106 @@
+package pproffx
+
+import (
+	"net/http/pprof"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/spf13/viper"
+	"go.uber.org/fx"
+	"go.uber.org/zap"
+
+	"github.com/prismedic/scalpel/metricsfx"
+	"github.com/prismedic/scalpel/routerfx"
+)
+
+// Module registers the net/http/pprof handlers under /debug/pprof/ when pprof.enabled is true and a token protects them.
+// It is not included in the scalpel module, add it to the app to opt in.
+var Module = fx.Module("pprof",
+	fx.Provide(
+		fx.Annotate(
+			newHandlerRoutes,
+			fx.ParamTags(``, `optional:"true"`, `optional:"true"`),
+			fx.ResultTags(`group:"handlerRoutes,flatten"`),
+		),
+	),
+)
+
+// PprofPath is the prefix of the profiling routes, it is excluded from the request timeout by default
+const PprofPath = "/debug/pprof/"
+
+type PprofConfig struct {
+	// Enabled registers the profiling routes, they are not served by default
+	Enabled bool `mapstructure:"enabled" yaml:"enabled"`
+	// Server is the name of the server of http.servers serving the profiles, the default server when empty.
+	// The profiles and traces of ?seconds=N need a write timeout above N: the default server has the 60s of
+	// http.timeouts.write, while a named server has no write timeout unless http.servers.<name>.timeouts.write is set.
+	Server string `mapstructure:"server" yaml:"server"`
+	Auth   struct {
+		// Token is the bearer token required to access the profiles, the token of the metrics is used when it is empty.
+		// The profiles are not served without a token.
+		Token string `mapstructure:"token" yaml:"token"`
+	} `mapstructure:"auth" yaml:"auth"`
+}
+
+func init() {
+	// config must have a default value for viper to load config from env variables
+	viper.SetDefault("pprof.enabled", false)
+	viper.SetDefault("pprof.server", "")
+	viper.SetDefault("pprof.auth.token", "")
+}
+
+// newHandlerRoutes registers the profiling handler if it is enabled and has a token, the metrics config is optional
+func newHandlerRoutes(config *PprofConfig, metricsConfig *metricsfx.MetricsConfig, logger *zap.SugaredLogger) []routerfx.HandlerRoute {
+	if !config.Enabled {
+		return nil
+	}
+	token := config.Auth.Token
+	if token == "" && metricsConfig != nil {
+		token = metricsConfig.Auth.Token
+	}
+	// the profiles and the heap dumps expose the memory of the process
+	if token == "" {
+		if logger != nil {
+			logger.Warn("Pprof routes are not registered, pprof.auth.token or metrics.auth.token is required to protect them")
+		}
+		return nil
+	}
+	return []routerfx.HandlerRoute{&PprofHandler{token: token, server: config.Server}}
+}
+
+type PprofHandler struct {
+	token  string
+	server string
+}
+
+// Handler serves the index, the named profiles and the endpoints of net/http/pprof that are not profiles
+func (ph *PprofHandler) Handler() gin.HandlerFunc {
+	return metricsfx.WithBearerToken(ph.token, func(c *gin.Context) {
+		switch strings.TrimPrefix(c.Param("profile"), "/") {
+		case "cmdline":
+			pprof.Cmdline(c.Writer, c.Request)
+		case "profile":
+			pprof.Profile(c.Writer, c.Request)
+		case "symbol":
+			pprof.Symbol(c.Writer, c.Request)
+		case "trace":
+			pprof.Trace(c.Writer, c.Request)
+		default:
+			// the index serves the named profiles from the path, e.g. /debug/pprof/heap
+			pprof.Index(c.Writer, c.Request)
+		}
+	})
+}
+
+func (ph *PprofHandler) RoutePattern() string {
+	return PprofPath + "*profile"
+}
+
+func (ph *PprofHandler) RouteServer() string {
+	return ph.server
+}
+
+var (
+	_ routerfx.HandlerRoute = (*PprofHandler)(nil)
+	_ routerfx.ServerRoute  = (*PprofHandler)(nil)
+)
@@ -0,0 +1,86 @@
+package pproffx_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"go.uber.org/fx"
+	"go.uber.org/fx/fxtest"
+
+	"github.com/prismedic/scalpel/metricsfx"
+	"github.com/prismedic/scalpel/pproffx"
+	"github.com/prismedic/scalpel/routerfx"
+)
+
+func newTestRouter(t *testing.T, options ...fx.Option) http.Handler {
+	var router http.Handler
+	app := fxtest.New(t, append(options,
+		pproffx.Module,
+		routerfx.Module,
+		fx.Supply(&routerfx.Config{}),
+		fx.Populate(&router),
+	)...)
+	app.RequireStart()
+	t.Cleanup(app.RequireStop)
+	return router
+}
+
+func serve(router http.Handler, path string, token string) *httptest.ResponseRecorder {
+	request := httptest.NewRequest(http.MethodGet, path, nil)
+	if token != "" {
+		request.Header.Set("Authorization", "Bearer "+token)
+	}
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, request)
+	return recorder
+}
+
+func TestPprof(t *testing.T) {
+	t.Run("Test disabled by default", func(t *testing.T) {
+		router := newTestRouter(t, fx.Supply(&pproffx.PprofConfig{}))
+		if code := serve(router, "/debug/pprof/", "").Code; code != http.StatusNotFound {
+			t.Errorf("unexpected status code, got %d, expected %d", code, http.StatusNotFound)
+		}
+	})
+	t.Run("Test disabled without token", func(t *testing.T) {
+		router := newTestRouter(t, fx.Supply(&pproffx.PprofConfig{Enabled: true}, &metricsfx.MetricsConfig{}))
+		if code := serve(router, "/debug/pprof/", "").Code; code != http.StatusNotFound {
+			t.Errorf("unexpected status code, got %d, expected %d", code, http.StatusNotFound)
+		}
+		if code := serve(router, "/debug/pprof/heap", "").Code; code != http.StatusNotFound {
+			t.Errorf("unexpected heap status code, got %d, expected %d", code, http.StatusNotFound)
+		}
+	})
+	t.Run("Test profiles", func(t *testing.T) {
+		config := &pproffx.PprofConfig{Enabled: true}
+		config.Auth.Token = "secret"
+		router := newTestRouter(t, fx.Supply(config))
+		recorder := serve(router, "/debug/pprof/", "secret")
+		if recorder.Code != http.StatusOK || !strings.Contains(recorder.Body.String(), "goroutine") {
+			t.Errorf("unexpected index response %d: %s", recorder.Code, recorder.Body.String())
+		}
+		recorder = serve(router, "/debug/pprof/goroutine?debug=1", "secret")
+		if recorder.Code != http.StatusOK || !strings.Contains(recorder.Body.String(), "goroutine profile") {
+			t.Errorf("unexpected goroutine profile response %d: %s", recorder.Code, recorder.Body.String())
+		}
+		if code := serve(router, "/debug/pprof/cmdline", "secret").Code; code != http.StatusOK {
+			t.Errorf("unexpected cmdline status code, got %d, expected %d", code, http.StatusOK)
+		}
+		if code := serve(router, "/debug/pprof/cmdline", "").Code; code != http.StatusUnauthorized {
+			t.Errorf("unexpected cmdline status code without token, got %d, expected %d", code, http.StatusUnauthorized)
+		}
+	})
+	t.Run("Test metrics token", func(t *testing.T) {
+		metricsConfig := &metricsfx.MetricsConfig{}
+		metricsConfig.Auth.Token = "secret"
+		router := newTestRouter(t, fx.Supply(&pproffx.PprofConfig{Enabled: true}, metricsConfig))
+		if code := serve(router, "/debug/pprof/", "").Code; code != http.StatusUnauthorized {
+			t.Errorf("unexpected status code without token, got %d, expected %d", code, http.StatusUnauthorized)
+		}
+		if code := serve(router, "/debug/pprof/", "secret").Code; code != http.StatusOK {
+			t.Errorf("unexpected status code with token, got %d, expected %d", code, http.StatusOK)
+		}
+	})
+}
@@ -0,0 +1,208 @@
+package routerfx
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/fx"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"gopkg.in/natefinch/lumberjack.v2"
+
+	"github.com/prismedic/scalpel/logger"
+)
+
+const (
+	// JSONAccessLogFormat writes the access log entries with zap, like the other log entries
+	JSONAccessLogFormat = "json"
+	// CombinedAccessLogFormat writes the access log entries in the Apache combined log format
+	CombinedAccessLogFormat = "combined"
+)
+
+// combinedTimeFormat is the timestamp format of the Apache access logs
+const combinedTimeFormat = "02/Jan/2006:15:04:05 -0700"
+
+// AccessLogConfig configures the format and the destination of the access log
+type AccessLogConfig struct {
+	Format string `mapstructure:"format" yaml:"format" validate:"required,oneof=json combined"`
+	// File writes the access log to its own rotated file, disabled when the block is absent.
+	// Without it, json entries are written with the application logger and combined entries to stdout.
+	File *AccessLogFileConfig `mapstructure:"file" yaml:"file,omitempty"`
+	// IgnorePaths are path prefixes that are not written to the access log
+	IgnorePaths []string `mapstructure:"ignore_paths" yaml:"ignore_paths"`
+}
+
+// accessLogConfig returns the access log config, with the paths of the deprecated router.access_log_ignore_paths
+// when they are set
+func (c *Config) accessLogConfig(logger *zap.SugaredLogger) AccessLogConfig {
+	config := c.AccessLog
+	if len(c.AccessLogIgnorePaths) > 0 {
+		if logger != nil {
+			logger.Warn("router.access_log_ignore_paths is deprecated, use router.access_log.ignore_paths")
+		}
+		config.IgnorePaths = c.AccessLogIgnorePaths
+	}
+	return config
+}
+
+// AccessLogFileConfig configures the file of the access log, rotated by lumberjack.
+// Zero rotation values fall back to the lumberjack defaults (100MB, keep all backups forever).
+type AccessLogFileConfig struct {
+	Path       string `mapstructure:"path" yaml:"path" validate:"required"`
+	Name       string `mapstructure:"name" yaml:"name" validate:"required,excludesall=/\\"`
+	MaxSizeMB  int    `mapstructure:"max_size_mb" yaml:"max_size_mb" validate:"min=0"`
+	MaxBackups int    `mapstructure:"max_backups" yaml:"max_backups" validate:"min=0"`
+	MaxAgeDays int    `mapstructure:"max_age_days" yaml:"max_age_days" validate:"min=0"`
+	Compress   bool   `mapstructure:"compress" yaml:"compress"`
+}
+
+// newAccessLogWriter returns the rotating writer of the file, which is closed on stop when there is a lifecycle
+func newAccessLogWriter(config *AccessLogFileConfig, lifecycle fx.Lifecycle) (io.Writer, error) {
+	filename, err := logger.PrepareFile(config.Path, config.Name)
+	if err != nil {
+		return nil, fmt.Errorf("error in access log file: %w", err)
+	}
+
+	writer := &lumberjack.Logger{
+		Filename:   filename,
+		MaxSize:    config.MaxSizeMB,
+		MaxBackups: config.MaxBackups,
+		MaxAge:     config.MaxAgeDays,
+		Compress:   config.Compress,
+	}
+	if lifecycle != nil {
+		lifecycle.Append(fx.Hook{
+			OnStop: func(context.Context) error {
+				return writer.Close()
+			},
+		})
+	}
+	return writer, nil
+}
+
+// newAccessLogger returns the access log middleware for the config, or nil when json entries have no logger to write to
+func newAccessLogger(config AccessLogConfig, logger *zap.SugaredLogger, lifecycle fx.Lifecycle) (gin.HandlerFunc, error) {
+	var writer io.Writer
+	if config.File != nil {
+		var err error
+		writer, err = newAccessLogWriter(config.File, lifecycle)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if config.Format == CombinedAccessLogFormat {
+		if writer == nil {
+			writer = os.Stdout
+		}
+		return NewCombinedLogger(writer, config.IgnorePaths), nil
+	}
+	if writer != nil {
+		core := zapcore.NewCore(
+			zapcore.NewJSONEncoder(zap.NewProductionEncoderConfig()),
+			zapcore.AddSync(writer),
+			zapcore.DebugLevel,
+		)
+		logger = zap.New(core).Sugar()
+	}
+	if logger == nil {
+		return nil, nil
+	}
+	return NewRequestLogger(logger, config.IgnorePaths), nil
+}
+
+// NewCombinedLogger returns a middleware writing an access log line in the Apache combined log format for every request.
+// Requests with a path starting with any of ignorePaths are not logged.
+func NewCombinedLogger(writer io.Writer, ignorePaths []string) gin.HandlerFunc {
+	var mutex sync.Mutex
+	return func(c *gin.Context) {
+		start := time.Now()
+		path := c.Request.URL.Path
+		c.Next()
+
+		for _, ignorePath := range ignorePaths {
+			if strings.HasPrefix(path, ignorePath) {
+				return
+			}
+		}
+
+		line := formatCombined(c, start)
+		mutex.Lock()
+		defer mutex.Unlock()
+		_, _ = io.WriteString(writer, line)
+	}
+}
+
+// formatCombined formats the request as `host ident user [time] "request" status bytes "referer" "user agent"`
+func formatCombined(c *gin.Context, start time.Time) string {
+	user, _, _ := c.Request.BasicAuth()
+	requestURI := c.Request.RequestURI
+	if requestURI == "" {
+		requestURI = c.Request.URL.RequestURI()
+	}
+	size := "-"
+	if c.Writer.Size() > 0 {
+		size = strconv.Itoa(c.Writer.Size())
+	}
+
+	var builder strings.Builder
+	builder.WriteString(escapeCombinedField(c.ClientIP()))
+	builder.WriteString(" - ")
+	builder.WriteString(escapeCombinedField(user))
+	builder.WriteString(" [")
+	builder.WriteString(start.Format(combinedTimeFormat))
+	builder.WriteString("] \"")
+	builder.WriteString(escapeCombined(c.Request.Method + " " + requestURI + " " + c.Request.Proto))
+	builder.WriteString("\" ")
+	builder.WriteString(strconv.Itoa(c.Writer.Status()))
+	builder.WriteString(" ")
+	builder.WriteString(size)
+	builder.WriteString(" \"")
+	builder.WriteString(escapeCombinedHeader(c.Request.Referer()))
+	builder.WriteString("\" \"")
+	builder.WriteString(escapeCombinedHeader(c.Request.UserAgent()))
+	builder.WriteString("\"\n")
+	return builder.String()
+}
+
+// escapeCombinedField escapes a field written without quotes, spaces are escaped so the fields stay separated
+func escapeCombinedField(value string) string {
+	if value == "" {
+		return "-"
+	}
+	return strings.ReplaceAll(escapeCombined(value), " ", `\x20`)
+}
+
+// escapeCombinedHeader escapes a quoted header value, missing headers are written as "-"
+func escapeCombinedHeader(value string) string {
+	if value == "" {
+		return "-"
+	}
+	return escapeCombined(value)
+}
+
+// escapeCombined escapes quotes and backslashes with a backslash and other non-printable bytes as \xhh like Apache does,
+// so a value cannot end its quoted field or forge a new line
+func escapeCombined(value string) string {
+	var builder strings.Builder
+	for i := 0; i < len(value); i++ {
+		b := value[i]
+		switch {
+		case b == '"' || b == '\\':
+			builder.WriteByte('\\')
+			builder.WriteByte(b)
+		case b < ' ' || b >= 0x7f:
+			fmt.Fprintf(&builder, `\x%02x`, b)
+		default:
+			builder.WriteByte(b)
+		}
+	}
+	return builder.String()
+}
@@ -0,0 +1,120 @@
+package routerfx_test
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/fx/fxtest"
+
+	"github.com/prismedic/scalpel/routerfx"
+)
+
+func TestCombinedLogger(t *testing.T) {
+	gin.SetMode(gin.ReleaseMode)
+
+	t.Run("Test combined format", func(t *testing.T) {
+		var buffer bytes.Buffer
+		router := gin.New()
+		router.Use(routerfx.NewCombinedLogger(&buffer, []string{"/metrics"}))
+		router.GET("/users", func(c *gin.Context) {
+			c.String(http.StatusOK, "hello")
+		})
+		router.GET("/metrics", func(c *gin.Context) {})
+
+		request := httptest.NewRequest(http.MethodGet, "/users?page=2", nil)
+		request.RemoteAddr = "192.0.2.1:1234"
+		request.SetBasicAuth("jane doe", "secret")
+		request.Header.Set("Referer", "https://example.com/")
+		request.Header.Set("User-Agent", "agent \"quoted\"\nforged\\line")
+		router.ServeHTTP(httptest.NewRecorder(), request)
+		router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+		expected := regexp.MustCompile(`^192\.0\.2\.1 - jane\\x20doe \[\d{2}/\w{3}/\d{4}:\d{2}:\d{2}:\d{2} [+-]\d{4}\] ` +
+			`"GET /users\?page=2 HTTP/1\.1" 200 5 "https://example\.com/" "agent \\"quoted\\"\\x0aforged\\\\line"\n$`)
+		if !expected.MatchString(buffer.String()) {
+			t.Errorf("unexpected access log line: %q", buffer.String())
+		}
+	})
+	t.Run("Test empty fields", func(t *testing.T) {
+		var buffer bytes.Buffer
+		router := gin.New()
+		router.Use(routerfx.NewCombinedLogger(&buffer, nil))
+		router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/missing", nil))
+
+		if !strings.HasSuffix(buffer.String(), `"GET /missing HTTP/1.1" 404 - "-" "-"`+"\n") {
+			t.Errorf("unexpected access log line: %q", buffer.String())
+		}
+		if !strings.Contains(buffer.String(), " - - [") {
+			t.Errorf("missing user placeholder in access log line: %q", buffer.String())
+		}
+	})
+	t.Run("Test access log file", func(t *testing.T) {
+		dir := t.TempDir()
+		config := &routerfx.Config{
+			AccessLog: routerfx.AccessLogConfig{
+				Format: routerfx.CombinedAccessLogFormat,
+				File:   &routerfx.AccessLogFileConfig{Path: dir, Name: "access.log"},
+			},
+		}
+		lifecycle := fxtest.NewLifecycle(t)
+		result, err := routerfx.New(routerfx.Params{Config: config, Lifecycle: lifecycle})
+		if err != nil {
+			t.Fatalf("failed to create router: %v", err)
+		}
+		lifecycle.RequireStart()
+		result.Router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/missing", nil))
+		// the file is closed on stop
+		lifecycle.RequireStop()
+
+		content, err := os.ReadFile(path.Join(dir, "access.log"))
+		if err != nil {
+			t.Fatalf("failed to read access log file: %v", err)
+		}
+		if !strings.Contains(string(content), `"GET /missing HTTP/1.1" 404`) {
+			t.Errorf("unexpected access log file content: %q", content)
+		}
+	})
+	t.Run("Test ignored paths", func(t *testing.T) {
+		for _, test := range []struct {
+			name   string
+			config routerfx.Config
+		}{
+			{"Test ignore paths", routerfx.Config{AccessLog: routerfx.AccessLogConfig{IgnorePaths: []string{"/ignored"}}}},
+			{"Test deprecated ignore paths", routerfx.Config{
+				AccessLog:            routerfx.AccessLogConfig{IgnorePaths: []string{"/metrics"}},
+				AccessLogIgnorePaths: []string{"/ignored"},
+			}},
+		} {
+			t.Run(test.name, func(t *testing.T) {
+				dir := t.TempDir()
+				config := test.config
+				config.AccessLog.Format = routerfx.CombinedAccessLogFormat
+				config.AccessLog.File = &routerfx.AccessLogFileConfig{Path: dir, Name: "access.log"}
+				lifecycle := fxtest.NewLifecycle(t)
+				result, err := routerfx.New(routerfx.Params{Config: &config, Lifecycle: lifecycle})
+				if err != nil {
+					t.Fatalf("failed to create router: %v", err)
+				}
+				lifecycle.RequireStart()
+				result.Router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/ignored", nil))
+				result.Router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/logged", nil))
+				lifecycle.RequireStop()
+
+				content, err := os.ReadFile(path.Join(dir, "access.log"))
+				if err != nil {
+					t.Fatalf("failed to read access log file: %v", err)
+				}
+				if strings.Contains(string(content), "/ignored") || !strings.Contains(string(content), "/logged") {
+					t.Errorf("unexpected access log file content: %q", content)
+				}
+			})
+		}
+	})
+}
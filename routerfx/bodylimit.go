@@ -0,0 +1,86 @@
+package routerfx
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// bodyLimitKey is the key of the bodyLimit of the request in the gin context
+const bodyLimitKey = "routerfx.body_limit"
+
+// bodyLimit keeps the original body of the request, so a route can replace the limit of the middleware
+type bodyLimit struct {
+	original io.ReadCloser
+	reader   *maxBodyReader
+}
+
+// maxBodyReader records whether the handlers read past the limit of http.MaxBytesReader
+type maxBodyReader struct {
+	io.ReadCloser
+	limit    int64
+	exceeded bool
+}
+
+func (r *maxBodyReader) Read(p []byte) (int, error) {
+	n, err := r.ReadCloser.Read(p)
+	var maxBytesErr *http.MaxBytesError
+	if errors.As(err, &maxBytesErr) {
+		r.exceeded = true
+	}
+	return n, err
+}
+
+// set limits the body of the request to maxBytes, zero removes the limit
+func (l *bodyLimit) set(c *gin.Context, maxBytes int64) {
+	if maxBytes == 0 {
+		l.reader = nil
+		c.Request.Body = l.original
+		return
+	}
+	l.reader = &maxBodyReader{ReadCloser: http.MaxBytesReader(c.Writer, l.original, maxBytes), limit: maxBytes}
+	c.Request.Body = l.reader
+}
+
+// NewMaxBodyBytes returns a middleware limiting the size of the request bodies to maxBytes with http.MaxBytesReader.
+// Handlers reading past the limit get an *http.MaxBytesError, which AbortWithError answers with 413.
+// The middleware also answers 413 when the handlers return without a response after hitting the limit.
+// Routes accepting larger bodies, e.g. uploads, replace the limit with WithMaxBodyBytes.
+func NewMaxBodyBytes(maxBytes int64) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.Request.Body == nil || c.Request.Body == http.NoBody {
+			c.Next()
+			return
+		}
+		limit := &bodyLimit{original: c.Request.Body}
+		limit.set(c, maxBytes)
+		c.Set(bodyLimitKey, limit)
+		c.Next()
+		if reader := limit.reader; reader != nil && reader.exceeded && !c.Writer.Written() {
+			AbortWithStatusError(c, http.StatusRequestEntityTooLarge, CodeTooLarge,
+				fmt.Sprintf("request body is larger than %d bytes", reader.limit))
+		}
+	}
+}
+
+// WithMaxBodyBytes returns a route middleware replacing the limit of router.max_body_bytes with maxBytes,
+// zero removes the limit. The body is limited to maxBytes when the router has no limit.
+//
+//	rg.POST("/upload", routerfx.WithMaxBodyBytes(1<<30), uc.upload)
+func WithMaxBodyBytes(maxBytes int64) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if value, ok := c.Get(bodyLimitKey); ok {
+			value.(*bodyLimit).set(c, maxBytes)
+			c.Next()
+			return
+		}
+		if maxBytes == 0 {
+			c.Next()
+			return
+		}
+		NewMaxBodyBytes(maxBytes)(c)
+	}
+}
@@ -0,0 +1,68 @@
+package routerfx_test
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/prismedic/scalpel/routerfx"
+)
+
+func postBody(path string, size int) *httptest.ResponseRecorder {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(routerfx.NewMaxBodyBytes(10))
+	router.POST("/bind", func(c *gin.Context) {
+		var body map[string]string
+		if err := c.ShouldBindJSON(&body); err != nil {
+			routerfx.AbortWithError(c, err)
+			return
+		}
+		c.Status(http.StatusNoContent)
+	})
+	// returns without a response after hitting the limit
+	router.POST("/read", func(c *gin.Context) {
+		_, _ = io.ReadAll(c.Request.Body)
+	})
+	router.POST("/upload", routerfx.WithMaxBodyBytes(100), func(c *gin.Context) {
+		if _, err := io.ReadAll(c.Request.Body); err != nil {
+			routerfx.AbortWithError(c, err)
+			return
+		}
+		c.Status(http.StatusNoContent)
+	})
+
+	body := `{"a":"` + strings.Repeat("a", size) + `"}`
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, path, strings.NewReader(body)))
+	return recorder
+}
+
+func TestNewMaxBodyBytes(t *testing.T) {
+	for _, test := range []struct {
+		name         string
+		path         string
+		size         int
+		expectedCode int
+	}{
+		{name: "Test body below the limit", path: "/bind", size: 1, expectedCode: http.StatusNoContent},
+		{name: "Test body above the limit", path: "/bind", size: 20, expectedCode: http.StatusRequestEntityTooLarge},
+		{name: "Test handler without response", path: "/read", size: 20, expectedCode: http.StatusRequestEntityTooLarge},
+		{name: "Test route limit", path: "/upload", size: 50, expectedCode: http.StatusNoContent},
+		{name: "Test body above the route limit", path: "/upload", size: 200, expectedCode: http.StatusRequestEntityTooLarge},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			recorder := postBody(test.path, test.size)
+			if recorder.Code != test.expectedCode {
+				t.Errorf("unexpected status code, got %d, expected %d: %s", recorder.Code, test.expectedCode, recorder.Body.String())
+			}
+			if test.expectedCode == http.StatusRequestEntityTooLarge && !strings.Contains(recorder.Body.String(), routerfx.CodeTooLarge) {
+				t.Errorf("unexpected body %s", recorder.Body.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,254 @@
+package routerfx
+
+import (
+	"compress/flate"
+	"compress/gzip"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CompressionConfig configures the gzip and deflate compression of the responses
+type CompressionConfig struct {
+	Enabled bool `mapstructure:"enabled" yaml:"enabled"`
+	// MinSize is the smallest response body in bytes that is compressed
+	MinSize int `mapstructure:"min_size" yaml:"min_size" validate:"min=0"`
+	// ExcludePaths are path prefixes whose responses are never compressed
+	ExcludePaths []string `mapstructure:"exclude_paths" yaml:"exclude_paths"`
+}
+
+const (
+	gzipEncoding    = "gzip"
+	deflateEncoding = "deflate"
+)
+
+// content types that are already compressed, compressing them again only costs CPU
+var compressedContentTypes = []string{
+	"image/png",
+	"image/jpeg",
+	"image/gif",
+	"image/webp",
+	"video/",
+	"audio/",
+	"font/woff",
+	"application/zip",
+	"application/gzip",
+	"application/x-gzip",
+	"application/x-bzip2",
+	"application/x-7z-compressed",
+	"application/zstd",
+}
+
+var (
+	gzipWriterPool = sync.Pool{
+		New: func() any {
+			return gzip.NewWriter(io.Discard)
+		},
+	}
+	flateWriterPool = sync.Pool{
+		New: func() any {
+			writer, _ := flate.NewWriter(io.Discard, flate.DefaultCompression)
+			return writer
+		},
+	}
+)
+
+// NewCompression returns a middleware compressing the responses with the encoding accepted by the client.
+// Responses smaller than the minimum size, with an excluded path or an already compressed content type are sent as they are.
+func NewCompression(config CompressionConfig) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		for _, excludePath := range config.ExcludePaths {
+			if strings.HasPrefix(c.Request.URL.Path, excludePath) {
+				c.Next()
+				return
+			}
+		}
+		c.Header("Vary", "Accept-Encoding")
+		encoding := negotiateEncoding(c.GetHeader("Accept-Encoding"))
+		// upgraded connections such as websockets are not a response body
+		if encoding == "" || c.Request.Method == http.MethodHead || c.GetHeader("Upgrade") != "" {
+			c.Next()
+			return
+		}
+
+		// the status starts from the one of the writer, gin sets it for not found routes before the middlewares run
+		writer := &compressWriter{
+			ResponseWriter: c.Writer,
+			encoding:       encoding,
+			minSize:        config.MinSize,
+			status:         c.Writer.Status(),
+		}
+		c.Writer = writer
+		defer func() {
+			writer.finish()
+			c.Writer = writer.ResponseWriter
+		}()
+		c.Next()
+	}
+}
+
+// negotiateEncoding picks gzip or deflate from the Accept-Encoding header, gzip is preferred with equal weights
+func negotiateEncoding(acceptEncoding string) string {
+	bestEncoding := ""
+	bestWeight := 0.0
+	for _, part := range strings.Split(acceptEncoding, ",") {
+		name, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		name = strings.ToLower(strings.TrimSpace(name))
+		weight := 1.0
+		if params = strings.TrimSpace(params); strings.HasPrefix(params, "q=") {
+			parsed, err := strconv.ParseFloat(strings.TrimPrefix(params, "q="), 64)
+			if err != nil {
+				continue
+			}
+			weight = parsed
+		}
+		if name == "*" {
+			name = gzipEncoding
+		}
+		if name != gzipEncoding && name != deflateEncoding {
+			continue
+		}
+		if weight > bestWeight || (weight == bestWeight && name == gzipEncoding) {
+			bestEncoding, bestWeight = name, weight
+		}
+	}
+	return bestEncoding
+}
+
+// compressWriter buffers the body until it reaches the minimum size, then decides whether it is compressed.
+// The status is held back until then, as the encoding headers must be set before it is written.
+type compressWriter struct {
+	gin.ResponseWriter
+	encoding   string
+	minSize    int
+	status     int
+	buffer     []byte
+	decided    bool
+	compressor io.WriteCloser
+}
+
+func (w *compressWriter) WriteHeader(code int) {
+	if !w.decided {
+		w.status = code
+	}
+}
+
+// WriteHeaderNow is deferred until the encoding is decided
+func (w *compressWriter) WriteHeaderNow() {}
+
+func (w *compressWriter) Status() int {
+	if !w.decided {
+		return w.status
+	}
+	return w.ResponseWriter.Status()
+}
+
+func (w *compressWriter) Write(data []byte) (int, error) {
+	if !w.decided {
+		w.buffer = append(w.buffer, data...)
+		if len(w.buffer) < w.minSize {
+			return len(data), nil
+		}
+		if err := w.decide(true); err != nil {
+			return 0, err
+		}
+		return len(data), nil
+	}
+	if w.compressor != nil {
+		return w.compressor.Write(data)
+	}
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *compressWriter) WriteString(s string) (int, error) {
+	return w.Write([]byte(s))
+}
+
+// Flush sends the buffered body of streamed responses, which are compressed regardless of their size
+func (w *compressWriter) Flush() {
+	if !w.decided {
+		if err := w.decide(true); err != nil {
+			return
+		}
+	}
+	if flusher, ok := w.compressor.(interface{ Flush() error }); ok {
+		_ = flusher.Flush()
+	}
+	w.ResponseWriter.Flush()
+}
+
+// decide writes the status with the encoding headers and the buffered body, compressed when it is allowed
+func (w *compressWriter) decide(compress bool) error {
+	w.decided = true
+	header := w.Header()
+	if header.Get("Content-Type") == "" && len(w.buffer) > 0 {
+		// sniffed here, as the compressed body cannot be sniffed by net/http
+		header.Set("Content-Type", http.DetectContentType(w.buffer))
+	}
+	if compress && w.compressible() {
+		header.Set("Content-Encoding", w.encoding)
+		header.Del("Content-Length")
+		w.compressor = w.newCompressor()
+	}
+	w.ResponseWriter.WriteHeader(w.status)
+	if len(w.buffer) == 0 {
+		return nil
+	}
+	buffer := w.buffer
+	w.buffer = nil
+	if w.compressor != nil {
+		_, err := w.compressor.Write(buffer)
+		return err
+	}
+	_, err := w.ResponseWriter.Write(buffer)
+	return err
+}
+
+func (w *compressWriter) compressible() bool {
+	if w.status < http.StatusOK || w.status == http.StatusNoContent || w.status == http.StatusNotModified {
+		return false
+	}
+	if w.Header().Get("Content-Encoding") != "" {
+		return false
+	}
+	contentType := strings.ToLower(w.Header().Get("Content-Type"))
+	for _, compressedContentType := range compressedContentTypes {
+		if strings.HasPrefix(contentType, compressedContentType) {
+			return false
+		}
+	}
+	return true
+}
+
+func (w *compressWriter) newCompressor() io.WriteCloser {
+	if w.encoding == gzipEncoding {
+		writer := gzipWriterPool.Get().(*gzip.Writer)
+		writer.Reset(w.ResponseWriter)
+		return writer
+	}
+	writer := flateWriterPool.Get().(*flate.Writer)
+	writer.Reset(w.ResponseWriter)
+	return writer
+}
+
+// finish sends a body smaller than the minimum size as it is and closes the compressor
+func (w *compressWriter) finish() {
+	if !w.decided {
+		_ = w.decide(false)
+	}
+	if w.compressor == nil {
+		return
+	}
+	_ = w.compressor.Close()
+	switch compressor := w.compressor.(type) {
+	case *gzip.Writer:
+		gzipWriterPool.Put(compressor)
+	case *flate.Writer:
+		flateWriterPool.Put(compressor)
+	}
+	w.compressor = nil
+}
@@ -0,0 +1,94 @@
+package routerfx_test
+
+import (
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/prismedic/scalpel/routerfx"
+)
+
+func getCompressed(t *testing.T, path string, acceptEncoding string) *httptest.ResponseRecorder {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(routerfx.NewCompression(routerfx.CompressionConfig{
+		Enabled:      true,
+		MinSize:      100,
+		ExcludePaths: []string{"/metrics"},
+	}))
+	body := strings.Repeat("hello ", 100)
+	router.GET("/large", func(c *gin.Context) {
+		c.String(http.StatusCreated, body)
+	})
+	router.GET("/small", func(c *gin.Context) {
+		c.String(http.StatusOK, "hello")
+	})
+	router.GET("/image", func(c *gin.Context) {
+		c.Data(http.StatusOK, "image/png", []byte(body))
+	})
+	router.GET("/metrics", func(c *gin.Context) {
+		c.String(http.StatusOK, body)
+	})
+
+	request := httptest.NewRequest(http.MethodGet, path, nil)
+	request.Header.Set("Accept-Encoding", acceptEncoding)
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, request)
+	return recorder
+}
+
+func TestNewCompression(t *testing.T) {
+	t.Run("Test gzip response", func(t *testing.T) {
+		recorder := getCompressed(t, "/large", "deflate;q=0.5, gzip")
+		if recorder.Code != http.StatusCreated {
+			t.Errorf("unexpected status code, got %d, expected %d", recorder.Code, http.StatusCreated)
+		}
+		if got := recorder.Header().Get("Content-Encoding"); got != "gzip" {
+			t.Fatalf("unexpected content encoding, got %q, expected gzip", got)
+		}
+		if got := recorder.Header().Get("Vary"); got != "Accept-Encoding" {
+			t.Errorf("unexpected vary header, got %q", got)
+		}
+		reader, err := gzip.NewReader(recorder.Body)
+		if err != nil {
+			t.Fatalf("failed to read gzip body: %v", err)
+		}
+		body, err := io.ReadAll(reader)
+		if err != nil {
+			t.Fatalf("failed to read gzip body: %v", err)
+		}
+		if string(body) != strings.Repeat("hello ", 100) {
+			t.Errorf("unexpected body %s", body)
+		}
+	})
+	t.Run("Test deflate response", func(t *testing.T) {
+		recorder := getCompressed(t, "/large", "gzip;q=0, deflate")
+		if got := recorder.Header().Get("Content-Encoding"); got != "deflate" {
+			t.Errorf("unexpected content encoding, got %q, expected deflate", got)
+		}
+	})
+	t.Run("Test uncompressed responses", func(t *testing.T) {
+		for _, test := range []struct {
+			path           string
+			acceptEncoding string
+		}{
+			{"/large", ""},
+			{"/small", "gzip"},
+			{"/image", "gzip"},
+			{"/metrics", "gzip"},
+		} {
+			recorder := getCompressed(t, test.path, test.acceptEncoding)
+			if got := recorder.Header().Get("Content-Encoding"); got != "" {
+				t.Errorf("unexpected content encoding %q of %s", got, test.path)
+			}
+			if recorder.Code != http.StatusOK && recorder.Code != http.StatusCreated {
+				t.Errorf("unexpected status code %d of %s", recorder.Code, test.path)
+			}
+		}
+	})
+}
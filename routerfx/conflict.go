@@ -0,0 +1,74 @@
+package routerfx
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ErrRouteConflict is returned by New when two routes are registered on the same method and path,
+// or on paths that gin cannot tell apart, e.g. wildcards with different names
+var ErrRouteConflict = errors.New("route conflict")
+
+// routeRegistry tracks which route registered each method and path of the engine, routes are named by their type,
+// e.g. *infofx.HealthController, as the constructors providing them are not known to the router
+type routeRegistry struct {
+	engine *gin.Engine
+	owners map[string]string
+}
+
+func newRouteRegistry(engine *gin.Engine) *routeRegistry {
+	return &routeRegistry{engine: engine, owners: make(map[string]string)}
+}
+
+// register adds the routes of the owner to the target group of the engine, the routes of the engine that have
+// no owner yet are then the routes of the owner. A conflict panics in gin, the panic is returned as an error
+// naming the owner of the conflicting path.
+func (r *routeRegistry) register(owner string, target *gin.RouterGroup, register func(rg *gin.RouterGroup)) error {
+	if recovered := registerRecovered(target, register); recovered != nil {
+		return r.conflict(owner, recovered)
+	}
+	for _, route := range r.engine.Routes() {
+		key := route.Method + " " + route.Path
+		if _, ok := r.owners[key]; !ok {
+			r.owners[key] = owner
+		}
+	}
+	return nil
+}
+
+// conflict returns the error of the panic of gin, the panic has the path but not the method of the route
+func (r *routeRegistry) conflict(owner string, recovered any) error {
+	message := fmt.Sprint(recovered)
+	var keys []string
+	for key, other := range r.owners {
+		routePath := key[strings.Index(key, " ")+1:]
+		if other != owner && strings.Contains(message, "'"+routePath+"'") {
+			keys = append(keys, key)
+		}
+	}
+	switch len(keys) {
+	case 0:
+		return fmt.Errorf("%w: in routes of %s: %s", ErrRouteConflict, owner, message)
+	case 1:
+		return fmt.Errorf("%w: %s is registered by both %s and %s", ErrRouteConflict, keys[0], r.owners[keys[0]], owner)
+	default:
+		sort.Strings(keys)
+		others := make([]string, 0, len(keys))
+		for _, key := range keys {
+			others = append(others, key+" of "+r.owners[key])
+		}
+		return fmt.Errorf("%w: routes of %s conflict with %s", ErrRouteConflict, owner, strings.Join(others, ", "))
+	}
+}
+
+func registerRecovered(rg *gin.RouterGroup, register func(rg *gin.RouterGroup)) (recovered any) {
+	defer func() {
+		recovered = recover()
+	}()
+	register(rg)
+	return nil
+}
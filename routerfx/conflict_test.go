@@ -0,0 +1,100 @@
+package routerfx_test
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/prismedic/scalpel/routerfx"
+)
+
+type testHandler struct {
+	pattern string
+}
+
+func (th *testHandler) Handler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	}
+}
+
+func (th *testHandler) RoutePattern() string {
+	return th.pattern
+}
+
+type testWildcardController struct{}
+
+func (tc *testWildcardController) RegisterControllerRoutes(rg *gin.RouterGroup) {
+	rg.GET("/:name", func(c *gin.Context) {})
+}
+
+func (tc *testWildcardController) RoutePattern() string {
+	return "/users"
+}
+
+// testCountingController counts the registrations of its routes
+type testCountingController struct {
+	registrations int
+}
+
+func (tc *testCountingController) RegisterControllerRoutes(rg *gin.RouterGroup) {
+	tc.registrations++
+	rg.GET("/", func(c *gin.Context) {})
+}
+
+func (tc *testCountingController) RoutePattern() string {
+	return "/counts"
+}
+
+func TestRouteConflicts(t *testing.T) {
+	t.Run("Test same method and path", func(t *testing.T) {
+		_, err := routerfx.New(routerfx.Params{
+			Config:           &routerfx.Config{},
+			ControllerRoutes: []routerfx.ControllerRoute{&testController{pattern: "/users"}},
+			HandlerRoutes:    []routerfx.HandlerRoute{&testHandler{pattern: "/v1/users/"}},
+		})
+		if !errors.Is(err, routerfx.ErrRouteConflict) {
+			t.Fatalf("unexpected error, got %v, expected %v", err, routerfx.ErrRouteConflict)
+		}
+		for _, expected := range []string{"GET /v1/users/", "*routerfx_test.testController", "*routerfx_test.testHandler"} {
+			if !strings.Contains(err.Error(), expected) {
+				t.Errorf("%s not found in error %v", expected, err)
+			}
+		}
+	})
+	t.Run("Test conflicting wildcards", func(t *testing.T) {
+		_, err := routerfx.New(routerfx.Params{
+			Config:           &routerfx.Config{},
+			ControllerRoutes: []routerfx.ControllerRoute{&testController{pattern: "/users/:id"}, &testWildcardController{}},
+		})
+		if !errors.Is(err, routerfx.ErrRouteConflict) || !strings.Contains(err.Error(), "*routerfx_test.testWildcardController") {
+			t.Errorf("unexpected error, got %v, expected %v", err, routerfx.ErrRouteConflict)
+		}
+	})
+	t.Run("Test routes registered once", func(t *testing.T) {
+		controller := &testCountingController{}
+		_, err := routerfx.New(routerfx.Params{
+			Config:           &routerfx.Config{},
+			ControllerRoutes: []routerfx.ControllerRoute{controller, &testController{pattern: "/users"}},
+		})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if controller.registrations != 1 {
+			t.Errorf("unexpected number of registrations, got %d, expected 1", controller.registrations)
+		}
+	})
+	t.Run("Test distinct routes", func(t *testing.T) {
+		_, err := routerfx.New(routerfx.Params{
+			Config:           &routerfx.Config{},
+			ControllerRoutes: []routerfx.ControllerRoute{&testController{pattern: "/users"}, &testController{pattern: "/orders"}},
+			HandlerRoutes:    []routerfx.HandlerRoute{&testHandler{pattern: "/metrics"}},
+		})
+		if err != nil {
+			t.Errorf("unexpected error: %v", err)
+		}
+	})
+}
@@ -0,0 +1,50 @@
+package routerfx
+
+import (
+	"time"
+
+	"github.com/gin-contrib/cors"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// CorsConfig configures the CORS headers, no header is added when there is no allowed origin.
+// Preflight OPTIONS requests of allowed origins are answered by the middleware without reaching the handlers.
+type CorsConfig struct {
+	// AllowedOrigins are origins such as "https://admin.example.com", or "*" for all origins
+	AllowedOrigins   []string      `mapstructure:"allowed_origins" yaml:"allowed_origins" validate:"dive,eq=*|startswith=http://|startswith=https://"`
+	AllowedMethods   []string      `mapstructure:"allowed_methods" yaml:"allowed_methods" validate:"dive,required"`
+	AllowCredentials bool          `mapstructure:"allow_credentials" yaml:"allow_credentials"`
+	MaxAge           time.Duration `mapstructure:"max_age" yaml:"max_age" validate:"min=0"`
+}
+
+// NewCors returns the CORS middleware, which can also be used on a route group of a controller
+// with other settings than the global ones. It returns nil when there is no allowed origin.
+func NewCors(config CorsConfig) gin.HandlerFunc {
+	if len(config.AllowedOrigins) == 0 {
+		return nil
+	}
+	corsConfig := cors.DefaultConfig()
+	corsConfig.AllowOrigins = config.AllowedOrigins
+	if len(config.AllowedMethods) > 0 {
+		corsConfig.AllowMethods = config.AllowedMethods
+	}
+	corsConfig.AllowCredentials = config.AllowCredentials
+	if config.MaxAge > 0 {
+		corsConfig.MaxAge = config.MaxAge
+	}
+	return cors.New(corsConfig)
+}
+
+// corsConfig returns the CORS config, with the origins of the deprecated router.cors_allowed_origins
+// when router.cors.allowed_origins has none
+func (c *Config) corsConfig(logger *zap.SugaredLogger) CorsConfig {
+	config := c.Cors
+	if len(config.AllowedOrigins) == 0 && len(c.CorsAllowedOrigins) > 0 {
+		if logger != nil {
+			logger.Warn("router.cors_allowed_origins is deprecated, use router.cors.allowed_origins")
+		}
+		config.AllowedOrigins = c.CorsAllowedOrigins
+	}
+	return config
+}
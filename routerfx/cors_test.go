@@ -0,0 +1,100 @@
+package routerfx_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+
+	"github.com/prismedic/scalpel/routerfx"
+)
+
+func newCorsRouter(config routerfx.CorsConfig) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	if corsMiddleware := routerfx.NewCors(config); corsMiddleware != nil {
+		router.Use(corsMiddleware)
+	}
+	router.GET("/", func(c *gin.Context) {
+		c.String(http.StatusOK, "ok")
+	})
+	return router
+}
+
+func TestNewCors(t *testing.T) {
+	t.Run("Test preflight request", func(t *testing.T) {
+		router := newCorsRouter(routerfx.CorsConfig{
+			AllowedOrigins: []string{"https://admin.example.com"},
+			AllowedMethods: []string{"GET"},
+			MaxAge:         time.Hour,
+		})
+		request := httptest.NewRequest(http.MethodOptions, "/", nil)
+		request.Header.Set("Origin", "https://admin.example.com")
+		request.Header.Set("Access-Control-Request-Method", "GET")
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, request)
+		if recorder.Code != http.StatusNoContent {
+			t.Errorf("unexpected status code, got %d, expected %d", recorder.Code, http.StatusNoContent)
+		}
+		if got := recorder.Header().Get("Access-Control-Allow-Origin"); got != "https://admin.example.com" {
+			t.Errorf("unexpected allowed origin, got %s", got)
+		}
+		if got := recorder.Header().Get("Access-Control-Max-Age"); got != "3600" {
+			t.Errorf("unexpected max age, got %s, expected 3600", got)
+		}
+	})
+	t.Run("Test no allowed origins", func(t *testing.T) {
+		router := newCorsRouter(routerfx.CorsConfig{})
+		request := httptest.NewRequest(http.MethodGet, "/", nil)
+		request.Header.Set("Origin", "https://admin.example.com")
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, request)
+		if recorder.Code != http.StatusOK {
+			t.Errorf("unexpected status code, got %d, expected %d", recorder.Code, http.StatusOK)
+		}
+		if got := recorder.Header().Get("Access-Control-Allow-Origin"); got != "" {
+			t.Errorf("unexpected CORS header %s", got)
+		}
+	})
+}
+
+func TestCorsConfig(t *testing.T) {
+	t.Run("Test origin validation", func(t *testing.T) {
+		validate := validator.New()
+		for _, test := range []struct {
+			origin string
+			valid  bool
+		}{
+			{"*", true},
+			{"https://admin.example.com", true},
+			{"http://localhost:3000", true},
+			{"admin.example.com", false},
+			{"", false},
+		} {
+			err := validate.Struct(&routerfx.CorsConfig{AllowedOrigins: []string{test.origin}})
+			if (err == nil) != test.valid {
+				t.Errorf("unexpected validation of origin %q, got %v, expected valid %t", test.origin, err, test.valid)
+			}
+			err = validate.StructPartial(&routerfx.Config{CorsAllowedOrigins: []string{test.origin}}, "CorsAllowedOrigins")
+			if (err == nil) != test.valid {
+				t.Errorf("unexpected validation of deprecated origin %q, got %v, expected valid %t", test.origin, err, test.valid)
+			}
+		}
+	})
+	t.Run("Test deprecated allowed origins", func(t *testing.T) {
+		result, err := routerfx.New(routerfx.Params{Config: &routerfx.Config{CorsAllowedOrigins: []string{"https://admin.example.com"}}})
+		if err != nil {
+			t.Fatalf("failed to create router: %v", err)
+		}
+		request := httptest.NewRequest(http.MethodGet, "/", nil)
+		request.Header.Set("Origin", "https://admin.example.com")
+		recorder := httptest.NewRecorder()
+		result.Router.ServeHTTP(recorder, request)
+		if got := recorder.Header().Get("Access-Control-Allow-Origin"); got != "https://admin.example.com" {
+			t.Errorf("unexpected allowed origin, got %s", got)
+		}
+	})
+}
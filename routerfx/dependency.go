@@ -0,0 +1,50 @@
+package routerfx
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+var ErrUnknownDependency = errors.New("unknown route dependency")
+
+// DependentRoute is implemented by controllers that must not receive traffic until a dependency is ready,
+// RouteDependency returns the name of the health check of the dependency.
+// While the check fails, the routes of the controller answer 503 and the other routes are served.
+type DependentRoute interface {
+	RouteDependency() string
+}
+
+// DependencyChecker runs the health checks of the dependencies by their name, infofx provides it from the health checks
+type DependencyChecker interface {
+	HasDependency(name string) bool
+	CheckDependency(ctx context.Context, name string) error
+}
+
+// dependencyGates returns the middleware gating the routes of the controller, none when it has no dependency
+func dependencyGates(route ControllerRoute, checker DependencyChecker) ([]gin.HandlerFunc, error) {
+	dependent, ok := route.(DependentRoute)
+	if !ok || dependent.RouteDependency() == "" {
+		return nil, nil
+	}
+	name := dependent.RouteDependency()
+	if checker == nil || !checker.HasDependency(name) {
+		return nil, fmt.Errorf("%w: %q of %T", ErrUnknownDependency, name, route)
+	}
+	return []gin.HandlerFunc{newDependencyGate(name, checker)}, nil
+}
+
+// newDependencyGate returns a middleware answering 503 while the health check of the dependency fails,
+// the error of the check is added to the errors of the context for the access log
+func newDependencyGate(name string, checker DependencyChecker) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if err := checker.CheckDependency(c.Request.Context(), name); err != nil {
+			AbortWithError(c, NewError(http.StatusServiceUnavailable, CodeUnavailable, name+" is not ready", err))
+			return
+		}
+		c.Next()
+	}
+}
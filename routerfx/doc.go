@@ -0,0 +1,17 @@
+package routerfx
+
+// RouteDoc describes an operation of a controller, Path is relative to the route pattern of the controller.
+// Request and Response are zero values of the body types, e.g. LogLevelRequest{}, and are left out when nil.
+type RouteDoc struct {
+	Method   string
+	Path     string
+	Summary  string
+	Request  any
+	Response any
+}
+
+// DocumentedRoute is implemented by controllers describing their operations, e.g. for the OpenAPI document of openapifx.
+// The routes of other controllers are listed with their paths and methods only.
+type DocumentedRoute interface {
+	RouteDocs() []RouteDoc
+}
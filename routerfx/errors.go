@@ -0,0 +1,135 @@
+package routerfx
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+)
+
+// codes of the error responses, clients should rely on them rather than on the messages
+const (
+	CodeBadRequest   = "bad_request"
+	CodeUnauthorized = "unauthorized"
+	CodeForbidden    = "forbidden"
+	CodeNotFound     = "not_found"
+	CodeConflict     = "conflict"
+	CodeTooLarge     = "too_large"
+	CodeRateLimited  = "rate_limited"
+	CodeInternal     = "internal"
+	CodeUnavailable  = "unavailable"
+	CodeTimeout      = "timeout"
+	CodeClientClosed = "client_closed"
+)
+
+const (
+	internalErrorMessage = "internal server error"
+	// statusClientClosed is the non-standard status of nginx for requests canceled by the client
+	statusClientClosed = 499
+)
+
+// ErrorResponse is the body of all error responses, e.g. {"error":{"code":"not_found","message":"user not found"}}
+type ErrorResponse struct {
+	Error ErrorBody `json:"error"`
+}
+
+type ErrorBody struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+	// RequestID is set when the request has an ID, so clients can quote it when reporting the error
+	RequestID string `json:"request_id,omitempty"`
+}
+
+// Error is an error with the status and code of its response, handlers can return it wrapped in their own errors
+type Error struct {
+	Status  int
+	Code    string
+	Message string
+	Err     error
+}
+
+// NewError returns an error responded with the status, code and message, the cause is only logged
+func NewError(status int, code string, message string, cause error) *Error {
+	return &Error{Status: status, Code: code, Message: message, Err: cause}
+}
+
+func (e *Error) Error() string {
+	if e.Err != nil {
+		return e.Message + ": " + e.Err.Error()
+	}
+	return e.Message
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// errors mapped to their responses, they can be wrapped with fmt.Errorf("...: %w", routerfx.ErrNotFound)
+var (
+	ErrBadRequest   = NewError(http.StatusBadRequest, CodeBadRequest, "bad request", nil)
+	ErrUnauthorized = NewError(http.StatusUnauthorized, CodeUnauthorized, "unauthorized", nil)
+	ErrForbidden    = NewError(http.StatusForbidden, CodeForbidden, "forbidden", nil)
+	ErrNotFound     = NewError(http.StatusNotFound, CodeNotFound, "not found", nil)
+	ErrConflict     = NewError(http.StatusConflict, CodeConflict, "conflict", nil)
+)
+
+// responseOf maps the error to its status, code and message. Errors that are not known are internal errors,
+// their message is not sent to the client.
+func responseOf(err error) (int, ErrorBody) {
+	var routerErr *Error
+	var validationErrs validator.ValidationErrors
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+	var maxBytesErr *http.MaxBytesError
+	switch {
+	case errors.As(err, &routerErr):
+		message := routerErr.Message
+		// the message of a wrapping error describes the failure better than the one of a sentinel error
+		if routerErr.Err == nil && err != error(routerErr) {
+			message = err.Error()
+		}
+		return routerErr.Status, ErrorBody{Code: routerErr.Code, Message: message}
+	case errors.As(err, &maxBytesErr):
+		return http.StatusRequestEntityTooLarge, ErrorBody{Code: CodeTooLarge, Message: err.Error()}
+	case errors.As(err, &validationErrs), errors.As(err, &syntaxErr), errors.As(err, &typeErr),
+		errors.Is(err, io.EOF), errors.Is(err, io.ErrUnexpectedEOF):
+		return http.StatusBadRequest, ErrorBody{Code: CodeBadRequest, Message: err.Error()}
+	case errors.Is(err, context.DeadlineExceeded):
+		return http.StatusGatewayTimeout, ErrorBody{Code: CodeTimeout, Message: "request timed out"}
+	case errors.Is(err, context.Canceled):
+		return statusClientClosed, ErrorBody{Code: CodeClientClosed, Message: "request canceled"}
+	}
+	return http.StatusInternalServerError, ErrorBody{Code: CodeInternal, Message: internalErrorMessage}
+}
+
+// AbortWithError writes the error response mapped from the error and stops the following handlers.
+// Server errors are also added to the errors of the context, so the access log records their cause.
+func AbortWithError(c *gin.Context, err error) {
+	status, body := responseOf(err)
+	if status >= http.StatusInternalServerError {
+		_ = c.Error(err)
+	}
+	abortWithBody(c, status, body)
+}
+
+// AbortWithStatusError writes an error response with the status, code and message and stops the following handlers
+func AbortWithStatusError(c *gin.Context, status int, code string, message string) {
+	abortWithBody(c, status, ErrorBody{Code: code, Message: message})
+}
+
+func abortWithBody(c *gin.Context, status int, body ErrorBody) {
+	if requestID, ok := RequestIDFromContext(c.Request.Context()); ok {
+		body.RequestID = requestID
+	}
+	c.AbortWithStatusJSON(status, ErrorResponse{Error: body})
+}
+
+// notFound answers the requests without a matching route with the error response
+func notFound(c *gin.Context) {
+	AbortWithStatusError(c, http.StatusNotFound, CodeNotFound, "no route for "+strings.ToUpper(c.Request.Method)+" "+c.Request.URL.Path)
+}
@@ -0,0 +1,70 @@
+package routerfx_test
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/prismedic/scalpel/routerfx"
+)
+
+func getError(t *testing.T, router http.Handler, path string) (int, routerfx.ErrorBody) {
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, path, nil))
+	var response routerfx.ErrorResponse
+	if err := json.Unmarshal(recorder.Body.Bytes(), &response); err != nil {
+		t.Fatalf("failed to parse error response %s: %v", recorder.Body.String(), err)
+	}
+	return recorder.Code, response.Error
+}
+
+func TestAbortWithError(t *testing.T) {
+	gin.SetMode(gin.ReleaseMode)
+	router := gin.New()
+	router.Use(func(c *gin.Context) {
+		c.Request = c.Request.WithContext(routerfx.ContextWithRequestID(c.Request.Context(), "req-1"))
+	})
+	errs := map[string]error{
+		"/wrapped":  fmt.Errorf("user 42: %w", routerfx.ErrNotFound),
+		"/custom":   routerfx.NewError(http.StatusConflict, "duplicate_user", "user already exists", errors.New("unique violation")),
+		"/internal": errors.New("connection refused to 10.0.0.1"),
+	}
+	for path, err := range errs {
+		err := err
+		router.GET(path, func(c *gin.Context) {
+			routerfx.AbortWithError(c, err)
+		})
+	}
+
+	for _, test := range []struct {
+		path           string
+		expectedStatus int
+		expectedBody   routerfx.ErrorBody
+	}{
+		{"/wrapped", http.StatusNotFound, routerfx.ErrorBody{Code: routerfx.CodeNotFound, Message: "user 42: not found", RequestID: "req-1"}},
+		{"/custom", http.StatusConflict, routerfx.ErrorBody{Code: "duplicate_user", Message: "user already exists", RequestID: "req-1"}},
+		{"/internal", http.StatusInternalServerError, routerfx.ErrorBody{Code: routerfx.CodeInternal, Message: "internal server error", RequestID: "req-1"}},
+	} {
+		t.Run("Test error of "+test.path, func(t *testing.T) {
+			status, body := getError(t, router, test.path)
+			if status != test.expectedStatus || body != test.expectedBody {
+				t.Errorf("unexpected error response, got %d %+v, expected %d %+v", status, body, test.expectedStatus, test.expectedBody)
+			}
+		})
+	}
+	t.Run("Test unknown route", func(t *testing.T) {
+		result, err := routerfx.New(routerfx.Params{Config: &routerfx.Config{}})
+		if err != nil {
+			t.Fatalf("failed to create router: %v", err)
+		}
+		status, body := getError(t, result.Router, "/missing")
+		if status != http.StatusNotFound || body.Code != routerfx.CodeNotFound {
+			t.Errorf("unexpected error response, got %d %+v", status, body)
+		}
+	})
+}
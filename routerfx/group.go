@@ -0,0 +1,118 @@
+package routerfx
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/fx"
+)
+
+var (
+	ErrUnknownGroup  = errors.New("unknown route group")
+	ErrGroupCycle    = errors.New("route groups are nested in a cycle")
+	ErrGroupConflict = errors.New("route group is defined more than once")
+)
+
+// Group shares a path prefix and middlewares between the controllers targeting it.
+// Groups without a parent are at the root of the router, so the prefix should contain the version, e.g. "/api/v1".
+// Controllers without a group keep registering under "/v1".
+type Group struct {
+	Name string
+	// Prefix is appended to the prefix of the parent group
+	Prefix string
+	// Parent is the name of the enclosing group, its middlewares also apply to this group
+	Parent      string
+	Middlewares []gin.HandlerFunc
+}
+
+func AsGroup(group any) any {
+	return fx.Annotate(
+		group,
+		fx.ResultTags(`group:"routeGroups"`),
+	)
+}
+
+// GroupedRoute is implemented by controllers registering under a group, RouteGroup returns the name of the group
+type GroupedRoute interface {
+	RouteGroup() string
+}
+
+// groupPrefix returns the full prefix of the group including the prefixes of its parents
+func groupPrefix(groups map[string]Group, name string, visiting map[string]bool) (string, error) {
+	group, ok := groups[name]
+	if !ok {
+		return "", fmt.Errorf("%w: %q", ErrUnknownGroup, name)
+	}
+	if group.Parent == "" {
+		return joinPaths("/", group.Prefix), nil
+	}
+	if visiting[name] {
+		return "", fmt.Errorf("%w: %q", ErrGroupCycle, name)
+	}
+	visiting[name] = true
+	parentPrefix, err := groupPrefix(groups, group.Parent, visiting)
+	if err != nil {
+		return "", err
+	}
+	return joinPaths(parentPrefix, group.Prefix), nil
+}
+
+func groupsByName(groups []Group) (map[string]Group, error) {
+	groupMap := make(map[string]Group, len(groups))
+	for _, group := range groups {
+		if _, ok := groupMap[group.Name]; ok {
+			return nil, fmt.Errorf("%w: %q", ErrGroupConflict, group.Name)
+		}
+		groupMap[group.Name] = group
+	}
+	return groupMap, nil
+}
+
+// routerGroups creates the gin router groups, the groups of the parents are created first
+// so that the middlewares of the parents run before the ones of their children
+type routerGroups struct {
+	router *gin.Engine
+	groups map[string]Group
+	cache  map[string]*gin.RouterGroup
+}
+
+func (r *routerGroups) get(name string, visiting map[string]bool) (*gin.RouterGroup, error) {
+	if routerGroup, ok := r.cache[name]; ok {
+		return routerGroup, nil
+	}
+	group, ok := r.groups[name]
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", ErrUnknownGroup, name)
+	}
+	if visiting[name] {
+		return nil, fmt.Errorf("%w: %q", ErrGroupCycle, name)
+	}
+	visiting[name] = true
+
+	parent := &r.router.RouterGroup
+	if group.Parent != "" {
+		var err error
+		parent, err = r.get(group.Parent, visiting)
+		if err != nil {
+			return nil, err
+		}
+	}
+	routerGroup := parent.Group(group.Prefix, group.Middlewares...)
+	r.cache[name] = routerGroup
+	return routerGroup, nil
+}
+
+// ControllerPath returns the full path of a route registered by the controller with the relative path.
+// The groups are needed for controllers targeting a group, controllers of unknown groups are treated as ungrouped.
+func ControllerPath(route ControllerRoute, relativePath string, groups []Group) string {
+	prefix := apiPrefix
+	if grouped, ok := route.(GroupedRoute); ok && grouped.RouteGroup() != "" {
+		if groupMap, err := groupsByName(groups); err == nil {
+			if groupPath, err := groupPrefix(groupMap, grouped.RouteGroup(), make(map[string]bool)); err == nil {
+				prefix = groupPath
+			}
+		}
+	}
+	return joinPaths(joinPaths(prefix, route.RoutePattern()), relativePath)
+}
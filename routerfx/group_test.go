@@ -0,0 +1,88 @@
+package routerfx_test
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/prismedic/scalpel/routerfx"
+)
+
+type testController struct {
+	pattern string
+	group   string
+}
+
+func (tc *testController) RegisterControllerRoutes(rg *gin.RouterGroup) {
+	rg.GET("/", func(c *gin.Context) {
+		c.String(http.StatusOK, c.GetHeader("X-Auth"))
+	})
+}
+
+func (tc *testController) RoutePattern() string {
+	return tc.pattern
+}
+
+func (tc *testController) RouteGroup() string {
+	return tc.group
+}
+
+func setHeader(value string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Request.Header.Set("X-Auth", c.Request.Header.Get("X-Auth")+value)
+	}
+}
+
+func TestGroups(t *testing.T) {
+	groups := []routerfx.Group{
+		{Name: "api", Prefix: "/api/v1", Middlewares: []gin.HandlerFunc{setHeader("api")}},
+		{Name: "admin", Prefix: "/admin", Parent: "api", Middlewares: []gin.HandlerFunc{setHeader("+admin")}},
+	}
+	controllers := []routerfx.ControllerRoute{
+		&testController{pattern: "/users", group: "api"},
+		&testController{pattern: "/settings", group: "admin"},
+		&testController{pattern: "/info"},
+	}
+	result, err := routerfx.New(routerfx.Params{
+		Config:           &routerfx.Config{},
+		ControllerRoutes: controllers,
+		Groups:           groups,
+	})
+	if err != nil {
+		t.Fatalf("failed to create router: %v", err)
+	}
+
+	for _, test := range []struct {
+		path         string
+		expectedBody string
+	}{
+		{"/api/v1/users/", "api"},
+		{"/api/v1/admin/settings/", "api+admin"},
+		{"/v1/info/", ""},
+	} {
+		t.Run("Test path "+test.path, func(t *testing.T) {
+			recorder := httptest.NewRecorder()
+			result.Router.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, test.path, nil))
+			if recorder.Code != http.StatusOK || recorder.Body.String() != test.expectedBody {
+				t.Errorf("unexpected response, got %d %q, expected %q", recorder.Code, recorder.Body.String(), test.expectedBody)
+			}
+		})
+	}
+	t.Run("Test controller path", func(t *testing.T) {
+		if got := routerfx.ControllerPath(controllers[1], "/", groups); got != "/api/v1/admin/settings/" {
+			t.Errorf("unexpected controller path, got %s", got)
+		}
+	})
+	t.Run("Test unknown group", func(t *testing.T) {
+		_, err := routerfx.New(routerfx.Params{
+			Config:           &routerfx.Config{},
+			ControllerRoutes: []routerfx.ControllerRoute{&testController{pattern: "/users", group: "missing"}},
+		})
+		if !errors.Is(err, routerfx.ErrUnknownGroup) {
+			t.Errorf("unexpected error, got %v, expected %v", err, routerfx.ErrUnknownGroup)
+		}
+	})
+}
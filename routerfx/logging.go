@@ -0,0 +1,46 @@
+package routerfx
+
+import (
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// NewRequestLogger returns a middleware writing an access log entry for every request.
+// Requests with a path starting with any of ignorePaths are not logged.
+func NewRequestLogger(logger *zap.SugaredLogger, ignorePaths []string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		path := c.Request.URL.Path
+		c.Next()
+
+		for _, ignorePath := range ignorePaths {
+			if strings.HasPrefix(path, ignorePath) {
+				return
+			}
+		}
+
+		fields := []any{
+			"method", c.Request.Method,
+			"path", path,
+			"status", c.Writer.Status(),
+			"latency", time.Since(start),
+			"bytes", c.Writer.Size(),
+			"client_ip", c.ClientIP(),
+		}
+		if requestID, ok := RequestIDFromContext(c.Request.Context()); ok {
+			fields = append(fields, "request_id", requestID)
+		}
+
+		switch {
+		case len(c.Errors) > 0:
+			logger.Errorw(c.Errors.String(), fields...)
+		case c.Writer.Status() >= 500:
+			logger.Errorw("request failed", fields...)
+		default:
+			logger.Infow("request handled", fields...)
+		}
+	}
+}
@@ -0,0 +1,238 @@
+package routerfx
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/fx"
+)
+
+var (
+	ErrUnknownMiddleware   = errors.New("unknown middleware")
+	ErrMiddlewareConflict  = errors.New("middleware is defined more than once")
+	ErrMiddlewarePositions = errors.New("middleware is positioned both before and after another")
+)
+
+// names of the built-in middlewares, in their default order.
+// The middlewares only run when enabled by the config, a disabled middleware can still be the position of another.
+const (
+	// RequestIDMiddleware is first, so the access log, the recovery and the error responses have the request ID
+	RequestIDMiddleware = "request_id"
+	AccessLogMiddleware = "access_log"
+	// RecoveryMiddleware recovers from the panics of all the middlewares after it
+	RecoveryMiddleware        = "recovery"
+	CompressionMiddleware     = "compression"
+	CorsMiddleware            = "cors"
+	SecurityHeadersMiddleware = "security_headers"
+	// StartupGateMiddleware is before the rate limit, so the requests answered during the startup do not take tokens
+	StartupGateMiddleware  = "startup_gate"
+	RateLimitMiddleware    = "ratelimit"
+	MaxBodyBytesMiddleware = "max_body_bytes"
+	// TimeoutMiddleware is after the CORS middleware, so the CORS headers are kept in timed out responses
+	TimeoutMiddleware = "request_timeout"
+)
+
+// Middleware is a named middleware of the router, positioned before or after another middleware, e.g. an auth
+// middleware before RateLimitMiddleware so the rate limit is keyed by the authenticated client.
+// A middleware without a position runs after the built-in middlewares and the middlewares of AsMiddleware.
+// A nil Handler is a disabled middleware, it can still be the position of another.
+type Middleware struct {
+	Name    string
+	Handler gin.HandlerFunc
+	// Before is the name of the middleware this middleware runs before
+	Before string
+	// After is the name of the middleware this middleware runs after
+	After string
+}
+
+func AsOrderedMiddleware(middleware any) any {
+	return fx.Annotate(
+		middleware,
+		fx.ResultTags(`group:"orderedMiddlewares"`),
+	)
+}
+
+type middlewareEntry struct {
+	name    string
+	handler gin.HandlerFunc
+	// after is the position of a middleware inserted after another, so the next ones follow it
+	after string
+}
+
+// MiddlewareChain is the ordered list of the middlewares shared by the servers of the router.
+// The default order is request_id, access_log, recovery, compression, cors, security_headers, startup_gate,
+// ratelimit, max_body_bytes and request_timeout, followed by the middlewares of AsMiddleware, e.g. the metrics
+// and the tracing, in the order of the group. The middlewares of AsOrderedMiddleware are then inserted at their position,
+// the ones at the same position keep the order of the group.
+type MiddlewareChain struct {
+	entries []middlewareEntry
+}
+
+// NewMiddlewareChain returns the chain of the middlewares of the router
+func NewMiddlewareChain(p Params) (*MiddlewareChain, error) {
+	accessLogger, err := newAccessLogger(p.Config.accessLogConfig(p.Logger), p.Logger, p.Lifecycle)
+	if err != nil {
+		return nil, err
+	}
+	var recovery gin.HandlerFunc
+	if !p.Config.DisableRecovery {
+		if p.Logger != nil || len(p.PanicReporters) > 0 {
+			recovery = NewRecovery(p.Logger, p.PanicReporters...)
+		} else {
+			recovery = gin.Recovery()
+		}
+	}
+	var compression gin.HandlerFunc
+	if p.Config.Compression.Enabled {
+		compression = NewCompression(p.Config.Compression)
+	}
+	var securityHeaders gin.HandlerFunc
+	if p.Config.SecurityHeaders.Enabled {
+		securityHeaders = NewSecurityHeaders(p.Config.SecurityHeaders)
+	}
+	var startupGate gin.HandlerFunc
+	if p.StartupGate != nil && !p.StartupGate.Open() {
+		startupGate = NewStartupGateMiddleware(p.StartupGate, p.Config.StartupGate.ExcludePaths)
+	}
+	var rateLimit gin.HandlerFunc
+	if p.Config.RateLimit.RPS > 0 {
+		rateLimit = NewRateLimit(p.Config.RateLimit)
+	}
+	var maxBodyBytes gin.HandlerFunc
+	if p.Config.MaxBodyBytes > 0 {
+		maxBodyBytes = NewMaxBodyBytes(p.Config.MaxBodyBytes)
+	}
+	var timeout gin.HandlerFunc
+	if p.Config.RequestTimeout > 0 {
+		timeout = NewTimeout(p.Config.RequestTimeout, p.Config.RequestTimeoutExcludePaths, p.Logger)
+	}
+
+	chain := &MiddlewareChain{entries: []middlewareEntry{
+		{name: RequestIDMiddleware, handler: NewRequestID(p.Config.RequestIDHeader)},
+		{name: AccessLogMiddleware, handler: accessLogger},
+		{name: RecoveryMiddleware, handler: recovery},
+		{name: CompressionMiddleware, handler: compression},
+		{name: CorsMiddleware, handler: NewCors(p.Config.corsConfig(p.Logger))},
+		{name: SecurityHeadersMiddleware, handler: securityHeaders},
+		{name: StartupGateMiddleware, handler: startupGate},
+		{name: RateLimitMiddleware, handler: rateLimit},
+		{name: MaxBodyBytesMiddleware, handler: maxBodyBytes},
+		{name: TimeoutMiddleware, handler: timeout},
+	}}
+	for _, middleware := range p.Middlewares {
+		chain.entries = append(chain.entries, middlewareEntry{handler: middleware})
+	}
+	if err := chain.insert(p.OrderedMiddlewares); err != nil {
+		return nil, err
+	}
+	return chain, nil
+}
+
+// insert adds the middlewares at their position, a middleware can be positioned relative to another one of the list
+func (c *MiddlewareChain) insert(middlewares []Middleware) error {
+	names := make(map[string]bool)
+	for _, entry := range c.entries {
+		names[entry.name] = true
+	}
+	for _, middleware := range middlewares {
+		if middleware.Before != "" && middleware.After != "" {
+			return fmt.Errorf("%w: %q", ErrMiddlewarePositions, middleware.Name)
+		}
+		if middleware.Name == "" {
+			continue
+		}
+		if names[middleware.Name] {
+			return fmt.Errorf("%w: %q", ErrMiddlewareConflict, middleware.Name)
+		}
+		names[middleware.Name] = true
+	}
+
+	pending := middlewares
+	for len(pending) > 0 {
+		var next []Middleware
+		for _, middleware := range pending {
+			if !c.insertOne(middleware) {
+				next = append(next, middleware)
+			}
+		}
+		if len(next) == len(pending) {
+			position := next[0].Before
+			if position == "" {
+				position = next[0].After
+			}
+			return fmt.Errorf("%w: %q is the position of %q", ErrUnknownMiddleware, position, next[0].Name)
+		}
+		pending = next
+	}
+	return nil
+}
+
+// insertOne inserts the middleware, false when its position is not in the chain yet
+func (c *MiddlewareChain) insertOne(middleware Middleware) bool {
+	entry := middlewareEntry{name: middleware.Name, handler: middleware.Handler, after: middleware.After}
+	position := middleware.Before
+	if position == "" {
+		position = middleware.After
+	}
+	if position == "" {
+		c.entries = append(c.entries, entry)
+		return true
+	}
+	index := c.index(position)
+	if index < 0 {
+		return false
+	}
+	if middleware.After != "" {
+		index++
+		for index < len(c.entries) && c.entries[index].after == position {
+			index++
+		}
+	}
+	c.entries = append(c.entries[:index], append([]middlewareEntry{entry}, c.entries[index:]...)...)
+	return true
+}
+
+func (c *MiddlewareChain) index(name string) int {
+	for i, entry := range c.entries {
+		if entry.name == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// Names returns the names of the enabled middlewares in their order, the middlewares of AsMiddleware have no name
+func (c *MiddlewareChain) Names() []string {
+	var names []string
+	for _, entry := range c.entries {
+		if entry.handler != nil && entry.name != "" {
+			names = append(names, entry.name)
+		}
+	}
+	return names
+}
+
+// Handlers returns the enabled middlewares in their order
+func (c *MiddlewareChain) Handlers() []gin.HandlerFunc {
+	var handlers []gin.HandlerFunc
+	for _, entry := range c.entries {
+		if entry.handler != nil {
+			handlers = append(handlers, entry.handler)
+		}
+	}
+	return handlers
+}
+
+// MatchesPath reports whether the path is the excluded path or one of its sub-paths,
+// e.g. /metrics matches /metrics and /metrics/job but not /metricsfoo
+func MatchesPath(path string, excludePath string) bool {
+	if path == excludePath {
+		return true
+	}
+	if !strings.HasSuffix(excludePath, "/") {
+		excludePath += "/"
+	}
+	return strings.HasPrefix(path, excludePath)
+}
@@ -0,0 +1,107 @@
+package routerfx_test
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/prismedic/scalpel/routerfx"
+)
+
+func TestMiddlewareChain(t *testing.T) {
+	config := &routerfx.Config{
+		RateLimit:    routerfx.RateLimitConfig{RPS: 10, Burst: 10},
+		MaxBodyBytes: 1 << 20,
+	}
+	noop := func(*gin.Context) {}
+
+	t.Run("Test default order", func(t *testing.T) {
+		chain, err := routerfx.NewMiddlewareChain(routerfx.Params{Config: config})
+		if err != nil {
+			t.Fatalf("failed to create middleware chain: %v", err)
+		}
+		expected := []string{routerfx.RequestIDMiddleware, routerfx.RecoveryMiddleware, routerfx.RateLimitMiddleware, routerfx.MaxBodyBytesMiddleware}
+		if names := chain.Names(); !reflect.DeepEqual(names, expected) {
+			t.Errorf("unexpected middlewares, got %v, expected %v", names, expected)
+		}
+	})
+	t.Run("Test positions", func(t *testing.T) {
+		chain, err := routerfx.NewMiddlewareChain(routerfx.Params{
+			Config: config,
+			OrderedMiddlewares: []routerfx.Middleware{
+				{Name: "last", Handler: noop},
+				// positioned after a middleware of the list that is inserted later
+				{Name: "audit", Handler: noop, After: "auth"},
+				{Name: "auth", Handler: noop, Before: routerfx.RateLimitMiddleware},
+				{Name: "tenant", Handler: noop, After: routerfx.RequestIDMiddleware},
+				{Name: "locale", Handler: noop, After: routerfx.RequestIDMiddleware},
+				// the compression is disabled but it is still a position
+				{Name: "etag", Handler: noop, Before: routerfx.CompressionMiddleware},
+				{Name: "disabled", Before: routerfx.RecoveryMiddleware},
+			},
+		})
+		if err != nil {
+			t.Fatalf("failed to create middleware chain: %v", err)
+		}
+		expected := []string{
+			routerfx.RequestIDMiddleware, "tenant", "locale", routerfx.RecoveryMiddleware, "etag",
+			"auth", "audit", routerfx.RateLimitMiddleware, routerfx.MaxBodyBytesMiddleware, "last",
+		}
+		if names := chain.Names(); !reflect.DeepEqual(names, expected) {
+			t.Errorf("unexpected middlewares, got %v, expected %v", names, expected)
+		}
+	})
+	t.Run("Test invalid positions", func(t *testing.T) {
+		for _, test := range []struct {
+			middlewares []routerfx.Middleware
+			expectedErr error
+		}{
+			{middlewares: []routerfx.Middleware{{Name: "auth", Before: "session"}}, expectedErr: routerfx.ErrUnknownMiddleware},
+			{middlewares: []routerfx.Middleware{{Name: routerfx.CorsMiddleware}}, expectedErr: routerfx.ErrMiddlewareConflict},
+			{middlewares: []routerfx.Middleware{{Name: "auth", Before: routerfx.CorsMiddleware, After: routerfx.RequestIDMiddleware}}, expectedErr: routerfx.ErrMiddlewarePositions},
+		} {
+			if _, err := routerfx.NewMiddlewareChain(routerfx.Params{Config: config, OrderedMiddlewares: test.middlewares}); !errors.Is(err, test.expectedErr) {
+				t.Errorf("unexpected error, got %v, expected %v", err, test.expectedErr)
+			}
+		}
+	})
+	t.Run("Test order of the router", func(t *testing.T) {
+		result, err := routerfx.New(routerfx.Params{
+			Config:             &routerfx.Config{},
+			ControllerRoutes:   []routerfx.ControllerRoute{&testController{pattern: "/users"}},
+			Middlewares:        []gin.HandlerFunc{setHeader("+metrics")},
+			OrderedMiddlewares: []routerfx.Middleware{{Name: "auth", Handler: setHeader("auth"), Before: routerfx.RateLimitMiddleware}},
+		})
+		if err != nil {
+			t.Fatalf("failed to create router: %v", err)
+		}
+		recorder := httptest.NewRecorder()
+		result.Router.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/v1/users/", nil))
+		if body := recorder.Body.String(); body != "auth+metrics" {
+			t.Errorf("unexpected order of the middlewares, got %q", body)
+		}
+	})
+}
+
+func TestMatchesPath(t *testing.T) {
+	for _, test := range []struct {
+		path        string
+		excludePath string
+		matches     bool
+	}{
+		{"/metrics", "/metrics", true},
+		{"/metrics/job", "/metrics", true},
+		{"/metricsfoo", "/metrics", false},
+		{"/debug/pprof/heap", "/debug/pprof/", true},
+		{"/debug/pprof", "/debug/pprof/", false},
+		{"/v1/healthz/", "/v1/healthz", true},
+	} {
+		if matches := routerfx.MatchesPath(test.path, test.excludePath); matches != test.matches {
+			t.Errorf("unexpected match of %s with %s, got %t, expected %t", test.path, test.excludePath, matches, test.matches)
+		}
+	}
+}
@@ -0,0 +1,144 @@
+package routerfx
+
+import (
+	"container/list"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RateLimitConfig limits the requests of each client with a token bucket, which is disabled with zero RPS
+type RateLimitConfig struct {
+	// RPS is the rate of requests per second each client is allowed on average
+	RPS float64 `mapstructure:"rps" yaml:"rps" validate:"min=0"`
+	// Burst is the number of requests allowed at once, it defaults to RPS rounded up
+	Burst int `mapstructure:"burst" yaml:"burst" validate:"min=0"`
+	// KeyHeader identifies the client by a header such as X-API-Key instead of the client IP.
+	// The client IP is only read from the forwarded headers of the trusted proxies of the router.
+	KeyHeader string `mapstructure:"key_header" yaml:"key_header"`
+	// IdleTimeout is how long the bucket of an inactive client is kept
+	IdleTimeout time.Duration `mapstructure:"idle_timeout" yaml:"idle_timeout" validate:"min=0"`
+	// MaxClients is the number of clients whose bucket is kept, the least recently seen client is forgotten beyond it.
+	// It defaults to 10000 when zero.
+	MaxClients int `mapstructure:"max_clients" yaml:"max_clients" validate:"min=0"`
+	// ExcludePaths are path prefixes that are not limited
+	ExcludePaths []string `mapstructure:"exclude_paths" yaml:"exclude_paths"`
+}
+
+// defaultMaxClients is the number of buckets kept when MaxClients is zero
+const defaultMaxClients = 10000
+
+type tokenBucket struct {
+	key      string
+	tokens   float64
+	lastSeen time.Time
+}
+
+// rateLimiter keeps the buckets in the order the clients were last seen, the least recently seen first,
+// so the sweep and the eviction at MaxClients only walk the buckets they remove.
+type rateLimiter struct {
+	rps         float64
+	burst       float64
+	idleTimeout time.Duration
+	maxClients  int
+
+	mutex   sync.Mutex
+	buckets map[string]*list.Element
+	recent  *list.List
+}
+
+func newRateLimiter(config RateLimitConfig) *rateLimiter {
+	burst := config.Burst
+	if burst == 0 {
+		burst = int(math.Ceil(config.RPS))
+	}
+	maxClients := config.MaxClients
+	if maxClients == 0 {
+		maxClients = defaultMaxClients
+	}
+	return &rateLimiter{
+		rps:         config.RPS,
+		burst:       float64(burst),
+		idleTimeout: config.IdleTimeout,
+		maxClients:  maxClients,
+		buckets:     make(map[string]*list.Element),
+		recent:      list.New(),
+	}
+}
+
+// allow takes a token from the bucket of the key,
+// when the bucket is empty it returns how long until the next token is added
+func (l *rateLimiter) allow(key string, now time.Time) (bool, time.Duration) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	l.sweep(now)
+
+	var bucket *tokenBucket
+	if element, ok := l.buckets[key]; ok {
+		l.recent.MoveToBack(element)
+		bucket = element.Value.(*tokenBucket)
+	} else {
+		// the keys can be chosen by the clients, the least recently seen client is forgotten to make room
+		if l.recent.Len() >= l.maxClients {
+			l.remove(l.recent.Front())
+		}
+		bucket = &tokenBucket{key: key, tokens: l.burst, lastSeen: now}
+		l.buckets[key] = l.recent.PushBack(bucket)
+	}
+	bucket.tokens = math.Min(l.burst, bucket.tokens+now.Sub(bucket.lastSeen).Seconds()*l.rps)
+	bucket.lastSeen = now
+	if bucket.tokens >= 1 {
+		bucket.tokens--
+		return true, 0
+	}
+	return false, time.Duration((1 - bucket.tokens) / l.rps * float64(time.Second))
+}
+
+// sweep evicts the buckets of clients inactive for the idle timeout
+func (l *rateLimiter) sweep(now time.Time) {
+	if l.idleTimeout <= 0 {
+		return
+	}
+	for element := l.recent.Front(); element != nil; element = l.recent.Front() {
+		if now.Sub(element.Value.(*tokenBucket).lastSeen) < l.idleTimeout {
+			return
+		}
+		l.remove(element)
+	}
+}
+
+func (l *rateLimiter) remove(element *list.Element) {
+	delete(l.buckets, element.Value.(*tokenBucket).key)
+	l.recent.Remove(element)
+}
+
+// NewRateLimit returns a middleware answering requests over the limit of their client with 429 and a Retry-After header
+func NewRateLimit(config RateLimitConfig) gin.HandlerFunc {
+	limiter := newRateLimiter(config)
+	return func(c *gin.Context) {
+		for _, excludePath := range config.ExcludePaths {
+			if strings.HasPrefix(c.Request.URL.Path, excludePath) {
+				c.Next()
+				return
+			}
+		}
+		key := c.ClientIP()
+		if config.KeyHeader != "" {
+			if value := c.GetHeader(config.KeyHeader); value != "" {
+				key = value
+			}
+		}
+		allowed, retryAfter := limiter.allow(key, time.Now())
+		if !allowed {
+			c.Header("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+			AbortWithStatusError(c, http.StatusTooManyRequests, CodeRateLimited, "rate limit exceeded")
+			return
+		}
+		c.Next()
+	}
+}
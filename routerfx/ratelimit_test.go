@@ -0,0 +1,127 @@
+package routerfx_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/prismedic/scalpel/routerfx"
+)
+
+func TestNewRateLimit(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(routerfx.NewRateLimit(routerfx.RateLimitConfig{
+		RPS:          1,
+		Burst:        2,
+		KeyHeader:    "X-API-Key",
+		ExcludePaths: []string{"/v1/healthz"},
+	}))
+	handler := func(c *gin.Context) {
+		c.String(http.StatusOK, "ok")
+	}
+	router.GET("/", handler)
+	router.GET("/v1/healthz", handler)
+
+	get := func(path string, key string) *httptest.ResponseRecorder {
+		request := httptest.NewRequest(http.MethodGet, path, nil)
+		request.Header.Set("X-API-Key", key)
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, request)
+		return recorder
+	}
+
+	t.Run("Test over limit", func(t *testing.T) {
+		for i := 0; i < 2; i++ {
+			if recorder := get("/", "a"); recorder.Code != http.StatusOK {
+				t.Fatalf("unexpected status code of request %d, got %d, expected %d", i, recorder.Code, http.StatusOK)
+			}
+		}
+		recorder := get("/", "a")
+		if recorder.Code != http.StatusTooManyRequests {
+			t.Errorf("unexpected status code, got %d, expected %d", recorder.Code, http.StatusTooManyRequests)
+		}
+		if got := recorder.Header().Get("Retry-After"); got != "1" {
+			t.Errorf("unexpected Retry-After header, got %q, expected 1", got)
+		}
+	})
+	t.Run("Test separate clients", func(t *testing.T) {
+		if recorder := get("/", "b"); recorder.Code != http.StatusOK {
+			t.Errorf("unexpected status code, got %d, expected %d", recorder.Code, http.StatusOK)
+		}
+	})
+	t.Run("Test excluded path", func(t *testing.T) {
+		for i := 0; i < 5; i++ {
+			if recorder := get("/v1/healthz", "a"); recorder.Code != http.StatusOK {
+				t.Fatalf("unexpected status code, got %d, expected %d", recorder.Code, http.StatusOK)
+			}
+		}
+	})
+}
+
+func TestRateLimitClients(t *testing.T) {
+	t.Run("Test max clients", func(t *testing.T) {
+		gin.SetMode(gin.TestMode)
+		router := gin.New()
+		router.Use(routerfx.NewRateLimit(routerfx.RateLimitConfig{RPS: 1, Burst: 1, KeyHeader: "X-API-Key", MaxClients: 1}))
+		router.GET("/", func(c *gin.Context) {
+			c.String(http.StatusOK, "ok")
+		})
+		get := func(key string) int {
+			request := httptest.NewRequest(http.MethodGet, "/", nil)
+			request.Header.Set("X-API-Key", key)
+			recorder := httptest.NewRecorder()
+			router.ServeHTTP(recorder, request)
+			return recorder.Code
+		}
+
+		get("a")
+		if code := get("a"); code != http.StatusTooManyRequests {
+			t.Errorf("unexpected status code, got %d, expected %d", code, http.StatusTooManyRequests)
+		}
+		get("b")
+		// the bucket of a is evicted by the bucket of b
+		if code := get("a"); code != http.StatusOK {
+			t.Errorf("unexpected status code of an evicted client, got %d, expected %d", code, http.StatusOK)
+		}
+	})
+
+	get := func(t *testing.T, trustedProxies []string, forwardedFor string) int {
+		config := &routerfx.Config{
+			RateLimit:      routerfx.RateLimitConfig{RPS: 1, Burst: 1},
+			TrustedProxies: trustedProxies,
+		}
+		result, err := routerfx.New(routerfx.Params{Config: config})
+		if err != nil {
+			t.Fatalf("failed to create router: %v", err)
+		}
+		var code int
+		for _, client := range []string{"198.51.100.1", forwardedFor} {
+			request := httptest.NewRequest(http.MethodGet, "/", nil)
+			request.Header.Set("X-Forwarded-For", client)
+			recorder := httptest.NewRecorder()
+			result.Router.ServeHTTP(recorder, request)
+			code = recorder.Code
+		}
+		return code
+	}
+	t.Run("Test untrusted forwarded header", func(t *testing.T) {
+		// both requests come from the remote address of httptest
+		if code := get(t, nil, "198.51.100.2"); code != http.StatusTooManyRequests {
+			t.Errorf("unexpected status code, got %d, expected %d", code, http.StatusTooManyRequests)
+		}
+	})
+	t.Run("Test trusted proxy", func(t *testing.T) {
+		if code := get(t, []string{"192.0.2.0/24"}, "198.51.100.2"); code != http.StatusNotFound {
+			t.Errorf("unexpected status code, got %d, expected %d", code, http.StatusNotFound)
+		}
+	})
+	t.Run("Test invalid proxy", func(t *testing.T) {
+		_, err := routerfx.New(routerfx.Params{Config: &routerfx.Config{TrustedProxies: []string{"proxy"}}})
+		if err == nil {
+			t.Errorf("expected error of an invalid proxy")
+		}
+	})
+}
@@ -0,0 +1,85 @@
+package routerfx
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"runtime/debug"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/fx"
+	"go.uber.org/zap"
+)
+
+// PanicReporter is called by the recovery middleware with the recovered value and the stack of the panic,
+// e.g. to send it to an error tracking service. The request carries the context, so the request ID of the panic.
+// Reporters must not write the response, the recovery middleware answers 500 after them.
+type PanicReporter interface {
+	ReportPanic(request *http.Request, recovered any, stack []byte)
+}
+
+func AsPanicReporter(reporter any) any {
+	return fx.Annotate(
+		reporter,
+		fx.As(new(PanicReporter)),
+		fx.ResultTags(`group:"panicReporters"`),
+	)
+}
+
+// LogPanicReporter logs the panics with their stacktrace, it is the first reporter of the router when it has a logger
+type LogPanicReporter struct {
+	logger *zap.SugaredLogger
+}
+
+func NewLogPanicReporter(logger *zap.SugaredLogger) *LogPanicReporter {
+	return &LogPanicReporter{logger: logger}
+}
+
+func (r *LogPanicReporter) ReportPanic(request *http.Request, recovered any, stack []byte) {
+	fields := []any{
+		"panic", recovered,
+		"method", request.Method,
+		"path", request.URL.Path,
+		"stacktrace", string(stack),
+	}
+	if requestID, ok := RequestIDFromContext(request.Context()); ok {
+		fields = append(fields, "request_id", requestID)
+	}
+	r.logger.Errorw("recovered from panic", fields...)
+}
+
+// NewRecovery returns a middleware recovering from panics in handlers.
+// The panic is logged with its stacktrace, passed to the reporters and a 500 response is written.
+// http.ErrAbortHandler is panicked again so that aborted streaming responses are handled by net/http.
+func NewRecovery(logger *zap.SugaredLogger, reporters ...PanicReporter) gin.HandlerFunc {
+	if logger != nil {
+		reporters = append([]PanicReporter{NewLogPanicReporter(logger)}, reporters...)
+	}
+	return func(c *gin.Context) {
+		defer func() {
+			recovered := recover()
+			if recovered == nil {
+				return
+			}
+			if err, ok := recovered.(error); ok && errors.Is(err, http.ErrAbortHandler) {
+				panic(recovered)
+			}
+			stack := debug.Stack()
+			for _, reporter := range reporters {
+				reportPanic(reporter, c.Request, recovered, stack, logger)
+			}
+			AbortWithStatusError(c, http.StatusInternalServerError, CodeInternal, internalErrorMessage)
+		}()
+		c.Next()
+	}
+}
+
+// reportPanic recovers from the panics of the reporter, so a failing reporter does not skip the others and the response
+func reportPanic(reporter PanicReporter, request *http.Request, recovered any, stack []byte, logger *zap.SugaredLogger) {
+	defer func() {
+		if reporterPanic := recover(); reporterPanic != nil && logger != nil {
+			logger.Errorw("panic in panic reporter", "reporter", fmt.Sprintf("%T", reporter), "panic", reporterPanic)
+		}
+	}()
+	reporter.ReportPanic(request, recovered, stack)
+}
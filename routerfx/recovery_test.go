@@ -0,0 +1,66 @@
+package routerfx_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/prismedic/scalpel/routerfx"
+)
+
+type testPanicReporter struct {
+	recovered any
+	stack     string
+	requestID string
+}
+
+func (r *testPanicReporter) ReportPanic(request *http.Request, recovered any, stack []byte) {
+	r.recovered = recovered
+	r.stack = string(stack)
+	r.requestID, _ = routerfx.RequestIDFromContext(request.Context())
+}
+
+type panicHandler struct{}
+
+func (ph panicHandler) Handler() gin.HandlerFunc {
+	return func(*gin.Context) { panic("boom") }
+}
+
+func (ph panicHandler) RoutePattern() string {
+	return "/panic"
+}
+
+type failingPanicReporter struct{}
+
+func (r failingPanicReporter) ReportPanic(*http.Request, any, []byte) {
+	panic("reporter failed")
+}
+
+func TestNewRecovery(t *testing.T) {
+	reporter := &testPanicReporter{}
+	result, err := routerfx.New(routerfx.Params{
+		Config:         &routerfx.Config{},
+		PanicReporters: []routerfx.PanicReporter{failingPanicReporter{}, reporter},
+		HandlerRoutes:  []routerfx.HandlerRoute{panicHandler{}},
+	})
+	if err != nil {
+		t.Fatalf("failed to create router: %v", err)
+	}
+
+	request := httptest.NewRequest(http.MethodGet, "/panic", nil)
+	request.Header.Set(routerfx.DefaultRequestIDHeader, "req-1")
+	recorder := httptest.NewRecorder()
+	result.Router.ServeHTTP(recorder, request)
+	if recorder.Code != http.StatusInternalServerError {
+		t.Errorf("unexpected status code, got %d, expected %d", recorder.Code, http.StatusInternalServerError)
+	}
+	if reporter.recovered != "boom" || reporter.requestID != "req-1" {
+		t.Errorf("unexpected report, got %v with request ID %q", reporter.recovered, reporter.requestID)
+	}
+	if !strings.Contains(reporter.stack, "recovery_test.go") {
+		t.Errorf("stack does not contain the panicking handler:\n%s", reporter.stack)
+	}
+}
@@ -0,0 +1,68 @@
+package routerfx
+
+import (
+	"context"
+	"crypto/rand"
+	"fmt"
+
+	"github.com/gin-gonic/gin"
+)
+
+// DefaultRequestIDHeader is the header of the request ID when router.request_id_header is not set
+const DefaultRequestIDHeader = "X-Request-ID"
+
+// maxRequestIDLength bounds the IDs accepted from clients, longer IDs are replaced with a generated one
+const maxRequestIDLength = 128
+
+type requestIDKey struct{}
+
+// ContextWithRequestID returns a copy of ctx carrying the request ID
+func ContextWithRequestID(ctx context.Context, requestID string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, requestID)
+}
+
+// RequestIDFromContext returns the request ID stored in ctx, if any
+func RequestIDFromContext(ctx context.Context) (string, bool) {
+	requestID, ok := ctx.Value(requestIDKey{}).(string)
+	return requestID, ok && requestID != ""
+}
+
+// NewRequestID returns a middleware storing the request ID of the header in the request context and echoing it in the response.
+// A UUID is generated when the request has none, or when it is too long or has non-printable characters,
+// as it is written to the logs as it is.
+func NewRequestID(header string) gin.HandlerFunc {
+	if header == "" {
+		header = DefaultRequestIDHeader
+	}
+	return func(c *gin.Context) {
+		requestID := c.GetHeader(header)
+		if !validRequestID(requestID) {
+			requestID = newUUID()
+		}
+		c.Request = c.Request.WithContext(ContextWithRequestID(c.Request.Context(), requestID))
+		c.Header(header, requestID)
+		c.Next()
+	}
+}
+
+func validRequestID(requestID string) bool {
+	if requestID == "" || len(requestID) > maxRequestIDLength {
+		return false
+	}
+	for i := 0; i < len(requestID); i++ {
+		if requestID[i] < ' ' || requestID[i] > '~' {
+			return false
+		}
+	}
+	return true
+}
+
+// newUUID returns a random version 4 UUID
+func newUUID() string {
+	var uuid [16]byte
+	// crypto/rand only fails when the system has no entropy source
+	_, _ = rand.Read(uuid[:])
+	uuid[6] = uuid[6]&0x0f | 0x40
+	uuid[8] = uuid[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", uuid[0:4], uuid[4:6], uuid[6:8], uuid[8:10], uuid[10:16])
+}
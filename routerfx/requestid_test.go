@@ -0,0 +1,55 @@
+package routerfx_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/prismedic/scalpel/routerfx"
+)
+
+var uuidPattern = regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`)
+
+func TestRequestID(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(routerfx.NewRequestID("X-Correlation-ID"))
+	router.GET("/", func(c *gin.Context) {
+		requestID, _ := routerfx.RequestIDFromContext(c.Request.Context())
+		c.String(http.StatusOK, requestID)
+	})
+
+	serve := func(requestID string) *httptest.ResponseRecorder {
+		request := httptest.NewRequest(http.MethodGet, "/", nil)
+		if requestID != "" {
+			request.Header.Set("X-Correlation-ID", requestID)
+		}
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, request)
+		return recorder
+	}
+
+	t.Run("Test incoming request ID", func(t *testing.T) {
+		recorder := serve("abc-123")
+		if recorder.Body.String() != "abc-123" || recorder.Header().Get("X-Correlation-ID") != "abc-123" {
+			t.Errorf("unexpected request ID, got %s in context and %s in header", recorder.Body.String(), recorder.Header().Get("X-Correlation-ID"))
+		}
+	})
+	for name, requestID := range map[string]string{
+		"missing":       "",
+		"too long":      strings.Repeat("a", 129),
+		"non-printable": "abc\x1b[31m",
+	} {
+		t.Run("Test "+name+" request ID", func(t *testing.T) {
+			recorder := serve(requestID)
+			generated := recorder.Header().Get("X-Correlation-ID")
+			if !uuidPattern.MatchString(generated) || recorder.Body.String() != generated {
+				t.Errorf("unexpected generated request ID, got %s in context and %s in header", recorder.Body.String(), generated)
+			}
+		})
+	}
+}
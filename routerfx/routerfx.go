@@ -1,23 +1,99 @@
 package routerfx
 
 import (
+	"fmt"
 	"net/http"
+	"path"
+	"strings"
 	"time"
 
-	"github.com/gin-contrib/cors"
-	ginzap "github.com/gin-contrib/zap"
 	"github.com/gin-gonic/gin"
+	"github.com/spf13/viper"
 	"go.uber.org/fx"
 	"go.uber.org/zap"
 )
 
 var Module = fx.Module("router",
 	fx.Provide(New),
+	fx.Provide(
+		fx.Annotate(
+			NewStartupGate,
+			fx.ParamTags(``, ``, `optional:"true"`),
+		),
+	),
 	fx.Provide(AsControllerRoute(NewSwaggerController)),
 )
 
 type Config struct {
-	CorsAllowedOrigins []string `mapstructure:"cors_allowed_origins" yaml:"cors_allowed_origins"`
+	Cors        CorsConfig        `mapstructure:"cors" yaml:"cors"`
+	Compression CompressionConfig `mapstructure:"compression" yaml:"compression"`
+	RateLimit   RateLimitConfig   `mapstructure:"ratelimit" yaml:"ratelimit"`
+	AccessLog   AccessLogConfig   `mapstructure:"access_log" yaml:"access_log"`
+	// SecurityHeaders are disabled by default, as they only matter for browser-facing endpoints
+	SecurityHeaders SecurityHeadersConfig `mapstructure:"security_headers" yaml:"security_headers"`
+	// StartupGate is disabled by default, the modules holding it must be part of the app when it is enabled
+	StartupGate StartupGateConfig `mapstructure:"startup_gate" yaml:"startup_gate"`
+	// RequestTimeout cancels the context of requests running longer, they are answered with 503, zero disables it.
+	// The reads and the writes of the connections are limited by http.timeouts of httpfx.
+	RequestTimeout time.Duration `mapstructure:"request_timeout" yaml:"request_timeout" validate:"min=0"`
+	// RequestTimeoutExcludePaths are path prefixes without a request timeout
+	RequestTimeoutExcludePaths []string `mapstructure:"request_timeout_exclude_paths" yaml:"request_timeout_exclude_paths"`
+	// MaxBodyBytes limits the size of the request bodies, larger bodies are answered with 413, zero disables it.
+	// Routes accepting larger bodies replace it with WithMaxBodyBytes.
+	MaxBodyBytes int64 `mapstructure:"max_body_bytes" yaml:"max_body_bytes" validate:"min=0"`
+	// TrustedProxies are the IPs and CIDRs of the proxies whose X-Forwarded-For and X-Real-IP headers give the client IP
+	// of the rate limit and the logs. No proxy is trusted by default, the client IP is then the remote address.
+	TrustedProxies []string `mapstructure:"trusted_proxies" yaml:"trusted_proxies"`
+	// RequestIDHeader is the header of the request ID read from the requests and set in the responses
+	RequestIDHeader string `mapstructure:"request_id_header" yaml:"request_id_header"`
+	// DisableRecovery lets panics in handlers crash the server, which can be useful for debugging
+	DisableRecovery bool `mapstructure:"disable_recovery" yaml:"disable_recovery"`
+	// CorsAllowedOrigins are the allowed origins used when Cors has none.
+	//
+	// Deprecated: use Cors.AllowedOrigins, the key router.cors.allowed_origins.
+	CorsAllowedOrigins []string `mapstructure:"cors_allowed_origins" yaml:"cors_allowed_origins" validate:"dive,eq=*|startswith=http://|startswith=https://"`
+	// AccessLogIgnorePaths replace the ignored paths of the access log when they are set.
+	//
+	// Deprecated: use AccessLog.IgnorePaths, the key router.access_log.ignore_paths.
+	AccessLogIgnorePaths []string `mapstructure:"access_log_ignore_paths" yaml:"access_log_ignore_paths"`
+}
+
+func init() {
+	// config must have a default value for viper to load config from env variables
+	viper.SetDefault("router.cors.allowed_origins", []string{})
+	viper.SetDefault("router.cors_allowed_origins", []string{})
+	viper.SetDefault("router.cors.allowed_methods", []string{"GET", "POST", "PUT", "PATCH", "DELETE", "HEAD", "OPTIONS"})
+	viper.SetDefault("router.cors.allow_credentials", true)
+	viper.SetDefault("router.cors.max_age", 12*time.Hour)
+	viper.SetDefault("router.security_headers.enabled", false)
+	viper.SetDefault("router.security_headers.content_type_options", "nosniff")
+	viper.SetDefault("router.security_headers.frame_options", "DENY")
+	viper.SetDefault("router.security_headers.referrer_policy", "strict-origin-when-cross-origin")
+	viper.SetDefault("router.security_headers.content_security_policy", "")
+	viper.SetDefault("router.security_headers.hsts.max_age", 365*24*time.Hour)
+	viper.SetDefault("router.security_headers.hsts.include_subdomains", true)
+	viper.SetDefault("router.security_headers.hsts.preload", false)
+	viper.SetDefault("router.startup_gate.enabled", false)
+	viper.SetDefault("router.startup_gate.timeout", 0)
+	viper.SetDefault("router.startup_gate.exclude_paths", []string{"/metrics", "/v1/healthz", "/v1/readyz"})
+	viper.SetDefault("router.access_log.format", JSONAccessLogFormat)
+	viper.SetDefault("router.access_log.ignore_paths", []string{"/metrics", "/v1/healthz", "/v1/readyz"})
+	viper.SetDefault("router.access_log_ignore_paths", []string{})
+	viper.SetDefault("router.request_timeout", 30*time.Second)
+	viper.SetDefault("router.request_timeout_exclude_paths", []string{"/metrics", "/v1/healthz", "/v1/readyz", "/debug/pprof/"})
+	viper.SetDefault("router.max_body_bytes", 10<<20)
+	viper.SetDefault("router.trusted_proxies", []string{})
+	viper.SetDefault("router.request_id_header", DefaultRequestIDHeader)
+	viper.SetDefault("router.disable_recovery", false)
+	viper.SetDefault("router.compression.enabled", false)
+	viper.SetDefault("router.compression.min_size", 1024)
+	viper.SetDefault("router.compression.exclude_paths", []string{"/metrics"})
+	viper.SetDefault("router.ratelimit.rps", 0)
+	viper.SetDefault("router.ratelimit.burst", 0)
+	viper.SetDefault("router.ratelimit.key_header", "")
+	viper.SetDefault("router.ratelimit.idle_timeout", 10*time.Minute)
+	viper.SetDefault("router.ratelimit.max_clients", defaultMaxClients)
+	viper.SetDefault("router.ratelimit.exclude_paths", []string{"/metrics", "/v1/healthz", "/v1/readyz"})
 }
 
 type Params struct {
@@ -27,56 +103,118 @@ type Params struct {
 	ControllerRoutes []ControllerRoute  `group:"controllerRoutes"`
 	HandlerRoutes    []HandlerRoute     `group:"handlerRoutes"`
 	Middlewares      []gin.HandlerFunc  `group:"middlewares"`
+	// OrderedMiddlewares are inserted at their position in the chain of the built-in middlewares, see MiddlewareChain
+	OrderedMiddlewares []Middleware    `group:"orderedMiddlewares"`
+	Groups             []Group         `group:"routeGroups"`
+	PanicReporters     []PanicReporter `group:"panicReporters"`
+	// Dependencies gates the controllers declaring a dependency, see DependentRoute
+	Dependencies DependencyChecker `optional:"true"`
+	// StartupGate holds the requests until the startup tasks are done, it is provided by Module
+	StartupGate *StartupGate `optional:"true"`
+	// Lifecycle closes the file of the access log on stop
+	Lifecycle fx.Lifecycle `optional:"true"`
 }
 
 type Result struct {
 	fx.Out
 	Router http.Handler
+	// Servers are the handlers of the named servers of the ServerRoute routes, served by the servers of http.servers
+	Servers map[string]http.Handler `name:"serverHandlers"`
 }
 
-func New(p Params) Result {
+func New(p Params) (Result, error) {
 	gin.SetMode(gin.ReleaseMode)
 
-	router := gin.New()
-	if p.Logger != nil {
-		router.Use(ginzap.Ginzap(p.Logger.Desugar(), time.RFC3339, true))
+	chain, err := NewMiddlewareChain(p)
+	if err != nil {
+		return Result{}, err
 	}
-	router.Use(gin.Recovery())
-	corsConfig := cors.DefaultConfig()
-	corsConfig.AllowCredentials = true
-	corsConfig.AllowOrigins = p.Config.CorsAllowedOrigins
-	router.Use(cors.New(corsConfig))
-
-	for _, middleware := range p.Middlewares {
-		router.Use(middleware)
+	middlewares := chain.Handlers()
+	// the proxies are parsed once, so the engines of the servers cannot fail on them
+	if err := gin.New().SetTrustedProxies(p.Config.TrustedProxies); err != nil {
+		return Result{}, fmt.Errorf("error in trusted proxies: %w", err)
 	}
 
-	apiRouterGroup := router.Group("/v1")
+	groupMap, err := groupsByName(p.Groups)
+	if err != nil {
+		return Result{}, err
+	}
+	// all the servers share the middlewares, e.g. the rate limit of a client counts the requests to every server
+	servers := &serverRouters{
+		newEngine: func() *gin.Engine {
+			engine := gin.New()
+			_ = engine.SetTrustedProxies(p.Config.TrustedProxies)
+			engine.NoRoute(notFound)
+			engine.Use(middlewares...)
+			return engine
+		},
+		groups:  groupMap,
+		routers: make(map[string]*serverRouter),
+	}
+	// the default server is created even without routes, it is the http.Handler of the app
+	defaultRouter := servers.get(DefaultServer)
 	for _, route := range p.ControllerRoutes {
+		server := servers.get(routeServer(route))
+		routerGroup := server.api
+		if grouped, ok := route.(GroupedRoute); ok && grouped.RouteGroup() != "" {
+			routerGroup, err = server.groups.get(grouped.RouteGroup(), make(map[string]bool))
+			if err != nil {
+				return Result{}, err
+			}
+		}
+		gates, err := dependencyGates(route, p.Dependencies)
+		if err != nil {
+			return Result{}, err
+		}
 		if p.Logger != nil {
-			p.Logger.Infow("registering controller route", "pattern", route.RoutePattern())
+			p.Logger.Infow("registering controller route", "pattern", route.RoutePattern(), "prefix", routerGroup.BasePath(), "server", routeServer(route))
+		}
+		err = server.registry.register(fmt.Sprintf("%T", route), routerGroup, func(rg *gin.RouterGroup) {
+			route.RegisterControllerRoutes(rg.Group(route.RoutePattern(), gates...))
+		})
+		if err != nil {
+			return Result{}, err
 		}
-		route.RegisterControllerRoutes(
-			apiRouterGroup.Group(route.RoutePattern()),
-		)
 	}
 
 	for _, route := range p.HandlerRoutes {
+		server := servers.get(routeServer(route))
 		if p.Logger != nil {
-			p.Logger.Infow("registering handler route", "pattern", route.RoutePattern())
+			p.Logger.Infow("registering handler route", "pattern", route.RoutePattern(), "server", routeServer(route))
+		}
+		err = server.registry.register(fmt.Sprintf("%T", route), &server.engine.RouterGroup, func(rg *gin.RouterGroup) {
+			rg.Any(route.RoutePattern(), route.Handler())
+		})
+		if err != nil {
+			return Result{}, err
 		}
-		router.Any(route.RoutePattern(), route.Handler())
 	}
 
 	return Result{
-		Router: router,
-	}
+		Router:  defaultRouter.engine,
+		Servers: servers.handlers(),
+	}, nil
 }
 
 func (r *Result) GetHttpRouter() http.Handler {
 	return r.Router
 }
 
+// apiPrefix is the path prefix of the controller routes
+const apiPrefix = "/v1"
+
+// joinPaths joins the paths like gin does for route groups, keeping the trailing slash of the relative path
+func joinPaths(absolutePath string, relativePath string) string {
+	if relativePath == "" {
+		return absolutePath
+	}
+	finalPath := path.Join(absolutePath, relativePath)
+	if strings.HasSuffix(relativePath, "/") && !strings.HasSuffix(finalPath, "/") {
+		return finalPath + "/"
+	}
+	return finalPath
+}
+
 type ControllerRoute interface {
 	RegisterControllerRoutes(rg *gin.RouterGroup)
 	RoutePattern() string
@@ -102,3 +240,10 @@ func AsHandlerRoute(handler any) any {
 		fx.ResultTags(`group:"handlerRoutes"`),
 	)
 }
+
+func AsMiddleware(middleware any) any {
+	return fx.Annotate(
+		middleware,
+		fx.ResultTags(`group:"middlewares"`),
+	)
+}
@@ -0,0 +1,63 @@
+package routerfx
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SecurityHeadersConfig configures the security headers of browser-facing responses, headers with an empty value are not set
+type SecurityHeadersConfig struct {
+	Enabled bool `mapstructure:"enabled" yaml:"enabled"`
+	// ContentTypeOptions is the X-Content-Type-Options header, e.g. nosniff
+	ContentTypeOptions string `mapstructure:"content_type_options" yaml:"content_type_options"`
+	// FrameOptions is the X-Frame-Options header, e.g. DENY or SAMEORIGIN
+	FrameOptions   string `mapstructure:"frame_options" yaml:"frame_options"`
+	ReferrerPolicy string `mapstructure:"referrer_policy" yaml:"referrer_policy"`
+	// ContentSecurityPolicy is the Content-Security-Policy header, e.g. "default-src 'self'"
+	ContentSecurityPolicy string `mapstructure:"content_security_policy" yaml:"content_security_policy"`
+	// HSTS is the Strict-Transport-Security header, only set on requests served over TLS
+	HSTS struct {
+		// MaxAge disables the header when it is zero
+		MaxAge            time.Duration `mapstructure:"max_age" yaml:"max_age" validate:"min=0"`
+		IncludeSubdomains bool          `mapstructure:"include_subdomains" yaml:"include_subdomains"`
+		Preload           bool          `mapstructure:"preload" yaml:"preload"`
+	} `mapstructure:"hsts" yaml:"hsts"`
+}
+
+// NewSecurityHeaders returns a middleware setting the security headers before the handlers run,
+// so the handlers can still override them, e.g. a looser Content-Security-Policy for the swagger UI
+func NewSecurityHeaders(config SecurityHeadersConfig) gin.HandlerFunc {
+	headers := map[string]string{
+		"X-Content-Type-Options":  config.ContentTypeOptions,
+		"X-Frame-Options":         config.FrameOptions,
+		"Referrer-Policy":         config.ReferrerPolicy,
+		"Content-Security-Policy": config.ContentSecurityPolicy,
+	}
+	for name, value := range headers {
+		if value == "" {
+			delete(headers, name)
+		}
+	}
+	hsts := ""
+	if config.HSTS.MaxAge > 0 {
+		hsts = fmt.Sprintf("max-age=%d", int64(config.HSTS.MaxAge.Seconds()))
+		if config.HSTS.IncludeSubdomains {
+			hsts += "; includeSubDomains"
+		}
+		if config.HSTS.Preload {
+			hsts += "; preload"
+		}
+	}
+	return func(c *gin.Context) {
+		for name, value := range headers {
+			c.Header(name, value)
+		}
+		// browsers ignore the header over plain HTTP, a proxy terminating TLS should set it itself
+		if hsts != "" && c.Request.TLS != nil {
+			c.Header("Strict-Transport-Security", hsts)
+		}
+		c.Next()
+	}
+}
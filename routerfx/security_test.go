@@ -0,0 +1,56 @@
+package routerfx_test
+
+import (
+	"crypto/tls"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/prismedic/scalpel/routerfx"
+)
+
+func TestSecurityHeaders(t *testing.T) {
+	config := routerfx.SecurityHeadersConfig{
+		Enabled:            true,
+		ContentTypeOptions: "nosniff",
+		FrameOptions:       "DENY",
+		ReferrerPolicy:     "no-referrer",
+	}
+	config.HSTS.MaxAge = 24 * time.Hour
+	config.HSTS.IncludeSubdomains = true
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(routerfx.NewSecurityHeaders(config))
+	router.GET("/", func(c *gin.Context) {
+		c.String(http.StatusOK, "ok")
+	})
+
+	t.Run("Test plain HTTP", func(t *testing.T) {
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/", nil))
+		for name, expected := range map[string]string{
+			"X-Content-Type-Options":    "nosniff",
+			"X-Frame-Options":           "DENY",
+			"Referrer-Policy":           "no-referrer",
+			"Content-Security-Policy":   "",
+			"Strict-Transport-Security": "",
+		} {
+			if got := recorder.Header().Get(name); got != expected {
+				t.Errorf("unexpected %s header, got %q, expected %q", name, got, expected)
+			}
+		}
+	})
+	t.Run("Test TLS", func(t *testing.T) {
+		request := httptest.NewRequest(http.MethodGet, "/", nil)
+		request.TLS = &tls.ConnectionState{}
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, request)
+		if got := recorder.Header().Get("Strict-Transport-Security"); got != "max-age=86400; includeSubDomains" {
+			t.Errorf("unexpected Strict-Transport-Security header, got %q", got)
+		}
+	})
+}
@@ -0,0 +1,65 @@
+package routerfx
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// DefaultServer is the name of the server of the routes without a server, it is the http.Handler of the app
+const DefaultServer = ""
+
+// ServerRoute is implemented by controller and handler routes served by a named server of http.servers,
+// e.g. "admin" for the metrics and health routes. RouteServer returns the name, the default server when it is empty.
+type ServerRoute interface {
+	RouteServer() string
+}
+
+// routeServer returns the name of the server of the route
+func routeServer(route any) string {
+	if server, ok := route.(ServerRoute); ok {
+		return server.RouteServer()
+	}
+	return DefaultServer
+}
+
+// serverRouter is the router of a server, each server has its own groups and its own route conflicts
+type serverRouter struct {
+	engine   *gin.Engine
+	groups   *routerGroups
+	api      *gin.RouterGroup
+	registry *routeRegistry
+}
+
+// serverRouters creates the router of each server on its first route, with the middlewares of the default server
+type serverRouters struct {
+	newEngine func() *gin.Engine
+	groups    map[string]Group
+	routers   map[string]*serverRouter
+}
+
+func (s *serverRouters) get(name string) *serverRouter {
+	if router, ok := s.routers[name]; ok {
+		return router
+	}
+	engine := s.newEngine()
+	router := &serverRouter{
+		engine:   engine,
+		groups:   &routerGroups{router: engine, groups: s.groups, cache: make(map[string]*gin.RouterGroup)},
+		api:      engine.Group(apiPrefix),
+		registry: newRouteRegistry(engine),
+	}
+	s.routers[name] = router
+	return router
+}
+
+// handlers returns the handlers of the named servers, without the default server
+func (s *serverRouters) handlers() map[string]http.Handler {
+	handlers := make(map[string]http.Handler, len(s.routers))
+	for name, router := range s.routers {
+		if name != DefaultServer {
+			handlers[name] = router.engine
+		}
+	}
+	return handlers
+}
@@ -0,0 +1,60 @@
+package routerfx_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/prismedic/scalpel/routerfx"
+)
+
+type testServerController struct {
+	testController
+	server string
+}
+
+func (tc *testServerController) RouteServer() string {
+	return tc.server
+}
+
+func TestServers(t *testing.T) {
+	result, err := routerfx.New(routerfx.Params{
+		Config: &routerfx.Config{},
+		ControllerRoutes: []routerfx.ControllerRoute{
+			&testController{pattern: "/users"},
+			// the same route on another server does not conflict
+			&testServerController{testController: testController{pattern: "/users"}, server: "admin"},
+			&testServerController{testController: testController{pattern: "/settings"}, server: "admin"},
+		},
+		Middlewares: []gin.HandlerFunc{setHeader("shared")},
+	})
+	if err != nil {
+		t.Fatalf("failed to create router: %v", err)
+	}
+	admin, ok := result.Servers["admin"]
+	if !ok || len(result.Servers) != 1 {
+		t.Fatalf("unexpected servers %v", result.Servers)
+	}
+
+	for _, test := range []struct {
+		handler      http.Handler
+		path         string
+		expectedCode int
+	}{
+		{handler: result.Router, path: "/v1/users/", expectedCode: http.StatusOK},
+		{handler: result.Router, path: "/v1/settings/", expectedCode: http.StatusNotFound},
+		{handler: admin, path: "/v1/users/", expectedCode: http.StatusOK},
+		{handler: admin, path: "/v1/settings/", expectedCode: http.StatusOK},
+	} {
+		recorder := httptest.NewRecorder()
+		test.handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, test.path, nil))
+		if recorder.Code != test.expectedCode {
+			t.Errorf("unexpected status code of %s, got %d, expected %d", test.path, recorder.Code, test.expectedCode)
+		}
+		if test.expectedCode == http.StatusOK && recorder.Body.String() != "shared" {
+			t.Errorf("middlewares are not applied to %s, got %q", test.path, recorder.Body.String())
+		}
+	}
+}
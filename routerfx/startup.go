@@ -0,0 +1,148 @@
+package routerfx
+
+import (
+	"context"
+	"net/http"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/fx"
+	"go.uber.org/zap"
+)
+
+// StartupGateConfig answers the requests with 503 until the startup tasks are done, the server still accepts the connections
+type StartupGateConfig struct {
+	Enabled bool `mapstructure:"enabled" yaml:"enabled"`
+	// Timeout opens the gate when the tasks are not done in time after the start, zero waits for the tasks
+	Timeout time.Duration `mapstructure:"timeout" yaml:"timeout" validate:"min=0"`
+	// ExcludePaths are the paths served during the startup with their sub-paths, e.g. the health routes
+	ExcludePaths []string `mapstructure:"exclude_paths" yaml:"exclude_paths"`
+}
+
+// StartupGate is released by the modules warming their dependencies. Each module holds the gate with Hold
+// in its constructor or invoke, and calls the returned release once it is done, e.g. at the end of an OnStart goroutine.
+// The gate opens once the app is started and all the holds are released, it is never closed again.
+// It is unrelated to the readiness checks, which keep running for the whole life of the service.
+type StartupGate struct {
+	opened  atomic.Bool
+	logger  *zap.SugaredLogger
+	mutex   sync.Mutex
+	started bool
+	pending map[string]int
+	timer   *time.Timer
+}
+
+// NewStartupGate returns the gate of the config, a disabled gate is open from the start and its holds do nothing.
+// The logger is optional.
+func NewStartupGate(lifecycle fx.Lifecycle, config *Config, logger *zap.SugaredLogger) *StartupGate {
+	gate := &StartupGate{logger: logger, pending: make(map[string]int)}
+	if !config.StartupGate.Enabled {
+		gate.opened.Store(true)
+		return gate
+	}
+	lifecycle.Append(fx.Hook{
+		OnStart: func(ctx context.Context) error {
+			gate.mutex.Lock()
+			defer gate.mutex.Unlock()
+			gate.started = true
+			if timeout := config.StartupGate.Timeout; timeout > 0 {
+				gate.timer = time.AfterFunc(timeout, gate.expire)
+			}
+			gate.openIfDone()
+			return nil
+		},
+		OnStop: func(ctx context.Context) error {
+			gate.mutex.Lock()
+			defer gate.mutex.Unlock()
+			if gate.timer != nil {
+				gate.timer.Stop()
+			}
+			return nil
+		},
+	})
+	return gate
+}
+
+// Hold keeps the gate closed until the returned release is called, the name of the task is logged when the gate times out.
+// Holding an open gate does nothing.
+func (g *StartupGate) Hold(name string) (release func()) {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+	if g.opened.Load() {
+		return func() {}
+	}
+	g.pending[name]++
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			g.mutex.Lock()
+			defer g.mutex.Unlock()
+			if g.pending[name]--; g.pending[name] == 0 {
+				delete(g.pending, name)
+			}
+			g.openIfDone()
+		})
+	}
+}
+
+// Open reports whether the requests are served
+func (g *StartupGate) Open() bool {
+	return g.opened.Load()
+}
+
+// openIfDone opens the gate once the app is started and no task holds it, the mutex must be held
+func (g *StartupGate) openIfDone() {
+	if g.opened.Load() || !g.started || len(g.pending) > 0 {
+		return
+	}
+	g.open()
+	if g.logger != nil {
+		g.logger.Info("startup tasks are done, serving the requests")
+	}
+}
+
+func (g *StartupGate) open() {
+	g.opened.Store(true)
+	if g.timer != nil {
+		g.timer.Stop()
+	}
+}
+
+func (g *StartupGate) expire() {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+	if g.opened.Load() {
+		return
+	}
+	tasks := make([]string, 0, len(g.pending))
+	for name := range g.pending {
+		tasks = append(tasks, name)
+	}
+	sort.Strings(tasks)
+	g.open()
+	if g.logger != nil {
+		g.logger.Warnw("startup tasks are not done before the timeout, serving the requests", "tasks", tasks)
+	}
+}
+
+// NewStartupGateMiddleware returns a middleware answering 503 with a Retry-After header while the gate is closed,
+// requests to any of excludePaths or their sub-paths are always served
+func NewStartupGateMiddleware(gate *StartupGate, excludePaths []string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if gate.Open() {
+			c.Next()
+			return
+		}
+		for _, excludePath := range excludePaths {
+			if MatchesPath(c.Request.URL.Path, excludePath) {
+				c.Next()
+				return
+			}
+		}
+		c.Header("Retry-After", "1")
+		AbortWithStatusError(c, http.StatusServiceUnavailable, CodeUnavailable, "service is starting")
+	}
+}
@@ -0,0 +1,81 @@
+package routerfx_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"go.uber.org/fx/fxtest"
+
+	"github.com/prismedic/scalpel/routerfx"
+)
+
+func TestStartupGate(t *testing.T) {
+	newConfig := func(timeout time.Duration) *routerfx.Config {
+		config := &routerfx.Config{}
+		config.StartupGate = routerfx.StartupGateConfig{Enabled: true, Timeout: timeout, ExcludePaths: []string{"/v1/healthz"}}
+		return config
+	}
+	get := func(router http.Handler, path string) int {
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, path, nil))
+		return recorder.Code
+	}
+
+	t.Run("Test released by the startup tasks", func(t *testing.T) {
+		config := newConfig(0)
+		lifecycle := fxtest.NewLifecycle(t)
+		gate := routerfx.NewStartupGate(lifecycle, config, nil)
+		releaseCache := gate.Hold("cache")
+		releaseIndex := gate.Hold("index")
+		result, err := routerfx.New(routerfx.Params{
+			Config:           config,
+			ControllerRoutes: []routerfx.ControllerRoute{&testController{pattern: "/users"}, &testController{pattern: "/healthz"}},
+			StartupGate:      gate,
+		})
+		if err != nil {
+			t.Fatalf("failed to create router: %v", err)
+		}
+		lifecycle.RequireStart()
+		defer lifecycle.RequireStop()
+
+		if code := get(result.Router, "/v1/healthz/"); code != http.StatusOK {
+			t.Errorf("unexpected status code of an excluded path, got %d, expected %d", code, http.StatusOK)
+		}
+		if code := get(result.Router, "/v1/healthzfoo"); code != http.StatusServiceUnavailable {
+			t.Errorf("unexpected status code of a path sharing the prefix of an excluded path, got %d, expected %d", code, http.StatusServiceUnavailable)
+		}
+		releaseCache()
+		releaseCache()
+		if code := get(result.Router, "/v1/users/"); code != http.StatusServiceUnavailable {
+			t.Errorf("unexpected status code with a pending task, got %d, expected %d", code, http.StatusServiceUnavailable)
+		}
+		releaseIndex()
+		if code := get(result.Router, "/v1/users/"); code != http.StatusOK {
+			t.Errorf("unexpected status code once the tasks are done, got %d, expected %d", code, http.StatusOK)
+		}
+	})
+	t.Run("Test timeout", func(t *testing.T) {
+		config := newConfig(20 * time.Millisecond)
+		lifecycle := fxtest.NewLifecycle(t)
+		gate := routerfx.NewStartupGate(lifecycle, config, nil)
+		gate.Hold("cache")
+		lifecycle.RequireStart()
+		defer lifecycle.RequireStop()
+		if gate.Open() {
+			t.Errorf("gate is open before the timeout")
+		}
+		time.Sleep(50 * time.Millisecond)
+		if !gate.Open() {
+			t.Errorf("gate is still closed after the timeout")
+		}
+	})
+	t.Run("Test disabled", func(t *testing.T) {
+		gate := routerfx.NewStartupGate(fxtest.NewLifecycle(t), &routerfx.Config{}, nil)
+		gate.Hold("cache")
+		if !gate.Open() {
+			t.Errorf("disabled gate is closed")
+		}
+	})
+}
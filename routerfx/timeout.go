@@ -0,0 +1,119 @@
+package routerfx
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// NewTimeout returns a middleware cancelling the request context after the timeout.
+// The response is buffered like http.TimeoutHandler, so a handler returning after the deadline
+// is answered with 503 instead of its own response. Handlers must watch the request context to return early,
+// requests with a path starting with any of excludePaths are not limited, e.g. streamed responses.
+func NewTimeout(timeout time.Duration, excludePaths []string, logger *zap.SugaredLogger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		for _, excludePath := range excludePaths {
+			if strings.HasPrefix(c.Request.URL.Path, excludePath) {
+				c.Next()
+				return
+			}
+		}
+
+		ctx, cancel := context.WithTimeout(c.Request.Context(), timeout)
+		defer cancel()
+		c.Request = c.Request.WithContext(ctx)
+
+		writer := &timeoutWriter{
+			ResponseWriter: c.Writer,
+			header:         make(http.Header),
+			status:         c.Writer.Status(),
+		}
+		c.Writer = writer
+		// restored on panics too, so the recovery middleware writes to the connection
+		defer func() { c.Writer = writer.ResponseWriter }()
+		c.Next()
+		c.Writer = writer.ResponseWriter
+
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			if logger != nil {
+				logger.Warnw("request timed out", "method", c.Request.Method, "path", c.Request.URL.Path, "timeout", timeout)
+			}
+			AbortWithStatusError(c, http.StatusServiceUnavailable, CodeTimeout, "request timed out")
+			return
+		}
+		writer.flush()
+	}
+}
+
+// timeoutWriter holds the headers, status and body of the response until the handlers are done
+type timeoutWriter struct {
+	gin.ResponseWriter
+	header      http.Header
+	status      int
+	wroteHeader bool
+	body        bytes.Buffer
+}
+
+func (w *timeoutWriter) Header() http.Header {
+	return w.header
+}
+
+func (w *timeoutWriter) WriteHeader(code int) {
+	if !w.wroteHeader {
+		w.status = code
+	}
+}
+
+func (w *timeoutWriter) WriteHeaderNow() {
+	w.wroteHeader = true
+}
+
+func (w *timeoutWriter) Write(data []byte) (int, error) {
+	w.wroteHeader = true
+	return w.body.Write(data)
+}
+
+func (w *timeoutWriter) WriteString(s string) (int, error) {
+	w.wroteHeader = true
+	return w.body.WriteString(s)
+}
+
+func (w *timeoutWriter) Status() int {
+	return w.status
+}
+
+func (w *timeoutWriter) Size() int {
+	if !w.wroteHeader {
+		return -1
+	}
+	return w.body.Len()
+}
+
+func (w *timeoutWriter) Written() bool {
+	return w.wroteHeader
+}
+
+// Flush is a no-op, the body is only sent when the handlers are done
+func (w *timeoutWriter) Flush() {}
+
+func (w *timeoutWriter) flush() {
+	header := w.ResponseWriter.Header()
+	for key, values := range w.header {
+		header[key] = values
+	}
+	w.ResponseWriter.WriteHeader(w.status)
+	if !w.wroteHeader {
+		return
+	}
+	if w.body.Len() == 0 {
+		w.ResponseWriter.WriteHeaderNow()
+		return
+	}
+	_, _ = w.ResponseWriter.Write(w.body.Bytes())
+}
@@ -0,0 +1,63 @@
+package routerfx_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/prismedic/scalpel/routerfx"
+)
+
+func getWithTimeout(path string) *httptest.ResponseRecorder {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(routerfx.NewTimeout(10*time.Millisecond, []string{"/v1/healthz"}, nil))
+	slowHandler := func(c *gin.Context) {
+		select {
+		case <-c.Request.Context().Done():
+			c.String(http.StatusInternalServerError, c.Request.Context().Err().Error())
+		case <-time.After(100 * time.Millisecond):
+			c.String(http.StatusOK, "done")
+		}
+	}
+	router.GET("/slow", slowHandler)
+	router.GET("/v1/healthz", slowHandler)
+	router.GET("/fast", func(c *gin.Context) {
+		c.Header("X-Test", "fast")
+		c.String(http.StatusAccepted, "done")
+	})
+
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, path, nil))
+	return recorder
+}
+
+func TestNewTimeout(t *testing.T) {
+	t.Run("Test timed out request", func(t *testing.T) {
+		recorder := getWithTimeout("/slow")
+		if recorder.Code != http.StatusServiceUnavailable {
+			t.Errorf("unexpected status code, got %d, expected %d", recorder.Code, http.StatusServiceUnavailable)
+		}
+	})
+	t.Run("Test fast request", func(t *testing.T) {
+		recorder := getWithTimeout("/fast")
+		if recorder.Code != http.StatusAccepted || recorder.Body.String() != "done" || recorder.Header().Get("X-Test") != "fast" {
+			t.Errorf("unexpected response, got %d %s %v", recorder.Code, recorder.Body.String(), recorder.Header())
+		}
+	})
+	t.Run("Test excluded path", func(t *testing.T) {
+		recorder := getWithTimeout("/v1/healthz")
+		if recorder.Code != http.StatusOK {
+			t.Errorf("unexpected status code, got %d, expected %d", recorder.Code, http.StatusOK)
+		}
+	})
+	t.Run("Test not found route", func(t *testing.T) {
+		recorder := getWithTimeout("/missing")
+		if recorder.Code != http.StatusNotFound {
+			t.Errorf("unexpected status code, got %d, expected %d", recorder.Code, http.StatusNotFound)
+		}
+	})
+}
@@ -3,14 +3,20 @@ package scalpel
 import (
 	"go.uber.org/fx"
 
+	"github.com/prismedic/scalpel/config"
 	"github.com/prismedic/scalpel/httpfx"
 	"github.com/prismedic/scalpel/infofx"
 	"github.com/prismedic/scalpel/loggerfx"
 	"github.com/prismedic/scalpel/metricsfx"
 	"github.com/prismedic/scalpel/routerfx"
+	"github.com/prismedic/scalpel/shutdownfx"
 )
 
+// Module includes shutdownfx first, so its stop hook runs after the stop hooks of the other modules.
+// The validations of the modules are registered on the validator by config.ValidationModule.
 var Module = fx.Options(
+	config.ValidationModule,
+	shutdownfx.Module,
 	httpfx.Module,
 	infofx.Module,
 	loggerfx.Module,
@@ -0,0 +1,34 @@
+package sentryfx
+
+import (
+	"net/http"
+
+	"github.com/getsentry/sentry-go"
+
+	"github.com/prismedic/scalpel/routerfx"
+)
+
+// PanicReporter sends the panics recovered by the router to Sentry, with the request and its request ID.
+// The events are sent in the background, the stop hook of RunSentry flushes them.
+type PanicReporter struct{}
+
+func NewPanicReporter() *PanicReporter {
+	return &PanicReporter{}
+}
+
+func (r *PanicReporter) ReportPanic(request *http.Request, recovered any, stack []byte) {
+	hub := sentry.GetHubFromContext(request.Context())
+	if hub == nil {
+		hub = sentry.CurrentHub().Clone()
+	}
+	hub.WithScope(func(scope *sentry.Scope) {
+		scope.SetRequest(request)
+		if requestID, ok := routerfx.RequestIDFromContext(request.Context()); ok {
+			scope.SetTag("request_id", requestID)
+		}
+		// Sentry takes the stacktrace of the goroutine, which still has the frames of the panic
+		hub.RecoverWithContext(request.Context(), recovered)
+	})
+}
+
+var _ routerfx.PanicReporter = (*PanicReporter)(nil)
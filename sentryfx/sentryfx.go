@@ -7,6 +7,8 @@ import (
 	"github.com/getsentry/sentry-go"
 	"github.com/spf13/viper"
 	"go.uber.org/fx"
+
+	"github.com/prismedic/scalpel/routerfx"
 )
 
 var (
@@ -44,4 +46,5 @@ func RunSentry(lifecycle fx.Lifecycle, config *SentryConfig) {
 
 var Module = fx.Module("sentry",
 	fx.Invoke(RunSentry),
+	fx.Provide(routerfx.AsPanicReporter(NewPanicReporter)),
 )
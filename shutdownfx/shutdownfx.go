@@ -0,0 +1,107 @@
+package shutdownfx
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/spf13/viper"
+	"go.uber.org/fx"
+	"go.uber.org/zap"
+)
+
+var Module = fx.Module("shutdown",
+	fx.Invoke(HandleSignals),
+)
+
+type ShutdownConfig struct {
+	// Timeout is the grace period of the stop hooks, the process exits when they are not done in time
+	Timeout time.Duration `mapstructure:"timeout" yaml:"timeout" validate:"min=0"`
+}
+
+// defaultTimeout is the grace period when the app does not provide the config
+const defaultTimeout = 15 * time.Second
+
+func init() {
+	// config must have a default value for viper to load config from env variables
+	viper.SetDefault("shutdown.timeout", defaultTimeout)
+}
+
+// StopTimeout sets the timeout of the stop context of fx to shutdown.timeout.
+// It is read from viper directly as fx only accepts it when the app is created,
+// so it must be passed to fx.New after the config is loaded.
+func StopTimeout() fx.Option {
+	return fx.StopTimeout(viper.GetDuration("shutdown.timeout"))
+}
+
+// exit is replaced in tests
+var exit = os.Exit
+
+type SignalParams struct {
+	fx.In
+	Lifecycle  fx.Lifecycle
+	Shutdowner fx.Shutdowner
+	// Config is optional, so the apps not providing it keep starting, the grace period is 15s without it
+	Config *ShutdownConfig `optional:"true"`
+	Logger *zap.SugaredLogger
+}
+
+// HandleSignals shuts down the app on SIGINT or SIGTERM.
+// A second signal, or stop hooks running longer than the grace period, force the process to exit.
+// The signals are released on stop, so they do not reach the app once it is stopped.
+func HandleSignals(p SignalParams) {
+	timeout := defaultTimeout
+	if p.Config != nil {
+		timeout = p.Config.Timeout
+	}
+	signals := make(chan os.Signal, 2)
+	stopped := make(chan struct{})
+	done := make(chan struct{})
+	p.Lifecycle.Append(fx.Hook{
+		OnStart: func(context.Context) error {
+			signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM)
+			go func() {
+				defer close(done)
+				var received os.Signal
+				select {
+				case received = <-signals:
+				case <-stopped:
+					return
+				}
+				p.Logger.Infow("Received signal, shutting down", "signal", received.String(), "timeout", timeout)
+				go func() {
+					select {
+					case <-stopped:
+					case <-time.After(timeout):
+						p.Logger.Errorw("Graceful shutdown timed out, forcing exit", "timeout", timeout)
+						_ = p.Logger.Sync()
+						exit(1)
+					}
+				}()
+				if err := p.Shutdowner.Shutdown(); err != nil {
+					p.Logger.Errorw("Fail to shut down", "err", err)
+				}
+
+				select {
+				case received = <-signals:
+				case <-stopped:
+					return
+				}
+				p.Logger.Warnw("Received second signal, forcing exit", "signal", received.String())
+				_ = p.Logger.Sync()
+				exit(1)
+			}()
+			return nil
+		},
+		// the hook is appended before the hooks of the other modules, so it runs after their stop hooks are done
+		OnStop: func(context.Context) error {
+			signal.Stop(signals)
+			close(stopped)
+			<-done
+			p.Logger.Info("Shutdown complete")
+			return nil
+		},
+	})
+}
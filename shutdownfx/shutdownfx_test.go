@@ -0,0 +1,71 @@
+package shutdownfx_test
+
+import (
+	"os"
+	"syscall"
+	"testing"
+	"time"
+
+	"go.uber.org/fx"
+	"go.uber.org/fx/fxtest"
+
+	"github.com/prismedic/scalpel/loggerfx"
+	"github.com/prismedic/scalpel/shutdownfx"
+)
+
+// shutDownOnSignal sends SIGTERM to the process and waits for the shutdown of the app
+func shutDownOnSignal(t *testing.T, app *fxtest.App) {
+	done := app.Done()
+	process, err := os.FindProcess(os.Getpid())
+	if err != nil {
+		t.Fatalf("failed to find process: %v", err)
+	}
+	if err := process.Signal(syscall.SIGTERM); err != nil {
+		t.Fatalf("failed to send signal: %v", err)
+	}
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Errorf("app is not shut down after SIGTERM")
+	}
+}
+
+func TestHandleSignals(t *testing.T) {
+	t.Run("Test shutdown on SIGTERM", func(t *testing.T) {
+		app := fxtest.New(t,
+			loggerfx.TestModule,
+			shutdownfx.Module,
+			fx.Supply(&shutdownfx.ShutdownConfig{Timeout: time.Minute}),
+		)
+		app.RequireStart()
+		defer app.RequireStop()
+		shutDownOnSignal(t, app)
+	})
+	t.Run("Test without config", func(t *testing.T) {
+		app := fxtest.New(t,
+			loggerfx.TestModule,
+			shutdownfx.Module,
+		)
+		app.RequireStart()
+		defer app.RequireStop()
+		shutDownOnSignal(t, app)
+	})
+	t.Run("Test signals after stop", func(t *testing.T) {
+		stopped := fxtest.New(t,
+			loggerfx.TestModule,
+			shutdownfx.Module,
+		)
+		stopped.RequireStart()
+		shutDownOnSignal(t, stopped)
+		stopped.RequireStop()
+
+		// the stopped app would take the signal as a second signal and exit the process
+		app := fxtest.New(t,
+			loggerfx.TestModule,
+			shutdownfx.Module,
+		)
+		app.RequireStart()
+		defer app.RequireStop()
+		shutDownOnSignal(t, app)
+	})
+}
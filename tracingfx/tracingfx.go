@@ -0,0 +1,81 @@
+package tracingfx
+
+import (
+	"context"
+
+	"github.com/spf13/viper"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/fx"
+
+	"github.com/prismedic/scalpel/config"
+	"github.com/prismedic/scalpel/routerfx"
+)
+
+var Module = fx.Module("tracing",
+	fx.Provide(NewTracerProvider),
+	fx.Provide(routerfx.AsMiddleware(NewTracingMiddleware)),
+)
+
+type TracingConfig struct {
+	// Endpoint is the host:port of the OTLP HTTP receiver, tracing is a no-op when it is empty
+	Endpoint string `mapstructure:"endpoint" yaml:"endpoint" validate:"omitempty,hostname_port"`
+	Insecure bool   `mapstructure:"insecure" yaml:"insecure"`
+	// SamplerRatio is the fraction of root spans sampled, child spans follow the sampling decision of their parent
+	SamplerRatio float64 `mapstructure:"sampler_ratio" yaml:"sampler_ratio" validate:"min=0,max=1"`
+}
+
+func init() {
+	// config must have a default value for viper to load config from env variables
+	viper.SetDefault("tracing.endpoint", "")
+	viper.SetDefault("tracing.insecure", false)
+	viper.SetDefault("tracing.sampler_ratio", 1.0)
+}
+
+type TracerProviderParams struct {
+	fx.In
+	Lifecycle fx.Lifecycle
+	Config    *TracingConfig
+}
+
+// NewTracerProvider sets up the global tracer provider and propagator exporting spans with OTLP.
+// The exporter is flushed and shut down on stop.
+func NewTracerProvider(p TracerProviderParams) (trace.TracerProvider, error) {
+	if p.Config.Endpoint == "" {
+		return trace.NewNoopTracerProvider(), nil
+	}
+
+	options := []otlptracehttp.Option{otlptracehttp.WithEndpoint(p.Config.Endpoint)}
+	if p.Config.Insecure {
+		options = append(options, otlptracehttp.WithInsecure())
+	}
+	exporter, err := otlptracehttp.New(context.Background(), options...)
+	if err != nil {
+		return nil, err
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(p.Config.SamplerRatio))),
+		sdktrace.WithResource(resource.NewSchemaless(
+			attribute.String("service.name", config.GetPackageName()),
+		)),
+	)
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{},
+		propagation.Baggage{},
+	))
+
+	p.Lifecycle.Append(fx.Hook{
+		OnStop: func(ctx context.Context) error {
+			return provider.Shutdown(ctx)
+		},
+	})
+	return provider, nil
+}
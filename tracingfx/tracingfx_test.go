@@ -0,0 +1,169 @@
+package tracingfx_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/fx/fxtest"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+
+	"github.com/prismedic/scalpel/loggerfx"
+	"github.com/prismedic/scalpel/tracingfx"
+)
+
+func TestNewTracerProvider(t *testing.T) {
+	defer otel.SetTracerProvider(otel.GetTracerProvider())
+	defer otel.SetTextMapPropagator(otel.GetTextMapPropagator())
+
+	t.Run("Test no-op without endpoint", func(t *testing.T) {
+		lifecycle := fxtest.NewLifecycle(t)
+		provider, err := tracingfx.NewTracerProvider(tracingfx.TracerProviderParams{
+			Lifecycle: lifecycle,
+			Config:    &tracingfx.TracingConfig{},
+		})
+		if err != nil {
+			t.Fatalf("failed to create tracer provider: %v", err)
+		}
+		_, span := provider.Tracer("test").Start(context.Background(), "span")
+		defer span.End()
+		if span.IsRecording() {
+			t.Errorf("expected a span not recording without endpoint")
+		}
+	})
+	t.Run("Test export on stop", func(t *testing.T) {
+		var exports atomic.Int32
+		receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path == "/v1/traces" {
+				exports.Add(1)
+			}
+		}))
+		defer receiver.Close()
+
+		lifecycle := fxtest.NewLifecycle(t)
+		provider, err := tracingfx.NewTracerProvider(tracingfx.TracerProviderParams{
+			Lifecycle: lifecycle,
+			Config: &tracingfx.TracingConfig{
+				Endpoint:     strings.TrimPrefix(receiver.URL, "http://"),
+				Insecure:     true,
+				SamplerRatio: 1,
+			},
+		})
+		if err != nil {
+			t.Fatalf("failed to create tracer provider: %v", err)
+		}
+		if otel.GetTracerProvider() != provider {
+			t.Errorf("expected the global tracer provider to be set")
+		}
+		lifecycle.RequireStart()
+		_, span := provider.Tracer("test").Start(context.Background(), "span")
+		if !span.IsRecording() {
+			t.Errorf("expected a recording span with a sampler ratio of 1")
+		}
+		span.End()
+		// the batcher is flushed by the shutdown of the provider
+		lifecycle.RequireStop()
+		if exports.Load() == 0 {
+			t.Errorf("expected the span to be exported on stop")
+		}
+	})
+}
+
+func TestTracingMiddleware(t *testing.T) {
+	defer otel.SetTextMapPropagator(otel.GetTextMapPropagator())
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+
+	core, logs := observer.New(zapcore.InfoLevel)
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(loggerfx.NewContextMiddleware(zap.New(core).Sugar()), tracingfx.NewTracingMiddleware(provider))
+	router.GET("/orders/:id", func(c *gin.Context) {
+		loggerfx.WithContext(c.Request.Context()).Info("get order")
+		c.String(http.StatusOK, "ok")
+	})
+	router.GET("/fail", func(c *gin.Context) {
+		c.Status(http.StatusInternalServerError)
+	})
+	serve := func(path string, header http.Header) sdktrace.ReadOnlySpan {
+		request := httptest.NewRequest(http.MethodGet, path, nil)
+		for key, values := range header {
+			request.Header[key] = values
+		}
+		router.ServeHTTP(httptest.NewRecorder(), request)
+		spans := recorder.Ended()
+		if len(spans) == 0 {
+			t.Fatalf("no span ended for %s", path)
+		}
+		return spans[len(spans)-1]
+	}
+
+	t.Run("Test span name", func(t *testing.T) {
+		span := serve("/orders/42", nil)
+		if span.Name() != "GET /orders/:id" {
+			t.Errorf("unexpected span name, got %s, expected %s", span.Name(), "GET /orders/:id")
+		}
+		if span.SpanKind() != trace.SpanKindServer {
+			t.Errorf("unexpected span kind, got %s", span.SpanKind())
+		}
+		attributes := attribute.NewSet(span.Attributes()...)
+		if value, _ := attributes.Value("http.target"); value.AsString() != "/orders/42" {
+			t.Errorf("unexpected http.target, got %s", value.AsString())
+		}
+		if value, _ := attributes.Value("http.status_code"); value.AsInt64() != http.StatusOK {
+			t.Errorf("unexpected http.status_code, got %d", value.AsInt64())
+		}
+		if span.Status().Code != codes.Unset {
+			t.Errorf("unexpected span status, got %s", span.Status().Code)
+		}
+	})
+	t.Run("Test server error status", func(t *testing.T) {
+		span := serve("/fail", nil)
+		if span.Status().Code != codes.Error {
+			t.Errorf("unexpected span status, got %s, expected %s", span.Status().Code, codes.Error)
+		}
+	})
+	t.Run("Test unmatched route", func(t *testing.T) {
+		if span := serve("/missing", nil); span.Name() != "GET unmatched" {
+			t.Errorf("unexpected span name, got %s, expected %s", span.Name(), "GET unmatched")
+		}
+	})
+	t.Run("Test propagation", func(t *testing.T) {
+		header := http.Header{}
+		header.Set("traceparent", "00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-01")
+		span := serve("/orders/42", header)
+		if got := span.SpanContext().TraceID().String(); got != "0af7651916cd43dd8448eb211c80319c" {
+			t.Errorf("unexpected trace ID, got %s", got)
+		}
+		if got := span.Parent().SpanID().String(); got != "b7ad6b7169203331" || !span.Parent().IsRemote() {
+			t.Errorf("unexpected parent span, got %s", got)
+		}
+	})
+	t.Run("Test trace fields in logs", func(t *testing.T) {
+		logs.TakeAll()
+		span := serve("/orders/42", nil)
+		entries := logs.TakeAll()
+		if len(entries) != 1 {
+			t.Fatalf("unexpected number of entries, got %d, expected 1", len(entries))
+		}
+		fields := entries[0].ContextMap()
+		if fields["trace_id"] != span.SpanContext().TraceID().String() || fields["span_id"] != span.SpanContext().SpanID().String() {
+			t.Errorf("unexpected trace fields %v, expected the IDs of span %s", fields, span.SpanContext().SpanID())
+		}
+	})
+}
@@ -0,0 +1,103 @@
+package scalpel
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/go-playground/validator/v10"
+	"go.uber.org/fx"
+	"go.uber.org/multierr"
+	"gopkg.in/yaml.v3"
+
+	"github.com/prismedic/scalpel/config"
+	"github.com/prismedic/scalpel/httpfx"
+	"github.com/prismedic/scalpel/infofx"
+	"github.com/prismedic/scalpel/loggerfx"
+	"github.com/prismedic/scalpel/metricsfx"
+	"github.com/prismedic/scalpel/routerfx"
+	"github.com/prismedic/scalpel/shutdownfx"
+)
+
+// ValidateConfigEnv enables the config validation mode, e.g. VALIDATE_CONFIG=1, it is prefixed with config.EnvPrefix
+const ValidateConfigEnv = "VALIDATE_CONFIG"
+
+// ValidateConfigRequested reports whether the config validation mode is enabled with the env variable
+func ValidateConfigRequested() bool {
+	name := ValidateConfigEnv
+	if prefix := strings.TrimSuffix(config.EnvPrefix, "_"); prefix != "" {
+		name = prefix + "_" + name
+	}
+	enabled, _ := strconv.ParseBool(os.Getenv(name))
+	return enabled
+}
+
+// moduleConfigs are the configs of the modules in Module, the ones not provided by the app are skipped
+type moduleConfigs struct {
+	fx.In
+	Validate *validator.Validate
+	Health   *infofx.HealthConfig       `optional:"true"`
+	Http     *httpfx.HttpConfig         `optional:"true"`
+	Logs     *loggerfx.LoggerConfig     `optional:"true"`
+	Metrics  *metricsfx.MetricsConfig   `optional:"true"`
+	Router   *routerfx.Config           `optional:"true"`
+	Shutdown *shutdownfx.ShutdownConfig `optional:"true"`
+}
+
+// ValidateConfig resolves the configs provided by the options, e.g. the config module of the app, without running the app.
+// Only the constructors of the configs and the validator are called, no module is started.
+// The configs of the modules are validated with the validations of the modules, e.g. loglevel, and the ones provided
+// by the options with config.AsValidation, then the effective configs are written as YAML. The options must not include Module, which registers the validators itself.
+func ValidateConfig(writer io.Writer, options ...fx.Option) error {
+	var configs moduleConfigs
+	app := fx.New(
+		fx.NopLogger,
+		fx.Options(options...),
+		config.ValidationModule,
+		loggerfx.Validations,
+		httpfx.Validations,
+		fx.Invoke(func(c moduleConfigs) {
+			configs = c
+		}),
+	)
+	if err := app.Err(); err != nil {
+		return fmt.Errorf("error in resolving config: %w", err)
+	}
+
+	effectiveConfig := map[string]any{}
+	addConfig := func(key string, value any, provided bool) {
+		if provided {
+			effectiveConfig[key] = value
+		}
+	}
+	addConfig("health", configs.Health, configs.Health != nil)
+	addConfig("http", configs.Http, configs.Http != nil)
+	addConfig("logs", configs.Logs, configs.Logs != nil)
+	addConfig("metrics", configs.Metrics, configs.Metrics != nil)
+	addConfig("router", configs.Router, configs.Router != nil)
+	addConfig("shutdown", configs.Shutdown, configs.Shutdown != nil)
+
+	// sorted like the keys of the YAML output
+	keys := make([]string, 0, len(effectiveConfig))
+	for key := range effectiveConfig {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	var errs error
+	for _, key := range keys {
+		errs = multierr.Append(errs, config.ValidateStruct(configs.Validate, key, effectiveConfig[key]))
+	}
+
+	encoder := yaml.NewEncoder(writer)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(effectiveConfig); err != nil {
+		return fmt.Errorf("error in writing config: %w", err)
+	}
+	if errs != nil {
+		return fmt.Errorf("config is invalid: %w", errs)
+	}
+	return nil
+}
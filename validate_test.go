@@ -0,0 +1,56 @@
+package scalpel_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/go-playground/validator/v10"
+	"go.uber.org/fx"
+
+	"github.com/prismedic/scalpel"
+	"github.com/prismedic/scalpel/loggerfx"
+	"github.com/prismedic/scalpel/shutdownfx"
+)
+
+func newTestLoggerConfig(t *testing.T) *loggerfx.LoggerConfig {
+	config := &loggerfx.LoggerConfig{}
+	config.File.Path = t.TempDir()
+	config.File.Name = "server.log"
+	config.File.Format = loggerfx.JSONFormat
+	config.Console.Format = loggerfx.ConsoleFormat
+	config.Console.Output = loggerfx.StderrOutput
+	config.StacktraceLevel = loggerfx.ErrorLevel
+	return config
+}
+
+func TestValidateConfig(t *testing.T) {
+	t.Run("Test valid config", func(t *testing.T) {
+		var output bytes.Buffer
+		err := scalpel.ValidateConfig(&output, fx.Provide(validator.New), fx.Supply(newTestLoggerConfig(t), &shutdownfx.ShutdownConfig{}))
+		if err != nil {
+			t.Fatalf("unexpected validation error: %v", err)
+		}
+		for _, expected := range []string{"logs:\n", "  file:\n", "shutdown:\n"} {
+			if !strings.Contains(output.String(), expected) {
+				t.Errorf("%q not found in effective config %s", expected, output.String())
+			}
+		}
+		if strings.Contains(output.String(), "http:") {
+			t.Errorf("config not provided by the app found in effective config %s", output.String())
+		}
+	})
+	t.Run("Test invalid log level", func(t *testing.T) {
+		config := newTestLoggerConfig(t)
+		config.File.Level = "verbose"
+		err := scalpel.ValidateConfig(&bytes.Buffer{}, fx.Provide(validator.New), fx.Supply(config))
+		if err == nil || !strings.Contains(err.Error(), "logs.file.level: must be a valid loglevel") {
+			t.Errorf("unexpected validation error: %v", err)
+		}
+	})
+	t.Run("Test missing validator", func(t *testing.T) {
+		if err := scalpel.ValidateConfig(&bytes.Buffer{}, fx.Supply(newTestLoggerConfig(t))); err == nil {
+			t.Errorf("expected an error without a validator")
+		}
+	})
+}